@@ -11,6 +11,18 @@ interface CodeSnippetProps {
   filePath: string;
   highlightedHTML: string;
   url: string;
+  signature?: string;
+  qualifiedIdentifier?: string;
+  revisions?: string[];
+  license?: string;
+  role?: string;
+  usedBy?: string[];
+}
+
+const COMMIT_ID_REGEX = /^[0-9a-f]{40}$/;
+
+function formatRevision(revision: string): string {
+  return COMMIT_ID_REGEX.test(revision) ? revision.slice(0, 7) : revision;
 }
 
 export const CodeSnippet: React.FunctionComponent<CodeSnippetProps> = ({
@@ -18,6 +30,12 @@ export const CodeSnippet: React.FunctionComponent<CodeSnippetProps> = ({
   filePath,
   highlightedHTML,
   url,
+  signature,
+  qualifiedIdentifier,
+  revisions,
+  license,
+  role,
+  usedBy,
 }) => {
   const fileName = useMemo(() => {
     const filePathSplit = filePath.split("/");
@@ -37,8 +55,29 @@ export const CodeSnippet: React.FunctionComponent<CodeSnippetProps> = ({
         <img src={githubMark} alt="GitHub Mark" width="16" height="17" />
         <a href={url}>
           {repoistoryNameStripped} &middot; <strong>{fileName}</strong>
+          {qualifiedIdentifier && (
+            <>
+              {" "}
+              &middot; <code className="code-snippet-identifier">{qualifiedIdentifier}</code>
+            </>
+          )}
         </a>
+        {role && role !== "production" && (
+          <span className="code-snippet-role">{role}</span>
+        )}
+        {license && <span className="code-snippet-license">{license}</span>}
       </div>
+      {signature && <div className="code-snippet-signature">{signature}</div>}
+      {revisions && revisions.length > 1 && (
+        <div className="code-snippet-revisions">
+          Found in {revisions.map(formatRevision).join(", ")}
+        </div>
+      )}
+      {usedBy && usedBy.length > 0 && (
+        <div className="code-snippet-used-by">
+          Used by {usedBy.join(", ")}
+        </div>
+      )}
       <SimpleBar style={{ maxHeight: 500 }}>
         <div className="code-snippet-highlighted-code">
           <pre>
@@ -7,6 +7,12 @@ export interface HighlightedFunction {
   endLine: number;
   highlightedHTML: string;
   url: string;
+  signature: string;
+  qualifiedIdentifier: string;
+  revisions: string[];
+  license: string;
+  role: string;
+  usedBy: string[];
 }
 
 export interface SOQuestion {
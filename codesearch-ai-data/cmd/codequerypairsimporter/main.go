@@ -2,7 +2,9 @@ package main
 
 import (
 	cqpi "codesearch-ai-data/internal/codequerypairsimporter"
+	"codesearch-ai-data/internal/coderoles"
 	"codesearch-ai-data/internal/database"
+	fe "codesearch-ai-data/internal/functionextractor"
 	"context"
 	"flag"
 	"math/rand"
@@ -15,10 +17,25 @@ func main() {
 
 	importSO := flag.Bool("so", false, "Import SO questions")
 	importExtractedFunctions := flag.Bool("extracted-functions", false, "Import extracted functions")
+	importExtractedTypes := flag.Bool("extracted-types", false, "Import extracted types")
+	extractedFunctionsVisibility := flag.String("extracted-functions-visibility", "", "Only import extracted functions with the visibility (e.g. public), imports all extracted functions if empty")
+	extractedFunctionsRoles := flag.String("extracted-functions-roles", "", "Comma separated roles (production, test, benchmark, example) to only import extracted functions with these roles, imports all roles if empty")
+	extractedFunctionsExcludeRoles := flag.String("extracted-functions-exclude-roles", "", "Comma separated roles (e.g. test,benchmark) of the extracted functions that are not imported")
+	extractedFunctionsMetrics := flag.String("extracted-functions-metrics", "", "Comma separated bounds on the metrics of the imported extracted functions (e.g. token_count>=20,cyclomatic_complexity<=50), on token_count, cyclomatic_complexity, max_nesting_depth, parameter_count or comment_ratio")
+	nearDuplicateRepresentativesOnly := flag.Bool("near-duplicate-representatives-only", false, "Only import the representative extracted function of each near-duplicate cluster")
 	soTrainTestRatio := flag.Float64("so-train-test-ratio", 0.95, "SO train test ratio")
 
 	flag.Parse()
 
+	roles, err := coderoles.ParseFilter(*extractedFunctionsRoles, *extractedFunctionsExcludeRoles)
+	if err != nil {
+		log.Fatal(err)
+	}
+	metrics, err := fe.ParseMetricsFilter(*extractedFunctionsMetrics)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	ctx := context.Background()
 	conn, err := database.ConnectToDatabase(ctx)
 	if err != nil {
@@ -41,7 +58,15 @@ func main() {
 
 	if *importExtractedFunctions {
 		log.Info("Importing extracted functions code query pairs")
-		err = cqpi.ImportExtractedFunctionsCodeQueryPairs(ctx, conn)
+		err = cqpi.ImportExtractedFunctionsCodeQueryPairs(ctx, conn, *extractedFunctionsVisibility, roles, metrics, *nearDuplicateRepresentativesOnly)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *importExtractedTypes {
+		log.Info("Importing extracted types code query pairs")
+		err = cqpi.ImportExtractedTypesCodeQueryPairs(ctx, conn)
 		if err != nil {
 			log.Fatal(err)
 		}
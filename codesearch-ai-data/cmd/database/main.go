@@ -1,7 +1,7 @@
 package main
 
 import (
-	"codesearch-ai-data/internal/database"
+	"codesearch-ai-data/internal/storage"
 	"context"
 	"flag"
 
@@ -15,24 +15,24 @@ func main() {
 	flag.Parse()
 
 	ctx := context.Background()
-	conn, err := database.ConnectToDatabase(ctx)
+	store, err := storage.OpenFromEnv(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer func() {
-		err := conn.Close(ctx)
+		err := store.Close(ctx)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}()
 
 	if initializeSchema != nil && *initializeSchema {
-		err = database.InitializeDatabaseSchema(ctx, conn)
+		err = store.InitializeSchema(ctx)
 		if err != nil {
 			log.Fatal(err)
 		}
 	} else if resetSchema != nil && *resetSchema {
-		err = database.ResetDatabaseSchema(ctx, conn)
+		err = store.ResetSchema(ctx)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -2,24 +2,91 @@ package main
 
 import (
 	"codesearch-ai-data/internal/database"
+	"codesearch-ai-data/internal/extractionpolicy"
 	"codesearch-ai-data/internal/functionextractor"
 	"context"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v4"
 	log "github.com/sirupsen/logrus"
 )
 
+type processRepoFn func(ctx context.Context, output *extractionOutput, repoLine string) error
+
+// repoLineNameFn returns the name of the repo of a repo list line, which keys its repo job.
+type repoLineNameFn func(repoLine string) (string, error)
+
+const (
+	postgresOutput = "postgres"
+	jsonlOutput    = "jsonl"
+)
+
+// extractionOutput is where a worker writes the extracted repos: the database, or a JSONL sink. Sinks write a single
+// snapshot at a time, so each worker has its own output.
+type extractionOutput struct {
+	conn *pgx.Conn
+	sink functionextractor.Sink
+}
+
+func newExtractionOutput(ctx context.Context, output string, outputDirectory string) (*extractionOutput, error) {
+	if output == jsonlOutput {
+		sink, err := functionextractor.NewJSONLSink(outputDirectory)
+		if err != nil {
+			return nil, err
+		}
+		return &extractionOutput{sink: sink}, nil
+	}
+
+	conn, err := database.ConnectToDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &extractionOutput{conn: conn}, nil
+}
+
+// repoJobOptions configure the repo jobs of repo lists, which are tracked with the postgres output.
+type repoJobOptions struct {
+	// resume skips the repos whose job succeeded in a previous run, and retries failed jobs with an exponential backoff
+	// until they reach maxAttempts attempts.
+	resume       bool
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+func (eo *extractionOutput) close(ctx context.Context) {
+	if eo.conn != nil {
+		eo.conn.Close(ctx)
+	}
+}
+
 func main() {
 	rand.Seed(0)
 
 	repoName := flag.String("repo-name", "", "Name of the repository to process")
 	repoNamesFilePath := flag.String("repo-names-file", "", "Path to the repo names file")
+	repoPath := flag.String("repo-path", "", "Path to a local checkout or bare repository to process (the repo name defaults to the directory name, override it with -repo-name)")
+	repoPathsFilePath := flag.String("repo-paths-file", "", "Path to a file with one local repository per line, formatted as '<path> [<repo name>]'")
+	repoRef := flag.String("repo-ref", "", "Ref to extract from local repositories (defaults to HEAD)")
+	snapshotRefsFlag := flag.String("snapshot-refs", "", "Comma separated refs (e.g. release tags) to extract as additional snapshots of the repos, instead of extracting their latest commit")
+	update := flag.Bool("update", false, "Update repos that were already extracted to their latest commit instead of skipping them")
 	nWorkers := flag.Int("n-workers", 4, "Number of workers to process the repo names")
+	nFileWorkers := flag.Int("n-file-workers", runtime.NumCPU(), "Number of workers to extract the files of each repo")
+	policyPath := flag.String("policy", "", "Path to a JSON extraction policy with file and function thresholds, path globs and identifier blocklists (defaults to the built-in policy)")
+	output := flag.String("output", postgresOutput, "Where to write the extracted functions: postgres, or jsonl to write a JSONL file per snapshot to -output-directory without a database")
+	outputDirectory := flag.String("output-directory", "extracted-functions", "Directory of the JSONL files with -output=jsonl")
+	loadJSONLPath := flag.String("load-jsonl", "", "Path to a JSONL snapshot file, or a directory of them, written with -output=jsonl to insert into the database")
+	resume := flag.Bool("resume", false, "Resume the repo list of a previous run: skip the repos that were extracted, and retry the failed ones with a backoff")
+	maxAttempts := flag.Int("max-attempts", 3, "Number of attempts to extract each repo with -resume, across runs")
+	retryBackoff := flag.Duration("retry-backoff", time.Minute, "Backoff before retrying a failed repo with -resume, doubled after each attempt")
 	debug := flag.Bool("debug", false, "Enable debug logging")
 
 	flag.Parse()
@@ -29,68 +96,348 @@ func main() {
 	}
 
 	ctx := context.Background()
+	snapshotRefs := parseSnapshotRefs(*snapshotRefsFlag)
 
-	if repoName != nil && *repoName != "" {
-		conn, err := database.ConnectToDatabase(ctx)
+	if loadJSONLPath != nil && *loadJSONLPath != "" {
+		err := loadJSONL(ctx, *loadJSONLPath)
 		if err != nil {
 			log.Fatal(err)
 		}
-		defer conn.Close(ctx)
+		return
+	}
+
+	if *output != postgresOutput && *output != jsonlOutput {
+		log.Fatalf("Unknown output %q, expected %s or %s", *output, postgresOutput, jsonlOutput)
+	}
+	if *output == jsonlOutput && (*update || len(snapshotRefs) > 0) {
+		log.Fatal("-update and -snapshot-refs require the postgres output")
+	}
+	if *output == jsonlOutput && *resume {
+		log.Fatal("-resume requires the postgres output")
+	}
+	jobOptions := &repoJobOptions{resume: *resume, maxAttempts: *maxAttempts, retryBackoff: *retryBackoff}
+
+	policy, err := extractionpolicy.Load(*policyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Infof("Extracting with policy %s", policy.Hash())
 
-		err = functionextractor.ProcessRepo(ctx, conn, *repoName)
+	if repoPath != nil && *repoPath != "" {
+		extractionOutput, err := newExtractionOutput(ctx, *output, *outputDirectory)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer extractionOutput.close(ctx)
+
+		name := *repoName
+		if name == "" {
+			name = localRepoName(*repoPath)
+		}
+
+		if len(snapshotRefs) > 0 {
+			err = functionextractor.ProcessLocalRepoSnapshots(ctx, extractionOutput.conn, name, *repoPath, snapshotRefs, *nFileWorkers, policy)
+		} else if extractionOutput.sink != nil {
+			err = functionextractor.ExtractLocalRepo(ctx, extractionOutput.sink, name, *repoPath, *repoRef, *nFileWorkers, policy)
+		} else {
+			err = functionextractor.ProcessLocalRepo(ctx, extractionOutput.conn, name, *repoPath, *repoRef, *update, *nFileWorkers, policy)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else if repoName != nil && *repoName != "" {
+		extractionOutput, err := newExtractionOutput(ctx, *output, *outputDirectory)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer extractionOutput.close(ctx)
+
+		if len(snapshotRefs) > 0 {
+			err = functionextractor.ProcessRepoSnapshots(ctx, extractionOutput.conn, *repoName, snapshotRefs, *nFileWorkers, policy)
+		} else if extractionOutput.sink != nil {
+			err = functionextractor.ExtractRepo(ctx, extractionOutput.sink, *repoName, *nFileWorkers, policy)
+		} else {
+			err = functionextractor.ProcessRepo(ctx, extractionOutput.conn, *repoName, *update, *nFileWorkers, policy)
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
 	} else if repoNamesFilePath != nil && *repoNamesFilePath != "" {
-		processRepoListFile(ctx, *nWorkers, *repoNamesFilePath)
+		processRepoListFile(ctx, *nWorkers, *repoNamesFilePath, *output, *outputDirectory, jobOptions, remoteRepoName, processRemoteRepoFn(*update, snapshotRefs, *nFileWorkers, policy))
+	} else if repoPathsFilePath != nil && *repoPathsFilePath != "" {
+		processRepoListFile(ctx, *nWorkers, *repoPathsFilePath, *output, *outputDirectory, jobOptions, localRepoLineName, processLocalRepoFn(*repoRef, *update, snapshotRefs, *nFileWorkers, policy))
 	} else {
-		log.Fatal("Provide a valid -repo-name or a valid -repo-list-path command line arguments")
+		log.Fatal("Provide a valid -repo-name, -repo-names-file, -repo-path or -repo-paths-file command line arguments")
 	}
 }
 
-func processRepoListFile(ctx context.Context, nWorkers int, repoNamesFilePath string) {
-	repoJobs := make(chan string, nWorkers)
+func localRepoName(repoPath string) string {
+	return strings.TrimSuffix(filepath.Base(filepath.Clean(repoPath)), ".git")
+}
+
+// parseLocalRepoLine parses a repo paths line formatted as '<path> [<repo name>]'.
+func parseLocalRepoLine(repoLine string) (string, string, error) {
+	fields := strings.Fields(repoLine)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", "", fmt.Errorf("invalid repo paths line: %s", repoLine)
+	}
+
+	repoPath := fields[0]
+	repoName := localRepoName(repoPath)
+	if len(fields) == 2 {
+		repoName = fields[1]
+	}
+	return repoPath, repoName, nil
+}
+
+func localRepoLineName(repoLine string) (string, error) {
+	_, repoName, err := parseLocalRepoLine(repoLine)
+	return repoName, err
+}
+
+func remoteRepoName(repoLine string) (string, error) {
+	return strings.TrimSpace(repoLine), nil
+}
+
+func parseSnapshotRefs(snapshotRefsFlag string) []string {
+	snapshotRefs := []string{}
+	for _, ref := range strings.Split(snapshotRefsFlag, ",") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			snapshotRefs = append(snapshotRefs, ref)
+		}
+	}
+	return snapshotRefs
+}
+
+func processRemoteRepoFn(update bool, snapshotRefs []string, nFileWorkers int, policy *extractionpolicy.Policy) processRepoFn {
+	return func(ctx context.Context, output *extractionOutput, repoName string) error {
+		time.Sleep(time.Duration(1+rand.Intn(10)) * time.Second)
+		log.Infof("Started processing %s", repoName)
+		if len(snapshotRefs) > 0 {
+			return functionextractor.ProcessRepoSnapshots(ctx, output.conn, repoName, snapshotRefs, nFileWorkers, policy)
+		}
+		if output.sink != nil {
+			return functionextractor.ExtractRepo(ctx, output.sink, repoName, nFileWorkers, policy)
+		}
+		return functionextractor.ProcessRepo(ctx, output.conn, repoName, update, nFileWorkers, policy)
+	}
+}
+
+func processLocalRepoFn(ref string, update bool, snapshotRefs []string, nFileWorkers int, policy *extractionpolicy.Policy) processRepoFn {
+	return func(ctx context.Context, output *extractionOutput, repoLine string) error {
+		repoPath, repoName, err := parseLocalRepoLine(repoLine)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Started processing %s (%s)", repoName, repoPath)
+		if len(snapshotRefs) > 0 {
+			return functionextractor.ProcessLocalRepoSnapshots(ctx, output.conn, repoName, repoPath, snapshotRefs, nFileWorkers, policy)
+		}
+		if output.sink != nil {
+			return functionextractor.ExtractLocalRepo(ctx, output.sink, repoName, repoPath, ref, nFileWorkers, policy)
+		}
+		return functionextractor.ProcessLocalRepo(ctx, output.conn, repoName, repoPath, ref, update, nFileWorkers, policy)
+	}
+}
+
+type repoListLine struct {
+	line     string
+	repoName string
+}
+
+func processRepoListFile(ctx context.Context, nWorkers int, repoListFilePath string, output string, outputDirectory string, jobOptions *repoJobOptions, getRepoName repoLineNameFn, processRepo processRepoFn) {
+	repoListFile, err := ioutil.ReadFile(repoListFilePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repoLines := []*repoListLine{}
+	repoNames := []string{}
+	seenRepoNames := map[string]bool{}
+	for _, line := range strings.Split(string(repoListFile), "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		repoName, err := getRepoName(line)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		// Workers would extract a repo listed twice concurrently, and its second job would start over from the
+		// repo that the first job is extracting.
+		if seenRepoNames[repoName] {
+			log.Warnf("Skipping duplicate repo %s", repoName)
+			continue
+		}
+		seenRepoNames[repoName] = true
+		repoLines = append(repoLines, &repoListLine{line: line, repoName: repoName})
+		repoNames = append(repoNames, repoName)
+	}
+
+	if output == postgresOutput {
+		err := enqueueRepoJobs(ctx, repoNames, jobOptions.resume)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	queue := newRepoQueue(nWorkers)
 
 	wg := &sync.WaitGroup{}
 	for w := 0; w < nWorkers; w++ {
 		wg.Add(1)
-		go repoWorker(ctx, repoJobs, wg)
+		go repoWorker(ctx, queue, output, outputDirectory, jobOptions, processRepo, wg)
 	}
 
+	queue.enqueue(repoLines)
+	wg.Wait()
+}
+
+// repoQueue feeds the repo lines to the workers. Lines of failed attempts are requeued once their retry backoff has
+// elapsed, so workers keep processing the other lines in the meantime. The queue is closed once every line is done.
+type repoQueue struct {
+	lines   chan *repoListLine
+	pending sync.WaitGroup
+}
+
+func newRepoQueue(nWorkers int) *repoQueue {
+	return &repoQueue{lines: make(chan *repoListLine, nWorkers)}
+}
+
+func (q *repoQueue) enqueue(repoLines []*repoListLine) {
+	q.pending.Add(len(repoLines))
 	go func() {
-		repoNamesFile, err := ioutil.ReadFile(repoNamesFilePath)
-		if err != nil {
-			log.Fatal(err)
+		for _, repoLine := range repoLines {
+			q.lines <- repoLine
 		}
-		repoNames := strings.Split(string(repoNamesFile), "\n")
-		for _, repoName := range repoNames {
-			if len(strings.TrimSpace(repoName)) == 0 {
-				continue
-			}
-			repoJobs <- repoName
+	}()
+	go func() {
+		q.pending.Wait()
+		close(q.lines)
+	}()
+}
+
+// retry requeues the line after the backoff, or marks it as done if ctx is done before.
+func (q *repoQueue) retry(ctx context.Context, repoLine *repoListLine, backoff time.Duration) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			q.done()
+		case <-time.After(backoff):
+			q.lines <- repoLine
 		}
-		close(repoJobs)
 	}()
+}
 
-	wg.Wait()
+func (q *repoQueue) done() {
+	q.pending.Done()
 }
 
-func repoWorker(ctx context.Context, repoJobs <-chan string, wg *sync.WaitGroup) {
+func enqueueRepoJobs(ctx context.Context, repoNames []string, keepExisting bool) error {
 	conn, err := database.ConnectToDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	return functionextractor.EnqueueRepoJobs(ctx, conn, repoNames, keepExisting)
+}
+
+func repoWorker(ctx context.Context, queue *repoQueue, output string, outputDirectory string, jobOptions *repoJobOptions, processRepo processRepoFn, wg *sync.WaitGroup) {
+	extractionOutput, err := newExtractionOutput(ctx, output, outputDirectory)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer func() {
-		conn.Close(ctx)
+		extractionOutput.close(ctx)
 		wg.Done()
 	}()
 
-	for repoName := range repoJobs {
-		time.Sleep(time.Duration(1+rand.Intn(10)) * time.Second)
-		log.Infof("Started processing %s", repoName)
-		err := functionextractor.ProcessRepo(ctx, conn, repoName)
+	for repoLine := range queue.lines {
+		var err error
+		retry, backoff := false, time.Duration(0)
+		if extractionOutput.conn != nil {
+			retry, backoff, err = runRepoJob(ctx, extractionOutput, repoLine, jobOptions, processRepo)
+		} else {
+			err = processRepo(ctx, extractionOutput, repoLine.line)
+		}
+		if err != nil {
+			log.Error(err)
+		}
+
+		if retry {
+			queue.retry(ctx, repoLine, backoff)
+		} else {
+			queue.done()
+		}
+	}
+}
+
+// runRepoJob runs an attempt of the repo job of the line. With resume, repos whose job succeeded or ran out of
+// attempts are skipped, and a failed attempt has to be retried after the returned backoff.
+func runRepoJob(ctx context.Context, output *extractionOutput, repoLine *repoListLine, jobOptions *repoJobOptions, processRepo processRepoFn) (bool, time.Duration, error) {
+	if jobOptions.resume {
+		job, err := functionextractor.GetRepoJob(ctx, output.conn, repoLine.repoName)
+		if err != nil {
+			return false, 0, err
+		}
+		if job != nil && job.Status == functionextractor.RepoJobSucceeded {
+			log.Infof("Skipping %s, it was extracted by a previous run", repoLine.repoName)
+			return false, 0, nil
+		}
+		if job != nil && job.Status == functionextractor.RepoJobFailed && job.Attempts >= jobOptions.maxAttempts {
+			log.Infof("Skipping %s, it failed %d times: %s", repoLine.repoName, job.Attempts, job.Error)
+			return false, 0, nil
+		}
+	}
+
+	attempts, err := functionextractor.StartRepoJob(ctx, output.conn, repoLine.repoName)
+	if err != nil {
+		return false, 0, err
+	}
+
+	start := time.Now()
+	jobErr := processRepo(ctx, output, repoLine.line)
+	err = functionextractor.FinishRepoJob(ctx, output.conn, repoLine.repoName, time.Since(start), jobErr)
+	if err != nil {
+		return false, 0, err
+	}
+	if jobErr == nil || !jobOptions.resume || attempts >= jobOptions.maxAttempts {
+		return false, 0, jobErr
+	}
+
+	backoff := jobOptions.retryBackoff * time.Duration(1<<(attempts-1))
+	log.Warnf("Attempt %d of %s failed, retrying in %s: %v", attempts, repoLine.repoName, backoff, jobErr)
+	return true, backoff, nil
+}
+
+// loadJSONL inserts the JSONL snapshot file at the path, or every JSONL snapshot file of the directory at the path,
+// into the database. Snapshots that fail to load are logged and skipped.
+func loadJSONL(ctx context.Context, path string) error {
+	paths := []string{path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		paths, err = filepath.Glob(filepath.Join(path, "*.jsonl"))
+		if err != nil {
+			return err
+		}
+	}
+
+	conn, err := database.ConnectToDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	for _, snapshotPath := range paths {
+		log.Infof("Loading %s", snapshotPath)
+		err := functionextractor.LoadJSONLSnapshot(ctx, conn, snapshotPath)
 		if err != nil {
 			log.Error(err)
 		}
 	}
+	return nil
 }
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"codesearch-ai-data/internal/database"
+	"codesearch-ai-data/internal/neardup"
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	ctx := context.Background()
+	conn, err := database.ConnectToDatabase(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close(ctx)
+
+	log.Info("Clustering near-duplicate extracted functions")
+	err = neardup.ClusterExtractedFunctions(ctx, conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
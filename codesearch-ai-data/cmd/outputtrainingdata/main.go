@@ -1,11 +1,15 @@
 package main
 
 import (
-	cqpi "codesearch-ai-data/internal/codequerypairsimporter"
+	"codesearch-ai-data/internal/coderoles"
 	"codesearch-ai-data/internal/database"
+	fe "codesearch-ai-data/internal/functionextractor"
+	"codesearch-ai-data/internal/licenses"
+	"codesearch-ai-data/internal/storage"
 	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"os"
 	"path"
 	"strings"
@@ -19,6 +23,17 @@ type codeQueryPairsOptions struct {
 	IsTrain                *bool
 	SOOnly                 bool
 	ExtractedFunctionsOnly bool
+	ExtractedTypesOnly     bool
+	// AllowedLicenses are the license expressions extracted functions and types have to be licensed under. Nil
+	// allows every license.
+	AllowedLicenses []string
+	// AllowedRoles are the roles extracted functions have to have. Nil allows every role.
+	AllowedRoles []string
+	// FunctionMetrics bounds the metrics of extracted functions.
+	FunctionMetrics fe.MetricsFilter
+	// NearDuplicateRepresentativesOnly keeps a single pair, with the lowest ID among the pairs matching the other
+	// options, of each near-duplicate cluster.
+	NearDuplicateRepresentativesOnly bool
 }
 
 func (o *codeQueryPairsOptions) Condition() string {
@@ -34,37 +49,68 @@ func (o *codeQueryPairsOptions) Condition() string {
 		conds = append(conds, "so_question_id is not null")
 	} else if o.ExtractedFunctionsOnly {
 		conds = append(conds, "extracted_function_id is not null")
+	} else if o.ExtractedTypesOnly {
+		conds = append(conds, "extracted_type_id is not null")
+	}
+
+	if o.AllowedLicenses != nil {
+		// A function or type is allowed if any of its occurrences is under an allowed license. Pairs from SO questions
+		// are not covered by repo licenses.
+		allowedLicenses := database.StringArrayLiteral(o.AllowedLicenses)
+		conds = append(conds, fmt.Sprintf(`(so_question_id IS NOT NULL
+OR EXISTS (SELECT 1 FROM extracted_function_snapshots efs JOIN repo_snapshots s ON s.id = efs.snapshot_id WHERE efs.extracted_function_id = code_query_pairs.extracted_function_id AND COALESCE(NULLIF(efs.license, ''), s.license) = ANY(%[1]s))
+OR EXISTS (SELECT 1 FROM extracted_type_snapshots ets JOIN repo_snapshots s ON s.id = ets.snapshot_id WHERE ets.extracted_type_id = code_query_pairs.extracted_type_id AND COALESCE(NULLIF(ets.license, ''), s.license) = ANY(%[1]s)))`, allowedLicenses))
+	}
+
+	if o.AllowedRoles != nil {
+		conds = append(conds, fmt.Sprintf("(extracted_function_id IS NULL OR EXISTS (SELECT 1 FROM extracted_functions ef WHERE ef.id = code_query_pairs.extracted_function_id AND ef.role = ANY(%s)))", database.StringArrayLiteral(o.AllowedRoles)))
+	}
+
+	if o.FunctionMetrics != nil {
+		conds = append(conds, fmt.Sprintf("(extracted_function_id IS NULL OR EXISTS (SELECT 1 FROM extracted_functions ef WHERE ef.id = code_query_pairs.extracted_function_id AND %s))", o.FunctionMetrics.Condition("ef")))
 	}
 
 	if len(conds) == 0 {
-		return "1=1"
+		conds = append(conds, "1=1")
+	}
+	cond := strings.Join(conds, " AND ")
+
+	// The representative is picked among the pairs matching the other conditions, a cluster is not dropped because
+	// its lowest pair is filtered out. The subquery does not depend on the page, so it picks the same representatives
+	// on every page.
+	if o.NearDuplicateRepresentativesOnly {
+		cond += fmt.Sprintf(` AND (near_duplicate_cluster_id IS NULL OR id IN (SELECT DISTINCT ON (near_duplicate_cluster_id) id FROM code_query_pairs
+WHERE near_duplicate_cluster_id IS NOT NULL AND %s
+ORDER BY near_duplicate_cluster_id, id))`, cond)
 	}
-	return strings.Join(conds, " AND ")
+	return cond
 }
 
-func newCodeQueryPairsPaginator(conn *pgx.Conn, pageSize int, options *codeQueryPairsOptions) *database.Paginator[cqpi.CodeQueryPair] {
-	return &database.Paginator[cqpi.CodeQueryPair]{
+func newCodeQueryPairsPaginator(conn *pgx.Conn, pageSize int, options *codeQueryPairsOptions) *database.Paginator[storage.CodeQueryPair] {
+	return &database.Paginator[storage.CodeQueryPair]{
 		Conn:          conn,
 		AfterID:       0,
 		PageSize:      pageSize,
-		BaseQuery:     "SELECT id, code, query, so_question_id, extracted_function_id FROM code_query_pairs",
+		BaseQuery:     "SELECT id, code, query, so_question_id, extracted_function_id, extracted_type_id, near_duplicate_cluster_id FROM code_query_pairs",
 		BaseCondition: options.Condition(),
 		IDColumn:      "id",
-		ScanRow: func(rows pgx.Rows) (*cqpi.CodeQueryPair, error) {
-			cqp := &cqpi.CodeQueryPair{}
+		ScanRow: func(rows pgx.Rows) (*storage.CodeQueryPair, error) {
+			cqp := &storage.CodeQueryPair{}
 			err := rows.Scan(
 				&cqp.ID,
 				&cqp.Code,
 				&cqp.Query,
 				&cqp.SOQuestionID,
 				&cqp.ExtractedFunctionID,
+				&cqp.ExtractedTypeID,
+				&cqp.NearDuplicateClusterID,
 			)
 			if err != nil {
 				return nil, err
 			}
 			return cqp, nil
 		},
-		GetRowID: func(row *cqpi.CodeQueryPair) int { return row.ID },
+		GetRowID: func(row *storage.CodeQueryPair) int { return row.ID },
 	}
 }
 
@@ -89,9 +135,15 @@ func outputCodeQueryPairsToFile(ctx context.Context, conn *pgx.Conn, options *co
 			if cqp.ExtractedFunctionID == nil {
 				cqp.ExtractedFunctionID = &zero
 			}
+			if cqp.ExtractedTypeID == nil {
+				cqp.ExtractedTypeID = &zero
+			}
 			if cqp.SOQuestionID == nil {
 				cqp.SOQuestionID = &zero
 			}
+			if cqp.NearDuplicateClusterID == nil {
+				cqp.NearDuplicateClusterID = &zero
+			}
 			b, err := json.Marshal(cqp)
 			if err != nil {
 				return err
@@ -104,12 +156,73 @@ func outputCodeQueryPairsToFile(ctx context.Context, conn *pgx.Conn, options *co
 	return nil
 }
 
+// functionCalls are the functions of the repo of an extracted function that it calls.
+type functionCalls struct {
+	CallerFunctionID  int   `json:"callerFunctionId"`
+	CalleeFunctionIDs []int `json:"calleeFunctionIds"`
+}
+
+func newFunctionCallsPaginator(conn *pgx.Conn, pageSize int) *database.Paginator[functionCalls] {
+	return &database.Paginator[functionCalls]{
+		Conn:          conn,
+		AfterID:       0,
+		PageSize:      pageSize,
+		BaseQuery:     "SELECT caller_id, array_agg(DISTINCT callee_id) FROM extracted_function_calls",
+		BaseCondition: "callee_id IS NOT NULL AND callee_id <> caller_id",
+		GroupByColumn: "caller_id",
+		IDColumn:      "caller_id",
+		ScanRow: func(rows pgx.Rows) (*functionCalls, error) {
+			fc := &functionCalls{}
+			err := rows.Scan(
+				&fc.CallerFunctionID,
+				&fc.CalleeFunctionIDs,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return fc, nil
+		},
+		GetRowID: func(row *functionCalls) int { return row.CallerFunctionID },
+	}
+}
+
+// outputFunctionCallsToFile outputs the resolved calls of the extracted functions, to pair the functions of code
+// query pairs with the functions they call.
+func outputFunctionCallsToFile(ctx context.Context, conn *pgx.Conn, outputPath string) error {
+	fo, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer fo.Close()
+
+	paginator := newFunctionCallsPaginator(conn, 100_000)
+	newline := []byte("\n")
+	for page := paginator.Next(ctx); len(page) > 0; page = paginator.Next(ctx) {
+		for _, fc := range page {
+			b, err := json.Marshal(fc)
+			if err != nil {
+				return err
+			}
+			fo.Write(b)
+			fo.Write(newline)
+		}
+	}
+	return paginator.Error()
+}
+
 func main() {
 	outputTrain := flag.Bool("train", false, "Output train")
 	outputTest := flag.Bool("test", false, "Output test")
 	outputSO := flag.Bool("so", false, "Output SO questions")
 	outputExtractedFunctions := flag.Bool("extracted-functions", false, "Output extracted functions")
+	outputExtractedTypes := flag.Bool("extracted-types", false, "Output extracted types")
+	outputFunctionCalls := flag.Bool("function-calls", false, "Output the extracted functions called by each extracted function")
 	outputDirectory := flag.String("output-directory", "/tmp", "Output directory for the training files")
+	nearDuplicateRepresentativesOnly := flag.Bool("near-duplicate-representatives-only", false, "Only output one pair of each near-duplicate cluster")
+	licenseAllowlist := flag.String("license-allowlist", "", "Comma separated SPDX license IDs (e.g. MIT,Apache-2.0) to only output extracted functions and types under these licenses")
+	roles := flag.String("roles", "", "Comma separated roles (production, test, benchmark, example) to only output extracted functions with these roles")
+	excludeRoles := flag.String("exclude-roles", "", "Comma separated roles (e.g. test,benchmark) of the extracted functions that are not output")
+	functionMetrics := flag.String("function-metrics", "", "Comma separated bounds on the metrics of the output extracted functions (e.g. token_count>=20,cyclomatic_complexity<=50), on token_count, cyclomatic_complexity, max_nesting_depth, parameter_count or comment_ratio")
 
 	flag.Parse()
 
@@ -120,11 +233,34 @@ func main() {
 	}
 	defer conn.Close(ctx)
 
+	var allowedLicenses []string
+	if allowlist := licenses.ParseAllowlist(*licenseAllowlist); allowlist != nil {
+		allowedLicenses, err = database.GetAllowedLicenses(ctx, conn, allowlist)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("Outputting extracted functions and types licensed under: %s", strings.Join(allowedLicenses, ", "))
+	}
+
+	roleFilter, err := coderoles.ParseFilter(*roles, *excludeRoles)
+	if err != nil {
+		log.Fatal(err)
+	}
+	allowedRoles := roleFilter.Allowed()
+	if allowedRoles != nil {
+		log.Infof("Outputting extracted functions with roles: %s", strings.Join(allowedRoles, ", "))
+	}
+
+	metricsFilter, err := fe.ParseMetricsFilter(*functionMetrics)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	t := true
 	f := false
 	if *outputTrain {
 		log.Info("Outputting train.jsonl file")
-		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{IsTrain: &t}, path.Join(*outputDirectory, "train.jsonl"))
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{IsTrain: &t, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "train.jsonl"))
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -132,7 +268,7 @@ func main() {
 
 	if *outputTest {
 		log.Info("Outputting test.jsonl file")
-		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{IsTrain: &f}, path.Join(*outputDirectory, "test.jsonl"))
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{IsTrain: &f, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "test.jsonl"))
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -140,19 +276,19 @@ func main() {
 
 	if *outputSO {
 		log.Info("Outputting so.jsonl file")
-		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{SOOnly: true}, path.Join(*outputDirectory, "so.train.jsonl"))
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{SOOnly: true, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "so.train.jsonl"))
 		if err != nil {
 			log.Fatal(err)
 		}
 
 		log.Info("Outputting so.train.jsonl file")
-		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{SOOnly: true, IsTrain: &t}, path.Join(*outputDirectory, "so.train.jsonl"))
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{SOOnly: true, IsTrain: &t, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "so.train.jsonl"))
 		if err != nil {
 			log.Fatal(err)
 		}
 
 		log.Info("Outputting so.test.jsonl file")
-		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{SOOnly: true, IsTrain: &f}, path.Join(*outputDirectory, "so.test.jsonl"))
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{SOOnly: true, IsTrain: &f, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "so.test.jsonl"))
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -160,19 +296,47 @@ func main() {
 
 	if *outputExtractedFunctions {
 		log.Info("Outputting extracted-functions.jsonl file")
-		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{ExtractedFunctionsOnly: true}, path.Join(*outputDirectory, "extracted-functions.jsonl"))
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{ExtractedFunctionsOnly: true, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "extracted-functions.jsonl"))
 		if err != nil {
 			log.Fatal(err)
 		}
 
 		log.Info("Outputting extracted-functions.train.jsonl file")
-		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{ExtractedFunctionsOnly: true, IsTrain: &t}, path.Join(*outputDirectory, "extracted-functions.train.jsonl"))
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{ExtractedFunctionsOnly: true, IsTrain: &t, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "extracted-functions.train.jsonl"))
 		if err != nil {
 			log.Fatal(err)
 		}
 
 		log.Info("Outputting extracted-functions.test.jsonl file")
-		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{ExtractedFunctionsOnly: true, IsTrain: &f}, path.Join(*outputDirectory, "extracted-functions.test.jsonl"))
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{ExtractedFunctionsOnly: true, IsTrain: &f, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "extracted-functions.test.jsonl"))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *outputExtractedTypes {
+		log.Info("Outputting extracted-types.jsonl file")
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{ExtractedTypesOnly: true, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "extracted-types.jsonl"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Info("Outputting extracted-types.train.jsonl file")
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{ExtractedTypesOnly: true, IsTrain: &t, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "extracted-types.train.jsonl"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Info("Outputting extracted-types.test.jsonl file")
+		err = outputCodeQueryPairsToFile(ctx, conn, &codeQueryPairsOptions{ExtractedTypesOnly: true, IsTrain: &f, AllowedLicenses: allowedLicenses, AllowedRoles: allowedRoles, FunctionMetrics: metricsFilter, NearDuplicateRepresentativesOnly: *nearDuplicateRepresentativesOnly}, path.Join(*outputDirectory, "extracted-types.test.jsonl"))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *outputFunctionCalls {
+		log.Info("Outputting function-calls.jsonl file")
+		err = outputFunctionCallsToFile(ctx, conn, path.Join(*outputDirectory, "function-calls.jsonl"))
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -1,8 +1,8 @@
 package main
 
 import (
-	"codesearch-ai-data/internal/database"
 	"codesearch-ai-data/internal/soimporter"
+	"codesearch-ai-data/internal/storage"
 	"context"
 	"flag"
 
@@ -19,18 +19,18 @@ func main() {
 	}
 
 	ctx := context.Background()
-	conn, err := database.ConnectToDatabase(ctx)
+	store, err := storage.OpenFromEnv(ctx)
 	if err != nil {
 		log.Fatal("Unable to connect to database", err)
 	}
 	defer func() {
-		err := conn.Close(ctx)
+		err := store.Close(ctx)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}()
 
-	err = soimporter.Import(ctx, conn, *postsXmlPath)
+	err = soimporter.Import(ctx, store, *postsXmlPath)
 	if err != nil {
 		log.Fatal(err)
 	}
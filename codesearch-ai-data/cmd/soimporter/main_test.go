@@ -7,6 +7,7 @@ import (
 
 	"codesearch-ai-data/internal/database"
 	"codesearch-ai-data/internal/soimporter"
+	"codesearch-ai-data/internal/storage"
 
 	"github.com/jackc/pgx/v4"
 )
@@ -33,7 +34,7 @@ func TestPostsXmlFileImport(t *testing.T) {
 		}
 	}()
 
-	err = soimporter.Import(ctx, conn, "./testdata/Posts.xml")
+	err = soimporter.Import(ctx, storage.NewPostgresStore(conn), "./testdata/Posts.xml")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1,12 +1,15 @@
 package main
 
 import (
-	"codesearch-ai-data/internal/database"
+	"codesearch-ai-data/internal/coderoles"
+	"codesearch-ai-data/internal/languages"
+	"codesearch-ai-data/internal/licenses"
+	"codesearch-ai-data/internal/storage"
 	"codesearch-ai-data/internal/web"
 	"context"
 	"encoding/json"
 	"net/http"
-	"regexp"
+	"os"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -14,8 +17,6 @@ import (
 
 const MAX_RESULTS = 20
 
-var languagesRegexp = regexp.MustCompile(`(?i)\b(python|java|javascript|js|py|go|golang|ruby|php)\b`)
-
 func sliceQuery(query string) string {
 	trimmedQuery := strings.TrimSpace(query)
 	if len(trimmedQuery) > 512 {
@@ -28,53 +29,65 @@ func transformCodeQuery(codeQuery string) string {
 	return strings.ReplaceAll(codeQuery, "\t", " ")
 }
 
-func findLanguage(query string) string {
-	return strings.ToLower(languagesRegexp.FindString(query))
+func splitSOTags(tags string) []string {
+	return strings.Split(strings.Trim(tags, "<>"), "><")
 }
 
-func languageToFileExtension(language string) string {
-	switch language {
-	case "python", "py":
-		return "py"
-	case "javascript", "js":
-		return "js"
-	case "ruby":
-		return "rb"
-	case "java":
-		return "java"
-	case "php":
-		return "php"
-	case "go", "golang":
-		return "go"
-	}
-	return ""
+// Comma separated SPDX license IDs that function and type results have to be licensed under, all licenses are
+// allowed if it is not set.
+var licenseAllowlist = licenses.ParseAllowlist(os.Getenv("LICENSE_ALLOWLIST"))
+
+func filterAllowedLicenses[T any](results []*T, getLicense func(result *T) string) []*T {
+	if licenseAllowlist == nil {
+		return results
+	}
+
+	allowedResults := make([]*T, 0, len(results))
+	for _, result := range results {
+		if result != nil && licenseAllowlist.Allows(getLicense(result)) {
+			allowedResults = append(allowedResults, result)
+		}
+	}
+	return allowedResults
 }
 
-func languageToSOTag(language string) string {
-	switch language {
-	case "python", "py":
-		return "python"
-	case "javascript", "js":
-		return "javascript"
-	case "ruby":
-		return "ruby"
-	case "java":
-		return "java"
-	case "php":
-		return "php"
-	case "go", "golang":
-		return "go"
-	}
-	return ""
+// getRoleFilter parses the comma separated roles that function results have to have (roles) or not have
+// (excludeRoles). Every role is allowed if neither is set.
+func getRoleFilter(r *http.Request) (coderoles.Filter, error) {
+	return coderoles.ParseFilter(r.URL.Query().Get("roles"), r.URL.Query().Get("excludeRoles"))
 }
 
+func filterAllowedRoles(results []*web.HighlightedExtractedFunction, roles coderoles.Filter) []*web.HighlightedExtractedFunction {
+	if roles == nil {
+		return results
+	}
+
+	allowedResults := make([]*web.HighlightedExtractedFunction, 0, len(results))
+	for _, result := range results {
+		if result != nil && roles.Allows(coderoles.Role(result.Role)) {
+			allowedResults = append(allowedResults, result)
+		}
+	}
+	return allowedResults
+}
+
+func functionLicense(hef *web.HighlightedExtractedFunction) string { return hef.License }
+
+func typeLicense(het *web.HighlightedExtractedType) string { return het.License }
+
 func searchFunctionsByTextHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
-	conn, err := database.ConnectToDatabase(ctx)
+	store, err := storage.OpenFromEnv(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer conn.Close(ctx)
+	defer store.Close(ctx)
+
+	roles, err := getRoleFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	query := sliceQuery(r.URL.Query().Get("query"))
 	searchResults, err := search("functions", "text", query, MAX_RESULTS*3)
@@ -83,17 +96,17 @@ func searchFunctionsByTextHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := web.GetExtractedFunctionsByID(ctx, conn, searchResults.IDs)
+	results, err := store.GetExtractedFunctionsByID(ctx, searchResults.IDs)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	results = filterAllowedRoles(filterAllowedLicenses(results, functionLicense), roles)
 
-	language := findLanguage(query)
-	languageExtension := languageToFileExtension(language)
+	language := languages.FindInQuery(query)
 	filteredResults := make([]*web.HighlightedExtractedFunction, 0, MAX_RESULTS)
 	for _, result := range results {
-		if strings.HasSuffix(result.FilePath, languageExtension) {
+		if language == nil || language.HasFileExtension(result.FilePath) {
 			filteredResults = append(filteredResults, result)
 		}
 		if len(filteredResults) == MAX_RESULTS {
@@ -109,11 +122,17 @@ func searchFunctionsByTextHandler(w http.ResponseWriter, r *http.Request) {
 
 func searchFunctionsByCodeHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
-	conn, err := database.ConnectToDatabase(ctx)
+	store, err := storage.OpenFromEnv(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer conn.Close(ctx)
+	defer store.Close(ctx)
+
+	roles, err := getRoleFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	query := transformCodeQuery(sliceQuery(r.URL.Query().Get("query")))
 	searchResults, err := search("functions", "code", query, MAX_RESULTS)
@@ -122,11 +141,12 @@ func searchFunctionsByCodeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := web.GetExtractedFunctionsByID(ctx, conn, searchResults.IDs)
+	results, err := store.GetExtractedFunctionsByID(ctx, searchResults.IDs)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	results = filterAllowedRoles(filterAllowedLicenses(results, functionLicense), roles)
 	highlightCodeLineRanges(results)
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -134,13 +154,80 @@ func searchFunctionsByCodeHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(results)
 }
 
+func searchTypesByTextHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	store, err := storage.OpenFromEnv(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close(ctx)
+
+	query := sliceQuery(r.URL.Query().Get("query"))
+	searchResults, err := search("types", "text", query, MAX_RESULTS*3)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	results, err := store.GetExtractedTypesByID(ctx, searchResults.IDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	results = filterAllowedLicenses(results, typeLicense)
+
+	language := languages.FindInQuery(query)
+	filteredResults := make([]*web.HighlightedExtractedType, 0, MAX_RESULTS)
+	for _, result := range results {
+		if language == nil || language.HasFileExtension(result.FilePath) {
+			filteredResults = append(filteredResults, result)
+		}
+		if len(filteredResults) == MAX_RESULTS {
+			break
+		}
+	}
+	highlightTypeCodeLineRanges(filteredResults)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(filteredResults)
+}
+
+func searchTypesByCodeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	store, err := storage.OpenFromEnv(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close(ctx)
+
+	query := transformCodeQuery(sliceQuery(r.URL.Query().Get("query")))
+	searchResults, err := search("types", "code", query, MAX_RESULTS)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	results, err := store.GetExtractedTypesByID(ctx, searchResults.IDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	results = filterAllowedLicenses(results, typeLicense)
+	highlightTypeCodeLineRanges(results)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
+
 func searchSOByTextHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
-	conn, err := database.ConnectToDatabase(ctx)
+	store, err := storage.OpenFromEnv(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer conn.Close(ctx)
+	defer store.Close(ctx)
 
 	query := sliceQuery(r.URL.Query().Get("query"))
 	searchResults, err := search("so", "text", query, MAX_RESULTS*3)
@@ -149,17 +236,16 @@ func searchSOByTextHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := web.GetSOQuestionsWithAnswersByID(ctx, conn, searchResults.IDs)
+	results, err := store.GetSOQuestionsWithAnswersByID(ctx, searchResults.IDs)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	language := findLanguage(query)
-	languageTag := languageToSOTag(language)
+	language := languages.FindInQuery(query)
 	filteredResults := make([]*web.SOQuestionWithAnswers, 0, MAX_RESULTS)
 	for _, result := range results {
-		if languageTag == "" || strings.Contains(strings.ToLower(result.Tags), languageTag) {
+		if language == nil || language.MatchesAnySOTag(splitSOTags(result.Tags)) {
 			filteredResults = append(filteredResults, result)
 		}
 		if len(filteredResults) == MAX_RESULTS {
@@ -174,11 +260,11 @@ func searchSOByTextHandler(w http.ResponseWriter, r *http.Request) {
 
 func searchSOByCodeHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
-	conn, err := database.ConnectToDatabase(ctx)
+	store, err := storage.OpenFromEnv(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer conn.Close(ctx)
+	defer store.Close(ctx)
 
 	query := transformCodeQuery(sliceQuery(r.URL.Query().Get("query")))
 	searchResults, err := search("so", "code", query, MAX_RESULTS)
@@ -187,7 +273,7 @@ func searchSOByCodeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := web.GetSOQuestionsWithAnswersByID(ctx, conn, searchResults.IDs)
+	results, err := store.GetSOQuestionsWithAnswersByID(ctx, searchResults.IDs)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -17,6 +17,10 @@ func main() {
 		r.HandleFunc("/api/search/functions/by-text", mockSearchHandler("functions")).Methods("GET", "OPTIONS")
 		r.HandleFunc("/api/search/functions/by-code", mockSearchHandler("functions")).Methods("GET", "OPTIONS")
 
+		r.HandleFunc("/api/search/types", mockSearchHandler("types")).Methods("GET", "OPTIONS")
+		r.HandleFunc("/api/search/types/by-text", mockSearchHandler("types")).Methods("GET", "OPTIONS")
+		r.HandleFunc("/api/search/types/by-code", mockSearchHandler("types")).Methods("GET", "OPTIONS")
+
 		r.HandleFunc("/api/search/so/by-text", mockSearchHandler("so")).Methods("GET", "OPTIONS")
 		r.HandleFunc("/api/search/so/by-code", mockSearchHandler("so")).Methods("GET", "OPTIONS")
 	} else {
@@ -27,6 +31,11 @@ func main() {
 		r.HandleFunc("/api/search/functions/by-text", searchFunctionsByTextHandler).Methods("GET", "OPTIONS")
 		r.HandleFunc("/api/search/functions/by-code", searchFunctionsByCodeHandler).Methods("GET", "OPTIONS")
 
+		// Searching types defaults to searching by text.
+		r.HandleFunc("/api/search/types", searchTypesByTextHandler).Methods("GET", "OPTIONS")
+		r.HandleFunc("/api/search/types/by-text", searchTypesByTextHandler).Methods("GET", "OPTIONS")
+		r.HandleFunc("/api/search/types/by-code", searchTypesByCodeHandler).Methods("GET", "OPTIONS")
+
 		r.HandleFunc("/api/search/so/by-text", searchSOByTextHandler).Methods("GET", "OPTIONS")
 		r.HandleFunc("/api/search/so/by-code", searchSOByCodeHandler).Methods("GET", "OPTIONS")
 
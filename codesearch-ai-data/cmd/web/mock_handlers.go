@@ -1,8 +1,7 @@
 package main
 
 import (
-	"codesearch-ai-data/internal/database"
-	"codesearch-ai-data/internal/web"
+	"codesearch-ai-data/internal/storage"
 	"context"
 	"encoding/json"
 	"net/http"
@@ -14,25 +13,34 @@ import (
 func mockSearchHandler(dataSource string) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := context.Background()
-		conn, err := database.ConnectToDatabase(ctx)
+		store, err := storage.OpenFromEnv(ctx)
 		if err != nil {
 			log.Fatal(err)
 		}
-		defer conn.Close(ctx)
+		defer store.Close(ctx)
 
 		var results any
 		if dataSource == "functions" {
 			ids := []int{1, 100, 1000}
-			hefs, err := web.GetExtractedFunctionsByID(ctx, conn, ids)
+			hefs, err := store.GetExtractedFunctionsByID(ctx, ids)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
 			highlightCodeLineRanges(hefs)
 			results = hefs
+		} else if dataSource == "types" {
+			ids := []int{1, 100, 1000}
+			hets, err := store.GetExtractedTypesByID(ctx, ids)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			highlightTypeCodeLineRanges(hets)
+			results = hets
 		} else if dataSource == "so" {
 			ids := []int{1006395, 1243079, 1163074}
-			results, err = web.GetSOQuestionsWithAnswersByID(ctx, conn, ids)
+			results, err = store.GetSOQuestionsWithAnswersByID(ctx, ids)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
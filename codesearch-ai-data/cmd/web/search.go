@@ -75,3 +75,11 @@ func highlightCodeLineRanges(hefs []*web.HighlightedExtractedFunction) {
 	}
 	wg.Wait()
 }
+
+func highlightTypeCodeLineRanges(hets []*web.HighlightedExtractedType) {
+	hefs := make([]*web.HighlightedExtractedFunction, 0, len(hets))
+	for _, het := range hets {
+		hefs = append(hefs, &het.HighlightedExtractedFunction)
+	}
+	highlightCodeLineRanges(hefs)
+}
@@ -1,9 +1,12 @@
 package codequerypairsimporter
 
 import (
+	"codesearch-ai-data/internal/coderoles"
 	"codesearch-ai-data/internal/database"
 	fe "codesearch-ai-data/internal/functionextractor"
+	"codesearch-ai-data/internal/storage"
 	"context"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -11,33 +14,61 @@ import (
 	"github.com/jackc/pgx/v4"
 )
 
-func newExtractedFunctionsPaginator(conn *pgx.Conn, pageSize int) *database.Paginator[fe.ExtractedFunction] {
+func newExtractedFunctionsPaginator(conn *pgx.Conn, pageSize int, visibility string, roles coderoles.Filter, metrics fe.MetricsFilter, representativesOnly bool) *database.Paginator[fe.ExtractedFunction] {
+	conditions := []string{}
+	if visibility != "" {
+		conditions = append(conditions, fmt.Sprintf("extracted_functions.visibility = '%s'", visibility))
+	}
+	if roles != nil {
+		conditions = append(conditions, fmt.Sprintf("extracted_functions.role = ANY(%s)", database.StringArrayLiteral(roles.Allowed())))
+	}
+	if metrics != nil {
+		conditions = append(conditions, metrics.Condition("extracted_functions"))
+	}
+	if representativesOnly {
+		// Functions that were not clustered yet are their own representatives.
+		conditions = append(conditions, "(extracted_functions.near_duplicate_cluster_id IS NULL OR extracted_functions.near_duplicate_cluster_id = extracted_functions.id)")
+	}
+	baseCondition := strings.Join(conditions, " AND ")
+
 	return &database.Paginator[fe.ExtractedFunction]{
-		Conn:      conn,
-		AfterID:   0,
-		PageSize:  pageSize,
-		BaseQuery: "SELECT extracted_functions.id, docstring, inline_comments, clean_code, identifier, is_train FROM extracted_functions JOIN repos r on r.id = extracted_functions.repo_id",
-		IDColumn:  "extracted_functions.id",
+		Conn:          conn,
+		AfterID:       0,
+		PageSize:      pageSize,
+		BaseQuery:     "SELECT extracted_functions.id, docstring, docstring_summary, inline_comments, clean_code, identifier, qualified_identifier, parameters, return_type, is_train, near_duplicate_cluster_id FROM extracted_functions JOIN repos r on r.id = extracted_functions.repo_id",
+		BaseCondition: baseCondition,
+		IDColumn:      "extracted_functions.id",
 		ScanRow: func(rows pgx.Rows) (*fe.ExtractedFunction, error) {
 			ef := &fe.ExtractedFunction{}
+			var parameters string
 			err := rows.Scan(
 				&ef.ID,
 				&ef.Docstring,
+				&ef.DocstringSummary,
 				&ef.InlineComments,
 				&ef.CleanCode,
 				&ef.Identifier,
+				&ef.QualifiedIdentifier,
+				&parameters,
+				&ef.Signature.ReturnType,
 				&ef.IsTrain,
+				&ef.NearDuplicateClusterID,
 			)
 			if err != nil {
 				return nil, err
 			}
+			err = ef.Signature.UnmarshalParameters(parameters)
+			if err != nil {
+				return nil, err
+			}
 			return ef, nil
 		},
 		GetRowID: func(row *fe.ExtractedFunction) int { return row.ID },
 	}
 }
 
-var DOT_UNDERSCORE_REGEX = regexp.MustCompile("[._]")
+// Identifiers are split on dots, underscores and the separators of qualified identifiers (e.g. `pkg.(*T).M`, `A\B::c`, `A#b`).
+var IDENTIFIER_SEPARATOR_REGEX = regexp.MustCompile(`[._:#\\()*]`)
 var IDENTIFIER_TOKEN_REGEX = regexp.MustCompile("[_a-zA-Z][_a-zA-Z0-9]*")
 
 func identifierToDocstring(identifier string) string {
@@ -45,7 +76,7 @@ func identifierToDocstring(identifier string) string {
 		return ""
 	}
 
-	parts := DOT_UNDERSCORE_REGEX.Split(identifier, -1)
+	parts := IDENTIFIER_SEPARATOR_REGEX.Split(identifier, -1)
 	validIdentifierParts := []string{}
 	for _, part := range parts {
 		camelCaseParts := camelcase.Split(part)
@@ -63,30 +94,72 @@ func identifierToDocstring(identifier string) string {
 	return strings.Join(validIdentifierParts, " ")
 }
 
-func extractedFunctionToCodeQueryPair(ef *fe.ExtractedFunction) *CodeQueryPair {
-	docstring := removeNonAsciiChars(ef.Docstring)
+// signatureToDocstring describes the parameter names and the return type of the signature.
+func signatureToDocstring(signature *fe.Signature) string {
+	parts := []string{}
+	for _, parameter := range signature.Parameters {
+		parts = append(parts, identifierToDocstring(parameter.Name))
+	}
+	if returnType := identifierToDocstring(signature.ReturnType); returnType != "" {
+		parts = append(parts, "returns", returnType)
+	}
+	return strings.Join(parts, " ")
+}
+
+func joinNonEmpty(parts ...string) string {
+	nonEmptyParts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if trimmedPart := strings.TrimSpace(part); trimmedPart != "" {
+			nonEmptyParts = append(nonEmptyParts, trimmedPart)
+		}
+	}
+	return strings.Join(nonEmptyParts, " ")
+}
+
+func extractedFunctionToCodeQueryPair(ef *fe.ExtractedFunction) *storage.CodeQueryPair {
+	identifier := ef.QualifiedIdentifier
+	if identifier == "" {
+		identifier = ef.Identifier
+	}
+
+	// The summary is free of tags and markup, docstrings stored before it was parsed only have the raw text.
+	docstring := removeNonAsciiChars(ef.DocstringSummary)
+	if len(docstring) == 0 {
+		docstring = removeNonAsciiChars(ef.Docstring)
+	}
 	if len(docstring) == 0 {
-		docstring = removeNonAsciiChars(identifierToDocstring(ef.Identifier) + " " + ef.InlineComments)
+		docstring = removeNonAsciiChars(joinNonEmpty(identifierToDocstring(identifier), signatureToDocstring(&ef.Signature), ef.InlineComments))
 	}
 
 	if strings.Count(docstring, " ") < 3 {
 		docstring = ""
 	}
 
-	return newCodeQueryPair(
+	cqp := newCodeQueryPair(
 		ef.CleanCode,
 		strings.TrimSpace(docstring),
 		ef.IsTrain,
 		nil,
 		&ef.ID,
+		nil,
 	)
+	cqp.NearDuplicateClusterID = ef.NearDuplicateClusterID
+	return cqp
 }
 
-func ImportExtractedFunctionsCodeQueryPairs(ctx context.Context, conn *pgx.Conn) error {
-	extractedFunctionsPaginator := newExtractedFunctionsPaginator(conn, 100_000)
+// ImportExtractedFunctionsCodeQueryPairs imports the extracted functions with the visibility, or all of them if the visibility is empty.
+// Only functions with a role allowed by the roles filter, and with metrics within the bounds of the metrics filter, are
+// imported, e.g. to leave out tests or trivial getters.
+// With representativesOnly, only the representative function of each near-duplicate cluster is imported.
+func ImportExtractedFunctionsCodeQueryPairs(ctx context.Context, conn *pgx.Conn, visibility string, roles coderoles.Filter, metrics fe.MetricsFilter, representativesOnly bool) error {
+	if visibility != "" && !fe.IsKnownVisibility(visibility) {
+		return fmt.Errorf("unknown visibility %s, expected one of %s", visibility, strings.Join(fe.Visibilities, ", "))
+	}
+
+	extractedFunctionsPaginator := newExtractedFunctionsPaginator(conn, 100_000, visibility, roles, metrics, representativesOnly)
 	extractedFunctionsPage := extractedFunctionsPaginator.Next(ctx)
 
-	pairsBuffer := make([]*CodeQueryPair, 0, BATCH_SIZE)
+	pairsBuffer := make([]*storage.CodeQueryPair, 0, BATCH_SIZE)
 	for len(extractedFunctionsPage) > 0 {
 		for _, ef := range extractedFunctionsPage {
 			pairsBuffer = append(pairsBuffer, extractedFunctionToCodeQueryPair(ef))
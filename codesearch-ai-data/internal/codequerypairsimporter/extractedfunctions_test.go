@@ -8,6 +8,8 @@ import (
 )
 
 func TestExtractedFunctionToCodeQueryPair(t *testing.T) {
+	nearDuplicateClusterID := 3
+
 	tests := []struct {
 		name string
 		ef   *fe.ExtractedFunction
@@ -20,6 +22,14 @@ func TestExtractedFunctionToCodeQueryPair(t *testing.T) {
 				Identifier:     "FunctionA",
 			},
 		},
+		{
+			name: "Extracted function with docstring summary",
+			ef: &fe.ExtractedFunction{
+				Docstring:        "Returns the {@link User} with the given id. Throws if it does not exist. @param id the user id",
+				DocstringSummary: "Returns the User with the given id.",
+				Identifier:       "getUser",
+			},
+		},
 		{
 			name: "Extracted function with unicode docstring",
 			ef: &fe.ExtractedFunction{
@@ -42,6 +52,17 @@ func TestExtractedFunctionToCodeQueryPair(t *testing.T) {
 				CleanCode:      "() => 1",
 			},
 		},
+		{
+			name: "Extracted function with signature",
+			ef: &fe.ExtractedFunction{
+				Identifier: "parseConfig",
+				Signature: fe.Signature{
+					Parameters: []fe.Parameter{{Name: "file_path", Type: "string"}, {Name: "strict", Type: "bool"}},
+					ReturnType: "*Config",
+				},
+				CleanCode: "func parseConfig(filePath string, strict bool) *Config {}",
+			},
+		},
 		{
 			name: "Extracted function with multi-cased identifier",
 			ef: &fe.ExtractedFunction{
@@ -49,6 +70,23 @@ func TestExtractedFunctionToCodeQueryPair(t *testing.T) {
 				CleanCode:  "() => 1",
 			},
 		},
+		{
+			name: "Extracted function with qualified identifier",
+			ef: &fe.ExtractedFunction{
+				Identifier:          "Handle",
+				QualifiedIdentifier: "server.(*Server).Handle",
+				InlineComments:      "Serve request",
+				CleanCode:           "func (s *Server) Handle() {}",
+			},
+		},
+		{
+			name: "Extracted function in a near-duplicate cluster",
+			ef: &fe.ExtractedFunction{
+				Identifier:             "loadUserProfile",
+				CleanCode:              "function loadUserProfile(id) { return fetch(id) }",
+				NearDuplicateClusterID: &nearDuplicateClusterID,
+			},
+		},
 	}
 
 	for _, tt := range tests {
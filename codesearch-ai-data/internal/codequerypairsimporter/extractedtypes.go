@@ -0,0 +1,99 @@
+package codequerypairsimporter
+
+import (
+	"codesearch-ai-data/internal/database"
+	fe "codesearch-ai-data/internal/functionextractor"
+	"codesearch-ai-data/internal/storage"
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func newExtractedTypesPaginator(conn *pgx.Conn, pageSize int) *database.Paginator[fe.ExtractedType] {
+	return &database.Paginator[fe.ExtractedType]{
+		Conn:      conn,
+		AfterID:   0,
+		PageSize:  pageSize,
+		BaseQuery: "SELECT extracted_types.id, kind, identifier, docstring, docstring_summary, members, clean_code, is_train FROM extracted_types JOIN repos r on r.id = extracted_types.repo_id",
+		IDColumn:  "extracted_types.id",
+		ScanRow: func(rows pgx.Rows) (*fe.ExtractedType, error) {
+			et := &fe.ExtractedType{}
+			var members string
+			err := rows.Scan(
+				&et.ID,
+				&et.Kind,
+				&et.Identifier,
+				&et.Docstring,
+				&et.DocstringSummary,
+				&members,
+				&et.CleanCode,
+				&et.IsTrain,
+			)
+			if err != nil {
+				return nil, err
+			}
+			et.Members = strings.Fields(members)
+			return et, nil
+		},
+		GetRowID: func(row *fe.ExtractedType) int { return row.ID },
+	}
+}
+
+func extractedTypeToCodeQueryPair(et *fe.ExtractedType) *storage.CodeQueryPair {
+	docstring := removeNonAsciiChars(et.DocstringSummary)
+	if len(docstring) == 0 {
+		docstring = removeNonAsciiChars(et.Docstring)
+	}
+	if len(docstring) == 0 {
+		memberDocstrings := make([]string, 0, len(et.Members))
+		for _, member := range et.Members {
+			memberDocstrings = append(memberDocstrings, identifierToDocstring(member))
+		}
+		docstring = removeNonAsciiChars(identifierToDocstring(et.Identifier) + " " + et.Kind + " " + strings.Join(memberDocstrings, " "))
+	}
+
+	if strings.Count(docstring, " ") < 3 {
+		docstring = ""
+	}
+
+	return newCodeQueryPair(
+		et.CleanCode,
+		strings.TrimSpace(docstring),
+		et.IsTrain,
+		nil,
+		nil,
+		&et.ID,
+	)
+}
+
+func ImportExtractedTypesCodeQueryPairs(ctx context.Context, conn *pgx.Conn) error {
+	extractedTypesPaginator := newExtractedTypesPaginator(conn, 100_000)
+	extractedTypesPage := extractedTypesPaginator.Next(ctx)
+
+	pairsBuffer := make([]*storage.CodeQueryPair, 0, BATCH_SIZE)
+	for len(extractedTypesPage) > 0 {
+		for _, et := range extractedTypesPage {
+			pairsBuffer = append(pairsBuffer, extractedTypeToCodeQueryPair(et))
+
+			if len(pairsBuffer) == BATCH_SIZE {
+				err := importCodeQueryPairs(ctx, conn, pairsBuffer)
+				if err != nil {
+					return err
+				}
+				pairsBuffer = pairsBuffer[:0]
+			}
+		}
+
+		extractedTypesPage = extractedTypesPaginator.Next(ctx)
+	}
+
+	if len(pairsBuffer) > 0 {
+		err := importCodeQueryPairs(ctx, conn, pairsBuffer)
+		if err != nil {
+			return err
+		}
+	}
+
+	return extractedTypesPaginator.Error()
+}
@@ -0,0 +1,59 @@
+package codequerypairsimporter
+
+import (
+	fe "codesearch-ai-data/internal/functionextractor"
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func TestExtractedTypeToCodeQueryPair(t *testing.T) {
+	tests := []struct {
+		name string
+		et   *fe.ExtractedType
+	}{
+		{
+			name: "Extracted type with docstring",
+			et: &fe.ExtractedType{
+				Kind:       "class",
+				Identifier: "LruCache",
+				Members:    []string{"capacity", "get", "put"},
+				Docstring:  "A cache that evicts the least recently used entries.",
+			},
+		},
+		{
+			name: "Extracted type with docstring summary",
+			et: &fe.ExtractedType{
+				Kind:             "class",
+				Identifier:       "UserRepository",
+				Docstring:        "Loads and stores <b>users</b> in the database. Thread-safe. @see User",
+				DocstringSummary: "Loads and stores users in the database.",
+			},
+		},
+		{
+			name: "Extracted type without docstring",
+			et: &fe.ExtractedType{
+				Kind:       "struct",
+				Identifier: "HTTPServer",
+				Members:    []string{"Addr", "ListenAndServe", "read_timeout"},
+			},
+		},
+		{
+			name: "Extracted type without docstring and members",
+			et: &fe.ExtractedType{
+				Kind:       "interface",
+				Identifier: "Shape",
+				CleanCode:  "type Shape interface{}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cqp := extractedTypeToCodeQueryPair(tt.et)
+			// Ignore in the autogold snapshot.
+			cqp.ExtractedTypeID = nil
+			autogold.Equal(t, cqp)
+		})
+	}
+}
@@ -2,9 +2,10 @@ package codequerypairsimporter
 
 import (
 	"codesearch-ai-data/internal/database"
+	"codesearch-ai-data/internal/languages"
 	ph "codesearch-ai-data/internal/parsinghelpers"
-	"codesearch-ai-data/internal/sitterparsers"
 	"codesearch-ai-data/internal/socode"
+	"codesearch-ai-data/internal/storage"
 	"context"
 	"errors"
 	"math/rand"
@@ -16,59 +17,6 @@ import (
 	sitter "github.com/smacker/go-tree-sitter"
 )
 
-var soTagToLanguage = map[string]string{
-	"java":       "java",
-	"python":     "python",
-	"php":        "php",
-	"ruby":       "ruby",
-	"javascript": "javascript",
-	"go":         "go",
-	"django":     "python",
-	"jquery":     "javascript",
-	"node.js":    "javascript",
-	"reactjs":    "javascript",
-	"spring":     "java",
-	"laravel":    "php",
-	"numpy":      "python",
-}
-
-// For things like python-3, python-2, ruby-on-rails, etc.
-var checkTagPrefix = map[string]bool{
-	"java":       false,
-	"python":     true,
-	"php":        false,
-	"ruby":       true,
-	"javascript": false,
-	"go":         false,
-	"django":     false,
-	"jquery":     false,
-	"node.js":    false,
-	"reactjs":    false,
-	"spring":     false,
-	"laravel":    false,
-	"numpy":      false,
-}
-
-func getLanguagesFromTags(tags []string) []string {
-	languagesMap := map[string]bool{}
-	for _, tag := range tags {
-		tagLower := strings.ToLower(tag)
-		for soTag, language := range soTagToLanguage {
-			if checkTagPrefix[soTag] && strings.HasPrefix(tagLower, soTag) {
-				languagesMap[language] = true
-			} else if !checkTagPrefix[soTag] && tagLower == soTag {
-				languagesMap[language] = true
-			}
-		}
-	}
-
-	languages := make([]string, 0, len(languagesMap))
-	for language := range languagesMap {
-		languages = append(languages, language)
-	}
-	return languages
-}
-
 type SOQuestionWithAnswers struct {
 	ID      int
 	Title   string
@@ -110,17 +58,9 @@ func newSOQuestionsPaginator(conn *pgx.Conn, pageSize int) *database.Paginator[S
 	}
 }
 
-func addPHPTagsIfMissing(codeText string) string {
-	if !strings.HasPrefix(codeText, "<?php") {
-		codeText = "<?php\n" + codeText
-	}
-	if !strings.HasSuffix(codeText, "?>") {
-		codeText = codeText + "\n?>"
-	}
-	return codeText
-}
-
-func getCodeAnswers(answers []*string, languages []string) ([]string, error) {
+// getCodeAnswers returns the code snippets of the answers, cleaned with the first of the question languages that
+// parses them.
+func getCodeAnswers(answers []*string, questionLanguages []*languages.Language) ([]string, error) {
 	codeAnswers := map[string]struct{}{}
 	codeAnswersDeduplicated := []string{}
 	for _, answer := range answers {
@@ -138,31 +78,16 @@ func getCodeAnswers(answers []*string, languages []string) ([]string, error) {
 			}
 			joinedCodeLines := strings.Join(codeLines, "\n")
 
-			for _, language := range languages {
-				codeText := joinedCodeLines
-				if language == "php" {
-					codeText = addPHPTagsIfMissing(codeText)
-				}
-
-				code := []byte(codeText)
-				parser := sitterparsers.GetParserForLanguage(language)
-				rootNode, err := tryParse(parser, code)
+			for _, language := range questionLanguages {
+				code := []byte(language.GetSnippetCode(joinedCodeLines))
+				rootNode, err := tryParse(language.NewParser(), code)
 				if err != nil {
 					continue
 				}
 
-				var skipNodeFn ph.SkipNodeFn = nil
-				if language == "python" {
-					docstringNodes, err := ph.GetPythonDocstringNodes(rootNode)
-					if err != nil {
-						continue
-					}
-					skipNodeFn = ph.SkipPythonDocstringNodesFn(docstringNodes)
-				} else if language == "php" {
-					skipNodeFn = func(node *sitter.Node) bool {
-						nodeType := node.Type()
-						return nodeType == "php_tag" || nodeType == "?>"
-					}
+				skipNodeFn, err := language.GetSkipNodeFn(rootNode)
+				if err != nil {
+					continue
 				}
 
 				filteredNodes, _ := ph.StripComments(rootNode, skipNodeFn)
@@ -184,32 +109,32 @@ func getCodeAnswers(answers []*string, languages []string) ([]string, error) {
 	return codeAnswersDeduplicated, nil
 }
 
-func questionToCodeQueryPair(ctx context.Context, conn *pgx.Conn, question *SOQuestionWithAnswers, isTrain bool) (*CodeQueryPair, error) {
+func questionToCodeQueryPair(ctx context.Context, conn *pgx.Conn, question *SOQuestionWithAnswers, isTrain bool) (*storage.CodeQueryPair, error) {
 	title := strings.TrimSpace(removeNonAsciiChars(question.Title))
 	if len(title) == 0 {
 		return nil, nil
 	}
 
 	tags := strings.Split(strings.TrimPrefix(strings.TrimSuffix(question.Tags, ">"), "<"), "><")
-	languages := getLanguagesFromTags(tags)
-	if len(languages) == 0 {
+	questionLanguages := languages.ForSOTags(tags)
+	if len(questionLanguages) == 0 {
 		return nil, nil
 	}
 
-	codeAnswers, err := getCodeAnswers(question.Answers, languages)
+	codeAnswers, err := getCodeAnswers(question.Answers, questionLanguages)
 	if err != nil || len(codeAnswers) == 0 {
 		return nil, err
 	}
 
 	codeAnswer := strings.Join(codeAnswers, "\n")
-	return newCodeQueryPair(codeAnswer, title, isTrain, &question.ID, nil), nil
+	return newCodeQueryPair(codeAnswer, title, isTrain, &question.ID, nil, nil), nil
 }
 
 func ImportSOCodeQueryPairs(ctx context.Context, conn *pgx.Conn, trainTestSplitRatio float64) error {
 	page := 1
 	questionsPaginator := newSOQuestionsPaginator(conn, 100_000)
 	questionsPage := questionsPaginator.Next(ctx)
-	pairsBuffer := make([]*CodeQueryPair, 0, BATCH_SIZE)
+	pairsBuffer := make([]*storage.CodeQueryPair, 0, BATCH_SIZE)
 	processedRows := 0
 	for len(questionsPage) > 0 {
 		log.Infof("Processing page %d, len %d", page, len(questionsPage))
@@ -1,6 +1,7 @@
 package codequerypairsimporter
 
 import (
+	"codesearch-ai-data/internal/storage"
 	"crypto/sha1"
 	"encoding/hex"
 	"strings"
@@ -9,16 +10,6 @@ import (
 
 const BATCH_SIZE = 10_000
 
-type CodeQueryPair struct {
-	ID                  int    `json:"id"`
-	Code                string `json:"code"`
-	CodeHash            string `json:"-"`
-	Query               string `json:"query"`
-	IsTrain             bool   `json:"-"`
-	SOQuestionID        *int   `json:"soQuestionId"`
-	ExtractedFunctionID *int   `json:"extractedFunctionId"`
-}
-
 func getSHA1Hash(text string) string {
 	hasher := sha1.New()
 	hasher.Write([]byte(text))
@@ -34,13 +25,14 @@ func removeNonAsciiChars(text string) string {
 	}, text)
 }
 
-func newCodeQueryPair(code string, query string, isTrain bool, soQuestionID *int, extractedFunctionID *int) *CodeQueryPair {
-	return &CodeQueryPair{
+func newCodeQueryPair(code string, query string, isTrain bool, soQuestionID *int, extractedFunctionID *int, extractedTypeID *int) *storage.CodeQueryPair {
+	return &storage.CodeQueryPair{
 		Code:                code,
 		CodeHash:            getSHA1Hash(code),
 		Query:               query,
 		IsTrain:             isTrain,
 		SOQuestionID:        soQuestionID,
 		ExtractedFunctionID: extractedFunctionID,
+		ExtractedTypeID:     extractedTypeID,
 	}
 }
@@ -0,0 +1,100 @@
+package coderoles
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Role is what a function is written for.
+type Role string
+
+const (
+	Production Role = "production"
+	Test       Role = "test"
+	Benchmark  Role = "benchmark"
+	Example    Role = "example"
+)
+
+// Roles are the known roles, in the order they are listed to users.
+var Roles = []Role{Production, Test, Benchmark, Example}
+
+// IsValid reports whether the role is a known role.
+func (r Role) IsValid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type pathRule struct {
+	role Role
+	// Directory names anywhere in the path.
+	directories []string
+	// Regexps matched against the file name.
+	fileNames []*regexp.Regexp
+}
+
+// File names are more specific than directories, e.g. benchmarks in a test directory, so they are matched first.
+var pathRules = []pathRule{
+	{
+		role: Benchmark,
+		fileNames: []*regexp.Regexp{
+			regexp.MustCompile(`_bench(mark)?s?\.\w+$`),
+			regexp.MustCompile(`\.bench\.[cm]?[jt]sx?$`),
+			regexp.MustCompile(`Benchmarks?\.(java|kt|cs)$`),
+		},
+	},
+	{
+		role: Test,
+		fileNames: []*regexp.Regexp{
+			regexp.MustCompile(`_test\.\w+$`),
+			regexp.MustCompile(`^test_.*\.py$`),
+			regexp.MustCompile(`^conftest\.py$`),
+			regexp.MustCompile(`\.(test|spec)\.[cm]?[jt]sx?$`),
+			regexp.MustCompile(`_spec\.rb$`),
+			regexp.MustCompile(`Tests?\.(java|kt|cs|php)$`),
+		},
+	},
+	{
+		role:        Test,
+		directories: []string{"test", "tests", "__tests__", "spec", "specs", "testdata", "testing", "__mocks__"},
+	},
+	{
+		role:        Benchmark,
+		directories: []string{"bench", "benches", "benchmark", "benchmarks"},
+	},
+	// Singular example directories are left out, they are common in package names (e.g. com.example).
+	{
+		role:        Example,
+		directories: []string{"examples", "_examples", "samples", "demos"},
+	},
+}
+
+// ForPath returns the role of the code in the file at the path, relative to the repo root, according to the naming
+// conventions of test, benchmark and example files. Directories closer to the file take precedence, e.g. tests of
+// an example are tests.
+func ForPath(relativePath string) Role {
+	fileName := path.Base(relativePath)
+	for _, rule := range pathRules {
+		for _, fileNameRegexp := range rule.fileNames {
+			if fileNameRegexp.MatchString(fileName) {
+				return rule.role
+			}
+		}
+	}
+
+	directories := strings.Split(path.Dir(relativePath), "/")
+	for i := len(directories) - 1; i >= 0; i-- {
+		for _, rule := range pathRules {
+			for _, directory := range rule.directories {
+				if directories[i] == directory {
+					return rule.role
+				}
+			}
+		}
+	}
+	return Production
+}
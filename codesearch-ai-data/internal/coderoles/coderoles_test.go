@@ -0,0 +1,82 @@
+package coderoles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want Role
+	}{
+		{path: "cmd/main.go", want: Production},
+		{path: "internal/parser/parser_test.go", want: Test},
+		{path: "tests/test_parser.py", want: Test},
+		{path: "src/test_utils.js", want: Production},
+		{path: "conftest.py", want: Test},
+		{path: "src/components/Button.test.tsx", want: Test},
+		{path: "spec/models/user_spec.rb", want: Test},
+		{path: "src/test/java/com/example/ParserTest.java", want: Test},
+		{path: "src/main/java/com/example/Parser.java", want: Production},
+		{path: "src/latest/index.js", want: Production},
+		{path: "benches/parse.rs", want: Benchmark},
+		{path: "src/jmh/java/ParserBenchmark.java", want: Benchmark},
+		{path: "test/parse_bench.c", want: Benchmark},
+		{path: "examples/server/main.go", want: Example},
+		{path: "examples/server/tests/server_test.py", want: Test},
+		{path: "tests/fixtures/examples/app.js", want: Example},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ForPath(tt.path); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		include string
+		exclude string
+		want    []string
+	}{
+		{name: "No roles", include: "", exclude: "", want: nil},
+		{name: "Included roles", include: "production, example", exclude: "", want: []string{"production", "example"}},
+		{name: "Excluded roles", include: "", exclude: "test,benchmark", want: []string{"production", "example"}},
+		{name: "Included and excluded roles", include: "test,example", exclude: "example", want: []string{"test"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filter.Allowed(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for _, role := range Roles {
+				if filter.Allows(role) != (tt.want == nil || contains(tt.want, string(role))) {
+					t.Fatalf("unexpected Allows(%s)", role)
+				}
+			}
+		})
+	}
+
+	if _, err := ParseFilter("tests", ""); err == nil {
+		t.Fatal("expected an error for an unknown role")
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package coderoles
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Filter selects functions by role. A nil filter allows every role.
+type Filter map[Role]bool
+
+func parseRoles(roles string) ([]Role, error) {
+	parsed := []Role{}
+	for _, role := range strings.Split(roles, ",") {
+		if role = strings.TrimSpace(role); role == "" {
+			continue
+		}
+		if !Role(role).IsValid() {
+			return nil, fmt.Errorf("unknown role %q, expected one of %s", role, strings.Join(Names(), ", "))
+		}
+		parsed = append(parsed, Role(role))
+	}
+	return parsed, nil
+}
+
+// ParseFilter parses comma separated roles to include and to exclude, e.g. "production,example" and "test". Every
+// role is included if include is empty. It returns a nil filter if both are empty.
+func ParseFilter(include string, exclude string) (Filter, error) {
+	includedRoles, err := parseRoles(include)
+	if err != nil {
+		return nil, err
+	}
+	excludedRoles, err := parseRoles(exclude)
+	if err != nil {
+		return nil, err
+	}
+	if len(includedRoles) == 0 && len(excludedRoles) == 0 {
+		return nil, nil
+	}
+
+	if len(includedRoles) == 0 {
+		includedRoles = Roles
+	}
+	filter := Filter{}
+	for _, role := range includedRoles {
+		filter[role] = true
+	}
+	for _, role := range excludedRoles {
+		delete(filter, role)
+	}
+	return filter, nil
+}
+
+// Allows reports whether functions with the role are selected.
+func (f Filter) Allows(role Role) bool {
+	return f == nil || f[role]
+}
+
+// Allowed returns the names of the selected roles, e.g. to filter by role in SQL queries. It returns nil for a nil
+// filter.
+func (f Filter) Allowed() []string {
+	if f == nil {
+		return nil
+	}
+	allowed := []string{}
+	for _, role := range Roles {
+		if f[role] {
+			allowed = append(allowed, string(role))
+		}
+	}
+	return allowed
+}
+
+// Names returns the names of the known roles.
+func Names() []string {
+	names := make([]string, 0, len(Roles))
+	for _, role := range Roles {
+		names = append(names, string(role))
+	}
+	return names
+}
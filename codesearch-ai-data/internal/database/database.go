@@ -38,16 +38,93 @@ CREATE TABLE repos (
     is_train bool NOT NULL DEFAULT false
 );
 
+CREATE TABLE repo_jobs (
+    repo_name text NOT NULL PRIMARY KEY,
+    status text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    error text NOT NULL DEFAULT '',
+    repo_id integer,
+    started_at timestamptz,
+    duration_ms bigint NOT NULL DEFAULT 0,
+    extracted_functions integer NOT NULL DEFAULT 0,
+
+    CONSTRAINT repo_jobs_repo_fk FOREIGN KEY (repo_id) REFERENCES repos (id) ON DELETE SET NULL
+);
+
+CREATE INDEX repo_jobs_status_idx ON repo_jobs USING btree (status);
+
+CREATE TABLE extraction_policies (
+    hash text NOT NULL PRIMARY KEY,
+    policy text NOT NULL
+);
+
+CREATE TABLE repo_snapshots (
+    id bigserial NOT NULL PRIMARY KEY,
+    repo_id integer NOT NULL,
+    ref text NOT NULL,
+    commit_id text NOT NULL,
+    license text NOT NULL DEFAULT '',
+    policy_hash text NOT NULL DEFAULT '',
+
+    CONSTRAINT repo_snapshots_repo_fk FOREIGN KEY (repo_id) REFERENCES repos (id) ON DELETE CASCADE,
+
+    CONSTRAINT repo_snapshots_repo_ref_unique UNIQUE (repo_id, ref)
+);
+
+CREATE TABLE excluded_files (
+    snapshot_id integer NOT NULL,
+    path text NOT NULL,
+    reason text NOT NULL,
+    detail text NOT NULL,
+
+    PRIMARY KEY (snapshot_id, path),
+
+    CONSTRAINT excluded_files_snapshot_fk FOREIGN KEY (snapshot_id) REFERENCES repo_snapshots (id) ON DELETE CASCADE
+);
+
+CREATE INDEX excluded_files_reason_idx ON excluded_files USING btree (reason);
+
+CREATE TABLE parsed_files (
+    snapshot_id integer NOT NULL,
+    path text NOT NULL,
+    language text NOT NULL,
+    error_nodes integer NOT NULL,
+    missing_nodes integer NOT NULL,
+
+    PRIMARY KEY (snapshot_id, path),
+
+    CONSTRAINT parsed_files_snapshot_fk FOREIGN KEY (snapshot_id) REFERENCES repo_snapshots (id) ON DELETE CASCADE
+);
+
+CREATE INDEX parsed_files_language_idx ON parsed_files USING btree (language);
+
 CREATE TABLE extracted_functions (
     id bigserial NOT NULL PRIMARY KEY,
     path text NOT NULL,
     docstring text NOT NULL,
+    docstring_summary text NOT NULL DEFAULT '',
+    docstring_sections text NOT NULL DEFAULT '{}',
     inline_comments text NOT NULL,
     clean_code text NOT NULL,
     clean_code_hash text NOT NULL UNIQUE,
     identifier text NOT NULL,
+    qualified_identifier text NOT NULL DEFAULT '',
     start_line integer NOT NULL,
     end_line integer NOT NULL,
+    signature text NOT NULL DEFAULT '',
+    receiver text NOT NULL DEFAULT '',
+    parameters text NOT NULL DEFAULT '[]',
+    return_type text NOT NULL DEFAULT '',
+    visibility text NOT NULL DEFAULT '',
+    is_static bool NOT NULL DEFAULT false,
+    is_async bool NOT NULL DEFAULT false,
+    role text NOT NULL DEFAULT 'production',
+    token_count integer NOT NULL DEFAULT 0,
+    cyclomatic_complexity integer NOT NULL DEFAULT 0,
+    max_nesting_depth integer NOT NULL DEFAULT 0,
+    parameter_count integer NOT NULL DEFAULT 0,
+    comment_ratio real NOT NULL DEFAULT 0,
+    near_duplicate_cluster_id integer,
     repo_id integer NOT NULL,
 
     CONSTRAINT extracted_functions_repo_fk FOREIGN KEY (repo_id) REFERENCES repos (id) ON DELETE CASCADE
@@ -55,6 +132,101 @@ CREATE TABLE extracted_functions (
 
 CREATE INDEX extracted_functions_repo_id_idx ON extracted_functions USING btree (repo_id);
 
+CREATE INDEX extracted_functions_visibility_idx ON extracted_functions USING btree (visibility);
+
+CREATE INDEX extracted_functions_role_idx ON extracted_functions USING btree (role);
+
+CREATE INDEX extracted_functions_near_duplicate_cluster_id_idx ON extracted_functions USING btree (near_duplicate_cluster_id);
+
+CREATE TABLE extracted_types (
+    id bigserial NOT NULL PRIMARY KEY,
+    path text NOT NULL,
+    kind text NOT NULL,
+    identifier text NOT NULL,
+    docstring text NOT NULL,
+    docstring_summary text NOT NULL DEFAULT '',
+    members text NOT NULL,
+    clean_code text NOT NULL,
+    clean_code_hash text NOT NULL UNIQUE,
+    start_line integer NOT NULL,
+    end_line integer NOT NULL,
+    repo_id integer NOT NULL,
+
+    CONSTRAINT extracted_types_repo_fk FOREIGN KEY (repo_id) REFERENCES repos (id) ON DELETE CASCADE
+);
+
+CREATE INDEX extracted_types_repo_id_idx ON extracted_types USING btree (repo_id);
+
+CREATE TABLE extracted_function_snapshots (
+    snapshot_id integer NOT NULL,
+    extracted_function_id integer NOT NULL,
+    path text NOT NULL,
+    license text NOT NULL DEFAULT '',
+    docstring text NOT NULL,
+    start_line integer NOT NULL,
+    end_line integer NOT NULL,
+
+    PRIMARY KEY (snapshot_id, extracted_function_id, path),
+
+    CONSTRAINT extracted_function_snapshots_snapshot_fk FOREIGN KEY (snapshot_id) REFERENCES repo_snapshots (id) ON DELETE CASCADE,
+
+    CONSTRAINT extracted_function_snapshots_extracted_function_fk FOREIGN KEY (extracted_function_id) REFERENCES extracted_functions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX extracted_function_snapshots_extracted_function_id_idx ON extracted_function_snapshots USING btree (extracted_function_id);
+
+CREATE TABLE extracted_type_snapshots (
+    snapshot_id integer NOT NULL,
+    extracted_type_id integer NOT NULL,
+    path text NOT NULL,
+    license text NOT NULL DEFAULT '',
+    docstring text NOT NULL,
+    start_line integer NOT NULL,
+    end_line integer NOT NULL,
+
+    PRIMARY KEY (snapshot_id, extracted_type_id, path),
+
+    CONSTRAINT extracted_type_snapshots_snapshot_fk FOREIGN KEY (snapshot_id) REFERENCES repo_snapshots (id) ON DELETE CASCADE,
+
+    CONSTRAINT extracted_type_snapshots_extracted_type_fk FOREIGN KEY (extracted_type_id) REFERENCES extracted_types (id) ON DELETE CASCADE
+);
+
+CREATE INDEX extracted_type_snapshots_extracted_type_id_idx ON extracted_type_snapshots USING btree (extracted_type_id);
+
+CREATE TABLE extracted_function_calls (
+    caller_id integer NOT NULL,
+    callee_name text NOT NULL,
+    receiver text NOT NULL DEFAULT '',
+    callee_id integer,
+
+    PRIMARY KEY (caller_id, callee_name, receiver),
+
+    CONSTRAINT extracted_function_calls_caller_fk FOREIGN KEY (caller_id) REFERENCES extracted_functions (id) ON DELETE CASCADE,
+
+    CONSTRAINT extracted_function_calls_callee_fk FOREIGN KEY (callee_id) REFERENCES extracted_functions (id) ON DELETE SET NULL
+);
+
+CREATE INDEX extracted_function_calls_callee_id_idx ON extracted_function_calls USING btree (callee_id);
+
+CREATE TABLE extracted_function_signatures (
+    extracted_function_id integer NOT NULL PRIMARY KEY,
+    signature bytea NOT NULL,
+
+    CONSTRAINT extracted_function_signatures_extracted_function_fk FOREIGN KEY (extracted_function_id) REFERENCES extracted_functions (id) ON DELETE CASCADE
+);
+
+CREATE TABLE extracted_function_band_hashes (
+    extracted_function_id integer NOT NULL,
+    band integer NOT NULL,
+    hash bigint NOT NULL,
+
+    PRIMARY KEY (band, hash, extracted_function_id),
+
+    CONSTRAINT extracted_function_band_hashes_extracted_function_fk FOREIGN KEY (extracted_function_id) REFERENCES extracted_functions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX extracted_function_band_hashes_extracted_function_id_idx ON extracted_function_band_hashes USING btree (extracted_function_id);
+
 CREATE TABLE code_query_pairs (
     id bigserial NOT NULL PRIMARY KEY,
     code text NOT NULL,
@@ -63,22 +235,41 @@ CREATE TABLE code_query_pairs (
     is_train bool NOT NULL DEFAULT false,
     so_question_id integer,
     extracted_function_id integer,
+    extracted_type_id integer,
+    near_duplicate_cluster_id integer,
 
     CONSTRAINT code_query_pairs_so_question_id_fk FOREIGN KEY (so_question_id) REFERENCES so_questions (id) ON DELETE SET NULL,
 
-    CONSTRAINT code_query_pairs_extracted_function_id_fk FOREIGN KEY (extracted_function_id) REFERENCES extracted_functions (id) ON DELETE SET NULL
+    CONSTRAINT code_query_pairs_extracted_function_id_fk FOREIGN KEY (extracted_function_id) REFERENCES extracted_functions (id) ON DELETE SET NULL,
+
+    CONSTRAINT code_query_pairs_extracted_type_id_fk FOREIGN KEY (extracted_type_id) REFERENCES extracted_types (id) ON DELETE SET NULL
 );
 
 CREATE INDEX code_query_pairs_so_question_id_idx ON code_query_pairs USING btree (so_question_id);
 
 CREATE INDEX code_query_pairs_extracted_function_id_idx ON code_query_pairs USING btree (extracted_function_id);
+
+CREATE INDEX code_query_pairs_extracted_type_id_idx ON code_query_pairs USING btree (extracted_type_id);
+
+CREATE INDEX code_query_pairs_near_duplicate_cluster_id_idx ON code_query_pairs USING btree (near_duplicate_cluster_id);
 `
 
 const SCHEMA_DOWN = `
 DROP TABLE code_query_pairs;
 DROP TABLE so_questions;
 DROP TABLE so_answers;
+DROP TABLE extracted_function_calls;
+DROP TABLE extracted_function_signatures;
+DROP TABLE extracted_function_band_hashes;
+DROP TABLE extracted_function_snapshots;
+DROP TABLE extracted_type_snapshots;
 DROP TABLE extracted_functions;
+DROP TABLE extracted_types;
+DROP TABLE excluded_files;
+DROP TABLE parsed_files;
+DROP TABLE repo_snapshots;
+DROP TABLE extraction_policies;
+DROP TABLE repo_jobs;
 DROP TABLE repos;
 `
 
@@ -92,8 +283,18 @@ func ResetDatabaseSchema(ctx context.Context, conn *pgx.Conn) error {
 	return err
 }
 
+// SQLITE_URL_PREFIX starts the database URLs of SQLite files, which are opened by the storage package.
+const SQLITE_URL_PREFIX = "sqlite:"
+
+// ConnectToDatabase connects to the Postgres database of the CODESEARCH_AI_DATA_DATABASE_URL environment variable.
+// SQLite database URLs are rejected, the steps of the pipeline that connect to the database directly only run on
+// Postgres.
 func ConnectToDatabase(ctx context.Context) (*pgx.Conn, error) {
-	return pgx.Connect(ctx, os.Getenv("CODESEARCH_AI_DATA_DATABASE_URL"))
+	databaseURL := os.Getenv("CODESEARCH_AI_DATA_DATABASE_URL")
+	if strings.HasPrefix(databaseURL, SQLITE_URL_PREFIX) {
+		return nil, fmt.Errorf("%s is a SQLite database, this command requires a Postgres database", databaseURL)
+	}
+	return pgx.Connect(ctx, databaseURL)
 }
 
 func PrepareValuesForBulkInsert[T any](s []*T, argsPerValue int, appendValueArgs func(valueArgs []any, value *T) []any) (string, []any) {
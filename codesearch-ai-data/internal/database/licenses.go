@@ -0,0 +1,50 @@
+package database
+
+import (
+	"codesearch-ai-data/internal/licenses"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const licensesQuery = `
+SELECT license FROM repo_snapshots
+UNION SELECT license FROM extracted_function_snapshots
+UNION SELECT license FROM extracted_type_snapshots
+`
+
+// GetAllowedLicenses returns the stored license expressions that are allowed by the allowlist. License expressions
+// are evaluated once here, so queries can filter on the returned list.
+func GetAllowedLicenses(ctx context.Context, conn *pgx.Conn, allowlist licenses.Allowlist) ([]string, error) {
+	rows, err := conn.Query(ctx, licensesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	storedLicenses := []string{}
+	for rows.Next() {
+		var license string
+		err := rows.Scan(&license)
+		if err != nil {
+			return nil, err
+		}
+		storedLicenses = append(storedLicenses, license)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return allowlist.Filter(storedLicenses), nil
+}
+
+// StringArrayLiteral returns a text array literal for queries that cannot take the strings as arguments.
+func StringArrayLiteral(values []string) string {
+	quotedValues := make([]string, 0, len(values))
+	for _, value := range values {
+		quotedValues = append(quotedValues, fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", "''")))
+	}
+	return fmt.Sprintf("ARRAY[%s]::text[]", strings.Join(quotedValues, ","))
+}
@@ -0,0 +1,111 @@
+package docstrings
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Param is a documented parameter, or a documented exception with its type as name.
+type Param struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Sections are the parts of a docstring following its summary sentence.
+type Sections struct {
+	Description string  `json:"description,omitempty"`
+	Params      []Param `json:"params,omitempty"`
+	Returns     string  `json:"returns,omitempty"`
+	Raises      []Param `json:"raises,omitempty"`
+}
+
+// Marshal returns the sections as a JSON object.
+func (s *Sections) Marshal() string {
+	sections, err := json.Marshal(s)
+	if err != nil {
+		return "{}"
+	}
+	return string(sections)
+}
+
+// Unmarshal sets the sections from a JSON object.
+func (s *Sections) Unmarshal(sections string) error {
+	*s = Sections{}
+	if sections == "" || sections == "{}" {
+		return nil
+	}
+	return json.Unmarshal([]byte(sections), s)
+}
+
+// Docstring is a docstring split into its summary sentence and its sections. Inline tags and markup are resolved in
+// the summary and the sections.
+type Docstring struct {
+	// Text is the docstring as written, on a single line.
+	Text    string
+	Summary string
+	Sections
+}
+
+// style is the docstring convention of a language.
+type style struct {
+	// blockTags are Javadoc, JSDoc, PHPDoc, YARD and Doxygen tags, e.g. `@param name description`.
+	blockTags bool
+	// yardTypes are bracketed types preceding names and descriptions in YARD tags, e.g. `@param [Integer] count`.
+	yardTypes bool
+	// typesBeforeNames is set for PHPDoc, where types precede parameter names and return descriptions.
+	typesBeforeNames bool
+	// pythonSections are Google and NumPy style sections (e.g. `Args:`), and reST fields (e.g. `:param name:`).
+	pythonSections bool
+	// htmlMarkup strips HTML tags, as used in Javadoc.
+	htmlMarkup bool
+	// goDocLinks resolves Go doc links and KDoc links, e.g. `[io.Reader]`.
+	goDocLinks bool
+}
+
+var languageStyles = map[string]style{
+	"java":       {blockTags: true, htmlMarkup: true},
+	"javascript": {blockTags: true, htmlMarkup: true},
+	"typescript": {blockTags: true, htmlMarkup: true},
+	"php":        {blockTags: true, typesBeforeNames: true, htmlMarkup: true},
+	"ruby":       {blockTags: true, yardTypes: true},
+	"c":          {blockTags: true},
+	"cpp":        {blockTags: true},
+	"python":     {pythonSections: true},
+	"go":         {goDocLinks: true},
+	"kotlin":     {blockTags: true, goDocLinks: true},
+}
+
+// Flatten joins the non-empty lines of a docstring with spaces.
+func Flatten(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// Parse parses a docstring with its comment delimiters stripped, following the conventions of the language. Empty
+// lines separate paragraphs. Languages without a known convention only have a summary and a description.
+func Parse(text string, languageName string) *Docstring {
+	p := newParser(languageStyles[languageName])
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		// NumPy section headers are underlined with dashes.
+		if p.style.pythonSections && i+1 < len(lines) && numpyUnderlineRegexp.MatchString(strings.TrimSpace(lines[i+1])) {
+			if section, ok := pythonSections[strings.ToLower(line)]; ok {
+				p.startPythonSection(section, true)
+				i++
+				continue
+			}
+		}
+		p.parseLine(line)
+	}
+
+	docstring := p.finish()
+	docstring.Text = Flatten(text)
+	return docstring
+}
@@ -0,0 +1,144 @@
+package docstrings
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		text     string
+	}{
+		{
+			name:     "Javadoc",
+			language: "java",
+			text: `Creates a {@link ThreadPoolTaskExecutor} with the given {@link TaskExecutorCustomizer customizers}.
+<p>
+The executor is not started, e.g. call {@link #start()} first.
+
+@param name the name of the executor, see {@code Thread#getName}
+@param customizers the customizers to apply,
+       in order
+@return the executor
+@throws IllegalArgumentException if the name is empty
+@since 2.1.0`,
+		},
+		{
+			name:     "JSDoc",
+			language: "javascript",
+			text: `Formats a [date]{@link Date} for display.
+
+@param {Date} date - The date to format.
+@param {string} [locale=en] - The locale.
+@returns {string} The formatted date.`,
+		},
+		{
+			name:     "PHPDoc",
+			language: "php",
+			text: `Sends the request.
+
+@param string $url The URL to send the request to.
+@param array $headers
+@return Response The response of the server.
+@throws \RuntimeException When the request fails.`,
+		},
+		{
+			name:     "YARD",
+			language: "ruby",
+			text: `Adds two numbers.
+@param [Integer] a the first number
+@param [Integer] b the second number
+@return [Integer] the sum`,
+		},
+		{
+			name:     "Python Google style",
+			language: "python",
+			text: `Fetches rows from the table.
+
+Retrieves rows pertaining to the given keys. String keys will be UTF-8 encoded.
+
+Args:
+    table (Table): An open table instance.
+    keys: A sequence of strings representing the key of each
+        table row to fetch.
+
+Returns:
+    dict: A dict mapping keys to the corresponding table row data.
+
+Raises:
+    IOError: An error occurred accessing the table.`,
+		},
+		{
+			name:     "Python NumPy style",
+			language: "python",
+			text: `Compute the arithmetic mean along the specified axis.
+
+Parameters
+----------
+a : array_like
+    Array containing numbers whose mean is desired.
+axis : int, optional
+    Axis along which the means are computed.
+
+Returns
+-------
+ndarray
+    The mean of the array elements.
+
+Examples
+--------
+>>> np.mean([1, 2])`,
+		},
+		{
+			name:     "Python reST style",
+			language: "python",
+			text: `Opens a :class:` + "`Connection`" + ` to the server.
+
+:param str host: The host to connect to.
+:param port: The port.
+:type port: int
+:returns: An open connection.
+:raises ConnectionError: If the server is unreachable.`,
+		},
+		{
+			name:     "Go",
+			language: "go",
+			text: `NewReader returns a new [Reader] reading from r.
+The reader buffers the [io.Reader] with a default size.`,
+		},
+		{
+			name:     "KDoc",
+			language: "kotlin",
+			text: `Returns the [Circle] with the given [radius], see [Shape.area].
+
+@param radius The radius of the circle.
+@return The circle.
+@throws IllegalArgumentException If the radius is negative.`,
+		},
+		{
+			name:     "Without convention",
+			language: "rust",
+			text:     "Returns the `len` of the buffer!\nThe buffer is never empty.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			autogold.Equal(t, Parse(tt.text, tt.language))
+		})
+	}
+}
+
+func TestSectionsMarshalling(t *testing.T) {
+	sections := Sections{Description: "Description.", Params: []Param{{Name: "count", Type: "int"}}, Returns: "The sum."}
+
+	var unmarshalledSections Sections
+	err := unmarshalledSections.Unmarshal(sections.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	autogold.Want("unmarshalled sections", sections).Equal(t, unmarshalledSections)
+}
@@ -0,0 +1,84 @@
+package docstrings
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// JSDoc links with a label before the tag, e.g. `[the parser]{@link Parser}`.
+var labeledLinkRegexp = regexp.MustCompile(`\[([^\]]+)\]\{@link(?:code|plain)?\s+[^}]*\}`)
+
+// Inline tags, e.g. `{@link Parser#parse the parser}`, `{@link Parser|the parser}` or `{@code null}`.
+var inlineTagRegexp = regexp.MustCompile(`\{@(\w+)\s*([^}]*)\}`)
+
+var htmlTagRegexp = regexp.MustCompile(`(?i)</?(?:a|b|blockquote|br|code|dd|div|dl|dt|em|h[1-6]|i|li|ol|p|pre|s|span|strong|sub|sup|table|td|th|tr|tt|u|ul)\b[^>]*>`)
+
+// reStructuredText roles, e.g. :class:`Parser` or :py:meth:`~Parser.parse`.
+var restRoleRegexp = regexp.MustCompile("(?::[\\w-]+)+:`~?([^`]+)`")
+var backticksRegexp = regexp.MustCompile("`{1,2}([^`]+)`{1,2}")
+var markdownLinkRegexp = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+var goDocLinkRegexp = regexp.MustCompile(`\[(\*?[\w.]+)\]`)
+
+func replaceInlineTag(tag string) string {
+	match := inlineTagRegexp.FindStringSubmatch(tag)
+	name, content := match[1], strings.TrimSpace(match[2])
+
+	switch name {
+	case "inheritDoc", "inheritdoc":
+		return ""
+	case "link", "linkcode", "linkplain", "see":
+		if i := strings.Index(content, "|"); i >= 0 {
+			return strings.TrimSpace(content[i+1:])
+		}
+		target, label := splitFirstWord(content)
+		if label != "" {
+			return label
+		}
+		// Member references are written `Type#member`, or `#member` in the same type.
+		return strings.TrimPrefix(strings.ReplaceAll(target, "#", "."), ".")
+	default:
+		return content
+	}
+}
+
+// clean resolves inline tags and markup, and joins the text on a single line.
+func (p *parser) clean(text string) string {
+	text = labeledLinkRegexp.ReplaceAllString(text, "$1")
+	text = inlineTagRegexp.ReplaceAllStringFunc(text, replaceInlineTag)
+	if p.style.htmlMarkup {
+		text = htmlTagRegexp.ReplaceAllString(text, " ")
+		text = html.UnescapeString(text)
+	}
+	text = restRoleRegexp.ReplaceAllString(text, "$1")
+	text = backticksRegexp.ReplaceAllString(text, "$1")
+	text = markdownLinkRegexp.ReplaceAllString(text, "$1")
+	if p.style.goDocLinks {
+		text = goDocLinkRegexp.ReplaceAllString(text, "$1")
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+var abbreviations = map[string]bool{"e.g": true, "i.e": true, "etc": true, "vs": true, "cf": true, "approx": true}
+
+// splitSummary splits the first sentence from the rest of a paragraph. A sentence ends with a period, an exclamation
+// or a question mark followed by a space, except for common abbreviations.
+func splitSummary(paragraph string) (string, string) {
+	for i := 0; i < len(paragraph); i++ {
+		if !strings.ContainsRune(".!?", rune(paragraph[i])) {
+			continue
+		}
+		if i+1 < len(paragraph) && paragraph[i+1] != ' ' {
+			continue
+		}
+
+		if paragraph[i] == '.' {
+			lastWord := paragraph[strings.LastIndex(paragraph[:i], " ")+1 : i]
+			if abbreviations[strings.ToLower(lastWord)] {
+				continue
+			}
+		}
+		return paragraph[:i+1], strings.TrimSpace(paragraph[i+1:])
+	}
+	return paragraph, ""
+}
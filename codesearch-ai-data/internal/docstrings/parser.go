@@ -0,0 +1,351 @@
+package docstrings
+
+import (
+	"regexp"
+	"strings"
+)
+
+type section int
+
+const (
+	freeTextSection section = iota
+	paramsSection
+	returnsSection
+	raisesSection
+	ignoredSection
+)
+
+var pythonSections = map[string]section{
+	"args":              paramsSection,
+	"arguments":         paramsSection,
+	"parameters":        paramsSection,
+	"params":            paramsSection,
+	"keyword args":      paramsSection,
+	"keyword arguments": paramsSection,
+	"other parameters":  paramsSection,
+	"returns":           returnsSection,
+	"return":            returnsSection,
+	"yields":            returnsSection,
+	"yield":             returnsSection,
+	"raises":            raisesSection,
+	"exceptions":        raisesSection,
+	"attributes":        ignoredSection,
+	"example":           ignoredSection,
+	"examples":          ignoredSection,
+	"note":              ignoredSection,
+	"notes":             ignoredSection,
+	"references":        ignoredSection,
+	"see also":          ignoredSection,
+	"todo":              ignoredSection,
+	"warning":           ignoredSection,
+	"warnings":          ignoredSection,
+}
+
+var numpyUnderlineRegexp = regexp.MustCompile(`^-{3,}$`)
+var googleSectionRegexp = regexp.MustCompile(`^([A-Za-z]+(?: [A-Za-z]+)?):\s*(.*)$`)
+var restFieldRegexp = regexp.MustCompile(`^:(\w+)(?:\s+([^:]*?))?:\s*(.*)$`)
+var googleParamRegexp = regexp.MustCompile(`^(\*{0,2}\w+)\s*(?:\(([^)]*)\))?:\s*(.*)$`)
+var numpyParamRegexp = regexp.MustCompile(`^(\*{0,2}\w+)\s+:\s*(.*)$`)
+var googleRaisesRegexp = regexp.MustCompile(`^([\w.]+):\s*(.*)$`)
+var numpyRaisesRegexp = regexp.MustCompile(`^[\w.]+$`)
+var googleReturnsTypeRegexp = regexp.MustCompile(`^[\w.]+(?:\[[^\]]*\])?:\s+(.*)$`)
+
+var blockTagRegexp = regexp.MustCompile(`^[@\\](\w+)\s*(.*)$`)
+var firstWordRegexp = regexp.MustCompile(`^(\S+)\s*(.*)$`)
+
+type parser struct {
+	style   style
+	section section
+	// pythonSection is set in Python sections, where each line can start a new entry. Lines following block tags
+	// always continue the tag.
+	pythonSection bool
+	numpy         bool
+
+	paragraphs   [][]string
+	paragraph    []string
+	params       []Param
+	raises       []Param
+	returnsLines []string
+	// continuation appends a line to the current tag or entry.
+	continuation func(line string)
+}
+
+func newParser(s style) *parser {
+	return &parser{style: s, continuation: func(string) {}}
+}
+
+func (p *parser) endParagraph() {
+	if len(p.paragraph) > 0 {
+		p.paragraphs = append(p.paragraphs, p.paragraph)
+		p.paragraph = nil
+	}
+}
+
+func (p *parser) startSection(s section) {
+	p.endParagraph()
+	p.section = s
+	p.pythonSection = false
+	p.continuation = func(string) {}
+}
+
+func (p *parser) startPythonSection(s section, numpy bool) {
+	p.startSection(s)
+	p.pythonSection = true
+	p.numpy = numpy
+	if s == returnsSection {
+		p.returnsLines = nil
+		p.continuation = p.appendReturns
+	}
+}
+
+func (p *parser) appendReturns(line string) {
+	p.returnsLines = append(p.returnsLines, line)
+}
+
+func (p *parser) addParam(param Param) {
+	p.params = append(p.params, param)
+	i := len(p.params) - 1
+	p.continuation = func(line string) { p.params[i].Description = joinLine(p.params[i].Description, line) }
+}
+
+func (p *parser) addRaises(raises Param) {
+	p.raises = append(p.raises, raises)
+	i := len(p.raises) - 1
+	p.continuation = func(line string) { p.raises[i].Description = joinLine(p.raises[i].Description, line) }
+}
+
+func (p *parser) parseLine(line string) {
+	if line == "" {
+		if p.section == freeTextSection {
+			p.endParagraph()
+		}
+		return
+	}
+
+	if p.style.blockTags {
+		if match := blockTagRegexp.FindStringSubmatch(line); match != nil {
+			p.parseBlockTag(strings.ToLower(match[1]), match[2])
+			return
+		}
+	}
+
+	if p.style.pythonSections {
+		if match := restFieldRegexp.FindStringSubmatch(line); match != nil {
+			p.parseRestField(strings.ToLower(match[1]), match[2], match[3])
+			return
+		}
+		// Headers followed by content (e.g. `Returns: the sum`) could be entries of the current section.
+		if match := googleSectionRegexp.FindStringSubmatch(line); match != nil && (match[2] == "" || !p.pythonSection) {
+			if s, ok := pythonSections[strings.ToLower(match[1])]; ok {
+				p.startPythonSection(s, false)
+				if match[2] != "" {
+					p.parseLine(match[2])
+				}
+				return
+			}
+		}
+	}
+
+	switch {
+	case p.section == freeTextSection:
+		p.paragraph = append(p.paragraph, line)
+	case p.pythonSection && p.section == paramsSection && p.parsePythonParam(line):
+	case p.pythonSection && p.section == raisesSection && p.parsePythonRaises(line):
+	default:
+		p.continuation(line)
+	}
+}
+
+func (p *parser) parsePythonParam(line string) bool {
+	if p.numpy {
+		match := numpyParamRegexp.FindStringSubmatch(line)
+		if match == nil {
+			return false
+		}
+		p.addParam(Param{Name: match[1], Type: match[2]})
+		return true
+	}
+
+	match := googleParamRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return false
+	}
+	p.addParam(Param{Name: match[1], Type: match[2], Description: match[3]})
+	return true
+}
+
+func (p *parser) parsePythonRaises(line string) bool {
+	if p.numpy {
+		if !numpyRaisesRegexp.MatchString(line) {
+			return false
+		}
+		p.addRaises(Param{Name: line})
+		return true
+	}
+
+	match := googleRaisesRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return false
+	}
+	p.addRaises(Param{Name: match[1], Description: match[2]})
+	return true
+}
+
+// parseRestField parses reStructuredText fields, e.g. `:param int count: description` or `:returns: description`.
+func (p *parser) parseRestField(field string, argument string, description string) {
+	p.startSection(ignoredSection)
+
+	switch field {
+	case "param", "parameter", "arg", "argument", "key", "keyword":
+		p.section = paramsSection
+		typ, name := "", argument
+		if i := strings.LastIndex(argument, " "); i >= 0 {
+			typ, name = strings.TrimSpace(argument[:i]), argument[i+1:]
+		}
+		p.addParam(Param{Name: name, Type: typ, Description: description})
+	case "type":
+		for i := range p.params {
+			if p.params[i].Name == argument {
+				p.params[i].Type = description
+			}
+		}
+	case "returns", "return", "yields", "yield":
+		p.section = returnsSection
+		p.returnsLines = []string{description}
+		p.continuation = p.appendReturns
+	case "raises", "raise", "except", "exception":
+		p.section = raisesSection
+		p.addRaises(Param{Name: argument, Description: description})
+	}
+}
+
+// parseBlockTag parses Javadoc, JSDoc, PHPDoc, YARD and Doxygen tags. Unknown tags (e.g. @see or @since) are ignored
+// along with their continuation lines.
+func (p *parser) parseBlockTag(tag string, content string) {
+	p.startSection(ignoredSection)
+
+	switch tag {
+	case "param", "arg", "argument":
+		p.section = paramsSection
+		p.addParam(p.parseTaggedParam(content))
+	case "return", "returns":
+		p.section = returnsSection
+		typ, description := splitTagType(content, p.style.yardTypes)
+		if typ == "" && p.style.typesBeforeNames {
+			_, description = splitFirstWord(description)
+		}
+		p.returnsLines = []string{description}
+		p.continuation = p.appendReturns
+	case "throws", "throw", "exception", "raise", "raises":
+		p.section = raisesSection
+		typ, description := splitTagType(content, p.style.yardTypes)
+		if typ == "" {
+			typ, description = splitFirstWord(description)
+		}
+		p.addRaises(Param{Name: typ, Description: description})
+	}
+}
+
+// parseTaggedParam parses the content of a param tag, e.g. `{number} [count=1] - description` in JSDoc,
+// `int $count description` in PHPDoc or `[Integer] count description` in YARD.
+func (p *parser) parseTaggedParam(content string) Param {
+	typ, content := splitTagType(content, p.style.yardTypes)
+	name, description := splitFirstWord(content)
+	if typ == "" && p.style.typesBeforeNames && strings.HasPrefix(description, "$") {
+		typ = name
+		name, description = splitFirstWord(description)
+	}
+
+	// JSDoc optional parameters are bracketed, with an optional default value.
+	if strings.HasPrefix(name, "[") {
+		name = strings.TrimPrefix(name, "[")
+		if i := strings.IndexAny(name, "=]"); i >= 0 {
+			name = name[:i]
+		}
+	}
+	description = strings.TrimPrefix(description, "- ")
+	return Param{Name: name, Type: typ, Description: description}
+}
+
+// splitTagType splits the leading `{type}` (JSDoc), or `[type]` (YARD) if yardTypes is set, from the content of a
+// tag.
+func splitTagType(content string, yardTypes bool) (string, string) {
+	if len(content) == 0 || (content[0] != '{' && (content[0] != '[' || !yardTypes)) {
+		return "", content
+	}
+
+	closing := byte('}')
+	if content[0] == '[' {
+		closing = ']'
+	}
+	depth := 0
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case content[0]:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return content[1:i], strings.TrimSpace(content[i+1:])
+			}
+		}
+	}
+	return "", content
+}
+
+func splitFirstWord(content string) (string, string) {
+	match := firstWordRegexp.FindStringSubmatch(content)
+	if match == nil {
+		return "", ""
+	}
+	return match[1], match[2]
+}
+
+func joinLine(text string, line string) string {
+	if text == "" {
+		return line
+	}
+	return text + " " + line
+}
+
+// returns joins the lines of the return section. The leading type of Google style (`int: description`) and NumPy
+// style (the first line) return sections is dropped.
+func (p *parser) returns() string {
+	lines := p.returnsLines
+	if p.numpy && len(lines) > 1 {
+		lines = lines[1:]
+	}
+	returns := strings.Join(lines, " ")
+	if match := googleReturnsTypeRegexp.FindStringSubmatch(returns); p.style.pythonSections && !p.numpy && match != nil {
+		returns = match[1]
+	}
+	return returns
+}
+
+func (p *parser) finish() *Docstring {
+	p.endParagraph()
+
+	docstring := &Docstring{}
+	descriptionParagraphs := []string{}
+	for i, paragraph := range p.paragraphs {
+		text := p.clean(strings.Join(paragraph, " "))
+		if i == 0 {
+			docstring.Summary, text = splitSummary(text)
+		}
+		if text != "" {
+			descriptionParagraphs = append(descriptionParagraphs, text)
+		}
+	}
+	docstring.Description = strings.Join(descriptionParagraphs, "\n\n")
+
+	for _, param := range p.params {
+		param.Description = p.clean(param.Description)
+		docstring.Params = append(docstring.Params, param)
+	}
+	for _, raises := range p.raises {
+		raises.Description = p.clean(raises.Description)
+		docstring.Raises = append(docstring.Raises, raises)
+	}
+	docstring.Returns = p.clean(p.returns())
+	return docstring
+}
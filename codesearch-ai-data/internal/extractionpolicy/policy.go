@@ -0,0 +1,203 @@
+package extractionpolicy
+
+import (
+	"bytes"
+	"codesearch-ai-data/internal/languages"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// The default policy is used when no policy file is given. Policy files only need to set the rules they change.
+//
+//go:embed default.json
+var defaultPolicyJSON []byte
+
+var defaultPolicy = mustParse(defaultPolicyJSON)
+
+// Rules are the thresholds and blocklists applied to the files of a language.
+type Rules struct {
+	// MaxFileByteSize excludes larger files.
+	MaxFileByteSize int64 `json:"maxFileByteSize"`
+	// MaxLineLength excludes files with a longer line as minified.
+	MaxLineLength    int `json:"maxLineLength"`
+	MinFunctionLines int `json:"minFunctionLines"`
+	MaxFunctionLines int `json:"maxFunctionLines"`
+	MinTypeLines     int `json:"minTypeLines"`
+	MaxTypeLines     int `json:"maxTypeLines"`
+	// ExcludeIdentifiers are the names of the functions that are not extracted, e.g. methods inherited from Object.
+	ExcludeIdentifiers []string `json:"excludeIdentifiers"`
+}
+
+// ExcludesIdentifier reports whether functions with the unqualified name are not extracted.
+func (r *Rules) ExcludesIdentifier(name string) bool {
+	for _, identifier := range r.ExcludeIdentifiers {
+		if identifier == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rules) validate() error {
+	if r.MaxFileByteSize <= 0 || r.MaxLineLength <= 0 {
+		return fmt.Errorf("maxFileByteSize and maxLineLength have to be positive")
+	}
+	if r.MinFunctionLines < 0 || r.MinFunctionLines > r.MaxFunctionLines {
+		return fmt.Errorf("invalid function lines range [%d, %d]", r.MinFunctionLines, r.MaxFunctionLines)
+	}
+	if r.MinTypeLines < 0 || r.MinTypeLines > r.MaxTypeLines {
+		return fmt.Errorf("invalid type lines range [%d, %d]", r.MinTypeLines, r.MaxTypeLines)
+	}
+	return nil
+}
+
+// Policy controls which files, functions and types are extracted. Policies are not modified once parsed, so they
+// can be shared by concurrent extractions.
+type Policy struct {
+	// Rules are the rules of languages without their own rules.
+	Rules
+	// IncludePaths are gitattributes-like globs, files of a repo that do not match any of them are excluded. Every file
+	// is included if there are none.
+	IncludePaths []string `json:"includePaths"`
+	// ExcludePaths are gitattributes-like globs of the files of a repo that are excluded.
+	ExcludePaths []string `json:"excludePaths"`
+	// Languages are the rules of each language, by language name. In policy files, the rules of a language only need
+	// to set the fields that differ from the top-level rules. The top-level excluded identifiers are excluded in every
+	// language.
+	Languages map[string]*Rules `json:"languages"`
+
+	json string
+	hash string
+}
+
+// policyDocument is a policy file, with the language rules kept raw until the top-level rules are known.
+type policyDocument struct {
+	Rules
+	IncludePaths []string                   `json:"includePaths"`
+	ExcludePaths []string                   `json:"excludePaths"`
+	Languages    map[string]json.RawMessage `json:"languages"`
+}
+
+func decodeStrict(content []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// Parse parses a JSON policy on top of the default policy.
+func Parse(content []byte) (*Policy, error) {
+	document := &policyDocument{}
+	if err := decodeStrict(defaultPolicyJSON, document); err != nil {
+		return nil, fmt.Errorf("invalid default policy: %w", err)
+	}
+	defaultLanguages := document.Languages
+	document.Languages = nil
+
+	if err := decodeStrict(content, document); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	policy := &Policy{
+		Rules:        document.Rules,
+		IncludePaths: document.IncludePaths,
+		ExcludePaths: document.ExcludePaths,
+		Languages:    map[string]*Rules{},
+	}
+
+	// The rules of the policy file override the default rules of the language, field by field.
+	for _, languagesRules := range []map[string]json.RawMessage{defaultLanguages, document.Languages} {
+		for languageName, languageRules := range languagesRules {
+			if languages.ByName(languageName) == nil {
+				return nil, fmt.Errorf("invalid policy: unknown language %s", languageName)
+			}
+
+			rules, ok := policy.Languages[languageName]
+			if !ok {
+				rules = &Rules{}
+				*rules = policy.Rules
+				rules.ExcludeIdentifiers = nil
+				policy.Languages[languageName] = rules
+			}
+			if err := decodeStrict(languageRules, rules); err != nil {
+				return nil, fmt.Errorf("invalid policy for language %s: %w", languageName, err)
+			}
+		}
+	}
+
+	if err := policy.Rules.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	for languageName, rules := range policy.Languages {
+		rules.ExcludeIdentifiers = append(append([]string{}, policy.ExcludeIdentifiers...), rules.ExcludeIdentifiers...)
+		if err := rules.validate(); err != nil {
+			return nil, fmt.Errorf("invalid policy for language %s: %w", languageName, err)
+		}
+	}
+
+	// Maps are marshalled with sorted keys, equivalent policy files have the same hash.
+	canonicalJSON, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	policy.json = string(canonicalJSON)
+	hasher := sha1.New()
+	hasher.Write(canonicalJSON)
+	policy.hash = hex.EncodeToString(hasher.Sum(nil))
+	return policy, nil
+}
+
+func mustParse(content []byte) *Policy {
+	policy, err := Parse(content)
+	if err != nil {
+		panic(err)
+	}
+	return policy
+}
+
+// Load reads the policy file at the path, or returns the default policy if the path is empty.
+func Load(path string) (*Policy, error) {
+	if path == "" {
+		return Default(), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(content)
+}
+
+func Default() *Policy {
+	return defaultPolicy
+}
+
+// ForLanguage returns the rules of the language.
+func (p *Policy) ForLanguage(languageName string) *Rules {
+	if rules, ok := p.Languages[languageName]; ok {
+		return rules
+	}
+	return &p.Rules
+}
+
+// ForFile returns the rules of the language of the file, or the top-level rules for files in other languages.
+func (p *Policy) ForFile(filePath string) *Rules {
+	language := languages.ForFile(filePath)
+	if language == nil {
+		return &p.Rules
+	}
+	return p.ForLanguage(language.Name)
+}
+
+// JSON returns the policy with every rule resolved, as stored along with the extracted snapshots.
+func (p *Policy) JSON() string {
+	return p.json
+}
+
+// Hash identifies the rules of the policy.
+func (p *Policy) Hash() string {
+	return p.hash
+}
@@ -0,0 +1,144 @@
+package extractionpolicy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	policy, err := Parse([]byte(`{
+  "minFunctionLines": 2,
+  "excludeIdentifiers": ["main"],
+  "excludePaths": ["**/testdata/**"],
+  "languages": {
+    "php": {"maxFunctionLines": 100},
+    "go": {"maxFileByteSize": 2000000, "excludeIdentifiers": ["init"]}
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		rules    *Rules
+		wantRule Rules
+	}{
+		{
+			name:  "Language without rules",
+			rules: policy.ForLanguage("ruby"),
+			wantRule: Rules{
+				MaxFileByteSize:    1000000,
+				MaxLineLength:      1024,
+				MinFunctionLines:   2,
+				MaxFunctionLines:   512,
+				MinTypeLines:       3,
+				MaxTypeLines:       512,
+				ExcludeIdentifiers: []string{"main"},
+			},
+		},
+		{
+			name:  "Language with default rules",
+			rules: policy.ForLanguage("java"),
+			wantRule: Rules{
+				MaxFileByteSize:    1000000,
+				MaxLineLength:      1024,
+				MinFunctionLines:   2,
+				MaxFunctionLines:   512,
+				MinTypeLines:       3,
+				MaxTypeLines:       512,
+				ExcludeIdentifiers: []string{"main", "toString", "hashCode", "equals", "finalize", "notify", "notifyAll", "clone"},
+			},
+		},
+		{
+			name:  "Language with default rules and policy rules",
+			rules: policy.ForLanguage("php"),
+			wantRule: Rules{
+				MaxFileByteSize:  1000000,
+				MaxLineLength:    1024,
+				MinFunctionLines: 2,
+				MaxFunctionLines: 100,
+				MinTypeLines:     3,
+				MaxTypeLines:     512,
+				ExcludeIdentifiers: append(
+					[]string{"main"},
+					Default().ForLanguage("php").ExcludeIdentifiers...,
+				),
+			},
+		},
+		{
+			name:  "Language with policy rules",
+			rules: policy.ForFile("cmd/main.go"),
+			wantRule: Rules{
+				MaxFileByteSize:    2000000,
+				MaxLineLength:      1024,
+				MinFunctionLines:   2,
+				MaxFunctionLines:   512,
+				MinTypeLines:       3,
+				MaxTypeLines:       512,
+				ExcludeIdentifiers: []string{"main", "init"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(*tt.rules, tt.wantRule) {
+				t.Fatalf("expected %+v, got %+v", tt.wantRule, *tt.rules)
+			}
+		})
+	}
+
+	if !reflect.DeepEqual(policy.ExcludePaths, []string{"**/testdata/**"}) {
+		t.Fatalf("unexpected exclude paths %v", policy.ExcludePaths)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{name: "Unknown field", policy: `{"minFunctionLine": 2}`},
+		{name: "Unknown language field", policy: `{"languages": {"go": {"maxLines": 2}}}`},
+		{name: "Unknown language", policy: `{"languages": {"cobol": {"minFunctionLines": 2}}}`},
+		{name: "Invalid range", policy: `{"minFunctionLines": 10, "maxFunctionLines": 5}`},
+		{name: "Invalid language range", policy: `{"languages": {"go": {"minTypeLines": 600}}}`},
+		{name: "Invalid JSON", policy: `{"minFunctionLines": }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.policy)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestPolicyHash(t *testing.T) {
+	empty, err := Parse([]byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Hash() != Default().Hash() {
+		t.Fatal("expected an empty policy to have the hash of the default policy")
+	}
+
+	// Equivalent policies have the same hash, whatever the order and the level of their rules.
+	a, err := Parse([]byte(`{"maxTypeLines": 100, "languages": {"go": {"minTypeLines": 2}, "c": {"minTypeLines": 2}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse([]byte(`{"languages": {"c": {"minTypeLines": 2, "maxTypeLines": 100}, "go": {"minTypeLines": 2}}, "maxTypeLines": 100}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash() != b.Hash() {
+		t.Fatalf("expected equivalent policies to have the same hash, got %s and %s", a.JSON(), b.JSON())
+	}
+
+	if a.Hash() == Default().Hash() {
+		t.Fatal("expected policies with different rules to have different hashes")
+	}
+}
@@ -0,0 +1,216 @@
+package filefilter
+
+import (
+	"bytes"
+	"codesearch-ai-data/internal/extractionpolicy"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Minified files are also detected by their average line length, for files large enough for it to be meaningful.
+const MIN_MINIFIED_FILE_BYTE_SIZE = 4096
+const MAX_AVERAGE_LINE_LENGTH = 200
+
+// Generated code markers are only looked for in the file header.
+const GENERATED_HEADER_LINES = 20
+
+type Reason string
+
+const (
+	Vendored  Reason = "vendored"
+	Generated Reason = "generated"
+	Minified  Reason = "minified"
+	TooLarge  Reason = "too_large"
+	// Policy is the reason of files excluded by the path globs of the extraction policy.
+	Policy Reason = "policy"
+)
+
+// Exclusion is the reason a file is excluded from the extraction, and the rule that matched it.
+type Exclusion struct {
+	Reason Reason `json:"reason"`
+	Detail string `json:"detail"`
+}
+
+type pathRule struct {
+	reason Reason
+	// Directory names excluded anywhere in the path.
+	directories []string
+	// Directory names only excluded at the root of the repo. Nested directories with these names are usually part
+	// of the source code (e.g. an `external` API client package).
+	rootDirectories []string
+	// Regexps matched against the file name.
+	fileNames []*regexp.Regexp
+}
+
+var pathRules = []pathRule{
+	{
+		reason: Vendored,
+		directories: []string{
+			"vendor", "node_modules", "bower_components", "jspm_packages", "third_party", "third-party", "thirdparty",
+			"3rdparty", "Godeps", "Pods", "Carthage", ".yarn",
+		},
+		rootDirectories: []string{"external", "externals"},
+	},
+	{
+		reason: Generated,
+		fileNames: []*regexp.Regexp{
+			regexp.MustCompile(`\.pb\.`),
+			regexp.MustCompile(`_pb2(_grpc)?\.py$`),
+			regexp.MustCompile(`\.generated\.`),
+			regexp.MustCompile(`^zz_generated\.`),
+			regexp.MustCompile(`\.g\.(cs|dart)$`),
+			regexp.MustCompile(`\.[Dd]esigner\.cs$`),
+		},
+	},
+	{
+		reason:          Minified,
+		rootDirectories: []string{"dist"},
+		fileNames: []*regexp.Regexp{
+			regexp.MustCompile(`[.-]min\.(js|css)$`),
+			regexp.MustCompile(`[.-]bundle\.js$`),
+			regexp.MustCompile(`^(vendor|vendors|chunk)\..*\.js$`),
+		},
+	},
+}
+
+// Generated code markers are only looked for in comments, the header lines have to start with a comment delimiter.
+var commentLineRegexp = regexp.MustCompile(`^(//|#|/\*|\*|<!--|--|;|"""|''')`)
+
+var generatedHeaderRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`),
+	regexp.MustCompile(`@generated\b`),
+	regexp.MustCompile(`<auto-generated`),
+	regexp.MustCompile(`(?i)\b(auto-?generated|automatically generated|generated by the protocol buffer compiler)\b`),
+	regexp.MustCompile(`\bDO NOT EDIT\b`),
+}
+
+// Filter detects the files of a repo that should not be extracted: files excluded by the extraction policy, vendored
+// and third-party code, generated code, minified or bundled assets and large files. The .gitattributes files of the
+// repo are read lazily.
+type Filter struct {
+	repoPath     string
+	attributes   *gitattributes
+	policy       *extractionpolicy.Policy
+	includePaths []*pathPattern
+	excludePaths []*pathPattern
+}
+
+func New(repoPath string, policy *extractionpolicy.Policy) *Filter {
+	includePaths := make([]*pathPattern, 0, len(policy.IncludePaths))
+	for _, pattern := range policy.IncludePaths {
+		includePaths = append(includePaths, newPathPattern(pattern))
+	}
+	excludePaths := make([]*pathPattern, 0, len(policy.ExcludePaths))
+	for _, pattern := range policy.ExcludePaths {
+		excludePaths = append(excludePaths, newPathPattern(pattern))
+	}
+	return &Filter{repoPath, newGitattributes(repoPath), policy, includePaths, excludePaths}
+}
+
+func (f *Filter) excludePathByPolicy(relativePath string) *Exclusion {
+	for i, pattern := range f.excludePaths {
+		if pattern.matches(relativePath) {
+			return &Exclusion{Policy, "exclude path " + f.policy.ExcludePaths[i]}
+		}
+	}
+	if len(f.includePaths) == 0 {
+		return nil
+	}
+	for _, pattern := range f.includePaths {
+		if pattern.matches(relativePath) {
+			return nil
+		}
+	}
+	return &Exclusion{Policy, "not in include paths"}
+}
+
+// ExcludePath returns why the file at the path, relative to the repo root, is excluded, or nil if it is not.
+// Only the path, the .gitattributes and the file size are used.
+func (f *Filter) ExcludePath(relativePath string, size int64) *Exclusion {
+	relativePath = path.Clean(strings.TrimPrefix(relativePath, "/"))
+
+	if exclusion := f.excludePathByPolicy(relativePath); exclusion != nil {
+		return exclusion
+	}
+
+	if linguistGenerated, ok := f.attributes.get(relativePath, "linguist-generated"); ok && linguistGenerated {
+		return &Exclusion{Generated, ".gitattributes linguist-generated"}
+	}
+	if linguistVendored, ok := f.attributes.get(relativePath, "linguist-vendored"); ok && linguistVendored {
+		return &Exclusion{Vendored, ".gitattributes linguist-vendored"}
+	}
+
+	if exclusion := excludePathByConvention(relativePath); exclusion != nil {
+		// Files marked as not vendored or not generated in .gitattributes are kept.
+		if _, ok := f.attributes.get(relativePath, "linguist-"+string(exclusion.Reason)); !ok {
+			return exclusion
+		}
+	}
+
+	if size > f.policy.ForFile(relativePath).MaxFileByteSize {
+		return &Exclusion{TooLarge, fmt.Sprintf("%d bytes", size)}
+	}
+
+	return nil
+}
+
+func excludePathByConvention(relativePath string) *Exclusion {
+	directories := strings.Split(path.Dir(relativePath), "/")
+	fileName := path.Base(relativePath)
+	for _, rule := range pathRules {
+		for _, directory := range directories {
+			for _, excludedDirectory := range rule.directories {
+				if directory == excludedDirectory {
+					return &Exclusion{rule.reason, excludedDirectory + "/"}
+				}
+			}
+		}
+		for _, excludedDirectory := range rule.rootDirectories {
+			if directories[0] == excludedDirectory {
+				return &Exclusion{rule.reason, "/" + excludedDirectory + "/"}
+			}
+		}
+		for _, fileNameRegexp := range rule.fileNames {
+			if fileNameRegexp.MatchString(fileName) {
+				return &Exclusion{rule.reason, fileNameRegexp.String()}
+			}
+		}
+	}
+	return nil
+}
+
+// ExcludeCode returns why the file at the path is excluded based on its content, or nil if it is not.
+func (f *Filter) ExcludeCode(relativePath string, code []byte) *Exclusion {
+	maxLineLength := f.policy.ForFile(relativePath).MaxLineLength
+
+	lines := bytes.Split(code, []byte("\n"))
+
+	for i, line := range lines {
+		if i >= GENERATED_HEADER_LINES {
+			break
+		}
+		line = bytes.TrimSpace(line)
+		if !commentLineRegexp.Match(line) {
+			continue
+		}
+		for _, headerRegexp := range generatedHeaderRegexps {
+			if headerRegexp.Match(line) {
+				return &Exclusion{Generated, fmt.Sprintf("header line %d: %s", i+1, headerRegexp.String())}
+			}
+		}
+	}
+
+	for i, line := range lines {
+		if len(line) > maxLineLength {
+			return &Exclusion{Minified, fmt.Sprintf("line %d has %d characters", i+1, len(line))}
+		}
+	}
+
+	if len(code) >= MIN_MINIFIED_FILE_BYTE_SIZE && len(code)/len(lines) > MAX_AVERAGE_LINE_LENGTH {
+		return &Exclusion{Minified, fmt.Sprintf("average line length of %d characters", len(code)/len(lines))}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package filefilter
+
+import (
+	"codesearch-ai-data/internal/extractionpolicy"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGitattributes(t *testing.T, repoPath string, directory string, content string) {
+	err := os.MkdirAll(filepath.Join(repoPath, directory), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(repoPath, directory, ".gitattributes"), []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExcludePath(t *testing.T) {
+	repoPath := t.TempDir()
+	writeGitattributes(t, repoPath, "", `
+# Generated clients
+api/client/** linguist-generated=true
+*.snap linguist-generated
+lib/vendored.js linguist-vendored
+vendor/internal/** -linguist-vendored
+`)
+	writeGitattributes(t, repoPath, "api/client/manual", "*.ts -linguist-generated\n")
+
+	tests := []struct {
+		path       string
+		size       int64
+		wantReason Reason
+	}{
+		{path: "main.go"},
+		{path: "src/service/handler.py"},
+		{path: "vendor/github.com/pkg/errors/errors.go", wantReason: Vendored},
+		{path: "web/node_modules/react/index.js", wantReason: Vendored},
+		{path: "third_party/zlib/zlib.c", wantReason: Vendored},
+		{path: "external/googletest/src/gtest.cc", wantReason: Vendored},
+		{path: "src/external/client.go"},
+		{path: "vendor/internal/helpers.go"},
+		{path: "lib/vendored.js", wantReason: Vendored},
+		{path: "api/service.pb.go", wantReason: Generated},
+		{path: "proto/service_pb2.py", wantReason: Generated},
+		{path: "pkg/apis/zz_generated.deepcopy.go", wantReason: Generated},
+		{path: "Forms/Main.Designer.cs", wantReason: Generated},
+		{path: "api/client/models.ts", wantReason: Generated},
+		{path: "api/client/manual/auth.ts"},
+		{path: "tests/output.snap", wantReason: Generated},
+		{path: "static/jquery.min.js", wantReason: Minified},
+		{path: "static/app.bundle.js", wantReason: Minified},
+		{path: "dist/index.js", wantReason: Minified},
+		{path: "pkg/dist/release.go"},
+		{path: "src/large.go", size: extractionpolicy.Default().MaxFileByteSize + 1, wantReason: TooLarge},
+	}
+
+	filter := New(repoPath, extractionpolicy.Default())
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			exclusion := filter.ExcludePath(tt.path, tt.size)
+			var gotReason Reason
+			if exclusion != nil {
+				gotReason = exclusion.Reason
+			}
+			if gotReason != tt.wantReason {
+				t.Fatalf("Want reason %q, got %+v", tt.wantReason, exclusion)
+			}
+		})
+	}
+}
+
+func TestExcludePathByPolicy(t *testing.T) {
+	policy, err := extractionpolicy.Parse([]byte(`{
+  "includePaths": ["src/**", "*.py"],
+  "excludePaths": ["**/testdata/**", "*_test.go"],
+  "languages": {"python": {"maxFileByteSize": 100}}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path       string
+		size       int64
+		wantReason Reason
+	}{
+		{path: "src/main.go"},
+		{path: "scripts/build.py"},
+		{path: "docs/example.go", wantReason: Policy},
+		{path: "src/testdata/fixture.go", wantReason: Policy},
+		{path: "src/main_test.go", wantReason: Policy},
+		{path: "src/vendor/lib.go", wantReason: Vendored},
+		{path: "src/large.go", size: 1000},
+		{path: "src/large.py", size: 1000, wantReason: TooLarge},
+	}
+
+	filter := New(t.TempDir(), policy)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			exclusion := filter.ExcludePath(tt.path, tt.size)
+			var gotReason Reason
+			if exclusion != nil {
+				gotReason = exclusion.Reason
+			}
+			if gotReason != tt.wantReason {
+				t.Fatalf("Want reason %q, got %+v", tt.wantReason, exclusion)
+			}
+		})
+	}
+}
+
+func TestExcludeCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       string
+		wantReason Reason
+	}{
+		{name: "Regular code", code: "package main\n\n// Do not edit the returned slice.\nfunc main() {}\n"},
+		{name: "Go generated code", code: "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n", wantReason: Generated},
+		{name: "Generated tag", code: "/**\n * @generated\n */\nclass A {}\n", wantReason: Generated},
+		{name: "C# auto-generated", code: "//------\n// <auto-generated>\n//------\nclass A {}\n", wantReason: Generated},
+		{name: "Python autogenerated", code: "# This file is autogenerated by setup.py\nVERSION = '1.0'\n", wantReason: Generated},
+		{name: "Marker outside of comments", code: "def f():\n    return 'DO NOT EDIT'\n"},
+		{name: "Marker after the header", code: strings.Repeat("x = 1\n", GENERATED_HEADER_LINES) + "# DO NOT EDIT\n"},
+		{name: "Long line", code: "var a = 1;\n" + strings.Repeat("a", extractionpolicy.Default().MaxLineLength+1) + "\n", wantReason: Minified},
+		{name: "Long average line length", code: strings.Repeat(strings.Repeat("b", MAX_AVERAGE_LINE_LENGTH+50)+"\n", 20), wantReason: Minified},
+	}
+
+	filter := New(t.TempDir(), extractionpolicy.Default())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exclusion := filter.ExcludeCode("main.js", []byte(tt.code))
+			var gotReason Reason
+			if exclusion != nil {
+				gotReason = exclusion.Reason
+			}
+			if gotReason != tt.wantReason {
+				t.Fatalf("Want reason %q, got %+v", tt.wantReason, exclusion)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package filefilter
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// pathPattern is a gitattributes glob pattern.
+type pathPattern struct {
+	pattern *regexp.Regexp
+	// matchBaseName is set for patterns without a slash, they match the file name at any depth.
+	matchBaseName bool
+}
+
+func newPathPattern(pattern string) *pathPattern {
+	return &pathPattern{
+		pattern:       globToRegexp(strings.TrimPrefix(pattern, "/")),
+		matchBaseName: !strings.Contains(pattern, "/"),
+	}
+}
+
+func (p *pathPattern) matches(relativePath string) bool {
+	if p.matchBaseName {
+		return p.pattern.MatchString(path.Base(relativePath))
+	}
+	return p.pattern.MatchString(relativePath)
+}
+
+type attributeRule struct {
+	*pathPattern
+	// attributes are the set (true) and unset (false) attributes, unspecified attributes are deleted.
+	attributes map[string]*bool
+}
+
+// gitattributes reads the .gitattributes files of a repo tree. Only the attributes set on file path patterns are
+// supported, macros are ignored.
+type gitattributes struct {
+	repoPath string
+	// rules are the rules of the .gitattributes file in each directory, nil if there is none. Files of a repo tree
+	// are filtered concurrently, so the rules are guarded by rulesMutex.
+	rules      map[string][]*attributeRule
+	rulesMutex sync.Mutex
+}
+
+func newGitattributes(repoPath string) *gitattributes {
+	return &gitattributes{repoPath: repoPath, rules: map[string][]*attributeRule{}}
+}
+
+// get returns the value of the attribute for the file, and false if the attribute is unspecified. Deeper
+// .gitattributes files take precedence, and later lines take precedence within a file.
+func (g *gitattributes) get(relativePath string, attribute string) (bool, bool) {
+	var value *bool
+	directory := ""
+	for _, part := range strings.Split(relativePath, "/") {
+		pathInDirectory := strings.TrimPrefix(strings.TrimPrefix(relativePath, directory), "/")
+		for _, rule := range g.getDirectoryRules(directory) {
+			if !rule.matches(pathInDirectory) {
+				continue
+			}
+			if attributeValue, ok := rule.attributes[attribute]; ok {
+				value = attributeValue
+			}
+		}
+		directory = path.Join(directory, part)
+	}
+
+	if value == nil {
+		return false, false
+	}
+	return *value, true
+}
+
+func (g *gitattributes) getDirectoryRules(directory string) []*attributeRule {
+	g.rulesMutex.Lock()
+	defer g.rulesMutex.Unlock()
+
+	if rules, ok := g.rules[directory]; ok {
+		return rules
+	}
+
+	content, err := os.ReadFile(filepath.Join(g.repoPath, directory, ".gitattributes"))
+	var rules []*attributeRule
+	if err == nil {
+		rules = parseGitattributes(string(content))
+	}
+	g.rules[directory] = rules
+	return rules
+}
+
+func parseGitattributes(content string) []*attributeRule {
+	rules := []*attributeRule{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+
+		attributes := map[string]*bool{}
+		for _, field := range fields[1:] {
+			set, unset := true, false
+			switch {
+			case strings.HasPrefix(field, "-"):
+				attributes[field[1:]] = &unset
+			case strings.HasPrefix(field, "!"):
+				attributes[field[1:]] = nil
+			default:
+				name, value, _ := strings.Cut(field, "=")
+				if value == "false" {
+					attributes[name] = &unset
+				} else {
+					attributes[name] = &set
+				}
+			}
+		}
+
+		rules = append(rules, &attributeRule{newPathPattern(fields[0]), attributes})
+	}
+	return rules
+}
+
+// globToRegexp converts a gitattributes glob pattern to a regexp, `**` matches any number of directories.
+func globToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case pattern[i:] == "/**":
+			sb.WriteString("/.*")
+			i += 2
+		case pattern[i] == '*':
+			sb.WriteString("[^/]*")
+		case pattern[i] == '?':
+			sb.WriteString("[^/]")
+		case pattern[i] == '[' && strings.Contains(pattern[i+1:], "]"):
+			end := i + 1 + strings.Index(pattern[i+1:], "]")
+			class := pattern[i+1 : end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = end
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		// Invalid patterns never match.
+		return regexp.MustCompile(`[^\s\S]`)
+	}
+	return re
+}
@@ -0,0 +1,76 @@
+package functionextractor
+
+import (
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Call queries live in queries/calls/<language>.scm. Each pattern captures:
+//
+//	@call     - the call node (required),
+//	@name     - the name of the called function or method (required),
+//	@receiver - the object, type or module the function is called on, if any.
+//
+// When several patterns match the same call, the first pattern wins, so patterns with a receiver come first.
+type callQuery struct {
+	query *extractionQuery
+}
+
+// Call is a call from an extracted function, by the name of the called function. Calls are resolved to the extracted
+// functions of the same repo once the repo is extracted.
+type Call struct {
+	Name string `json:"name"`
+	// Receiver is the expression the function is called on as written, e.g. `self` or `os.path`. It is empty for
+	// calls to plain functions.
+	Receiver string `json:"receiver,omitempty"`
+}
+
+// selfReceivers refer to the object or the type of the calling method. Calls on them are resolved like calls without
+// a receiver, other receivers are unknown.
+var selfReceivers = []string{"self", "this", "$this", "Self", "static", "cls"}
+
+// getCalls returns the calls of the code, in order of appearance, by the innermost function node enclosing them.
+// Calls outside of the function nodes are skipped.
+func (cq *callQuery) getCalls(rootNode *sitter.Node, functionNodes map[*sitter.Node]bool, code []byte) map[*sitter.Node][]Call {
+	matches := cq.query.getWinningMatches(cq.query.getMatches(rootNode), "call", code)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].captures["call"].StartByte() < matches[j].captures["call"].StartByte()
+	})
+
+	calls := map[*sitter.Node][]Call{}
+	seenCalls := map[*sitter.Node]map[Call]bool{}
+	for _, match := range matches {
+		functionNode := getEnclosingFunctionNode(match.captures["call"], functionNodes)
+		if functionNode == nil {
+			continue
+		}
+
+		call := Call{
+			Name:     cq.query.getCaptureContent(match, "name", code),
+			Receiver: strings.Join(strings.Fields(cq.query.getCaptureContent(match, "receiver", code)), " "),
+		}
+		if call.Name == "" {
+			continue
+		}
+		if seenCalls[functionNode] == nil {
+			seenCalls[functionNode] = map[Call]bool{}
+		}
+		if seenCalls[functionNode][call] {
+			continue
+		}
+		seenCalls[functionNode][call] = true
+		calls[functionNode] = append(calls[functionNode], call)
+	}
+	return calls
+}
+
+func getEnclosingFunctionNode(node *sitter.Node, functionNodes map[*sitter.Node]bool) *sitter.Node {
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		if functionNodes[parent] {
+			return parent
+		}
+	}
+	return nil
+}
@@ -2,20 +2,103 @@ package functionextractor
 
 import (
 	"codesearch-ai-data/internal/database"
+	"codesearch-ai-data/internal/extractionpolicy"
+	"codesearch-ai-data/internal/filefilter"
+	ph "codesearch-ai-data/internal/parsinghelpers"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/jackc/pgx/v4"
 )
 
-func repoExists(ctx context.Context, conn *pgx.Conn, repoName string) (bool, error) {
-	var repoCount int
-	err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM repos WHERE name = $1", repoName).Scan(&repoCount)
+func getRepo(ctx context.Context, conn *pgx.Conn, repoName string) (int, string, error) {
+	var repoID int
+	var commitID string
+	err := conn.QueryRow(ctx, "SELECT id, commit_id FROM repos WHERE name = $1", repoName).Scan(&repoID, &commitID)
+	if err != nil {
+		return -1, "", err
+	}
+	return repoID, commitID, nil
+}
+
+func updateRepoCommitID(ctx context.Context, conn *pgx.Conn, repoID int, commitID string) error {
+	_, err := conn.Exec(ctx, "UPDATE repos SET commit_id = $1 WHERE id = $2", commitID, repoID)
+	return err
+}
+
+// getRepoFilePaths returns the paths of the repo files with extracted functions or types.
+func getRepoFilePaths(ctx context.Context, conn *pgx.Conn, repoID int) ([]string, error) {
+	rows, err := conn.Query(ctx, "SELECT path FROM extracted_functions WHERE repo_id = $1 UNION SELECT path FROM extracted_types WHERE repo_id = $1", repoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paths := []string{}
+	for rows.Next() {
+		var path string
+		err := rows.Scan(&path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// TRACKED_SNAPSHOT_REF is the ref of the tracked repo snapshot. Its commit is the repo commit ID, and updates move
+// it to the latest commit. Snapshots of other refs (e.g. release tags) are never updated.
+const TRACKED_SNAPSHOT_REF = ""
+
+// upsertRepoSnapshot inserts the snapshot of the repo ref, or moves the existing one to the commit. The policy hash
+// identifies the extraction policy the snapshot was extracted with.
+func upsertRepoSnapshot(ctx context.Context, conn *pgx.Conn, repoID int, ref string, commitID string, license string, policyHash string) (int, error) {
+	var snapshotID int
+	err := conn.QueryRow(
+		ctx,
+		"INSERT INTO repo_snapshots (repo_id, ref, commit_id, license, policy_hash) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (repo_id, ref) DO UPDATE SET commit_id = EXCLUDED.commit_id, license = EXCLUDED.license, policy_hash = EXCLUDED.policy_hash RETURNING id",
+		repoID,
+		ref,
+		commitID,
+		license,
+		policyHash,
+	).Scan(&snapshotID)
+	if err != nil {
+		return -1, err
+	}
+	return snapshotID, nil
+}
+
+// getRepoSnapshotPolicyHash returns the hash of the policy the snapshot of the repo ref was extracted with, or an
+// empty string if there is no snapshot.
+func getRepoSnapshotPolicyHash(ctx context.Context, conn *pgx.Conn, repoID int, ref string) (string, error) {
+	var policyHash string
+	err := conn.QueryRow(ctx, "SELECT policy_hash FROM repo_snapshots WHERE repo_id = $1 AND ref = $2", repoID, ref).Scan(&policyHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return policyHash, err
+}
+
+// insertExtractionPolicy records the rules of the policy, snapshots refer to them by hash.
+func insertExtractionPolicy(ctx context.Context, conn *pgx.Conn, policy *extractionpolicy.Policy) error {
+	return insertExtractionPolicyJSON(ctx, conn, policy.Hash(), policy.JSON())
+}
+
+func insertExtractionPolicyJSON(ctx context.Context, conn *pgx.Conn, policyHash string, policyJSON string) error {
+	_, err := conn.Exec(ctx, "INSERT INTO extraction_policies (hash, policy) VALUES ($1, $2) ON CONFLICT (hash) DO NOTHING", policyHash, policyJSON)
+	return err
+}
+
+func repoSnapshotExists(ctx context.Context, conn *pgx.Conn, repoID int, ref string) (bool, error) {
+	var snapshotCount int
+	err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM repo_snapshots WHERE repo_id = $1 AND ref = $2", repoID, ref).Scan(&snapshotCount)
 	if err != nil {
 		return false, err
 	}
-	return repoCount != 0, nil
+	return snapshotCount != 0, nil
 }
 
 func insertRepo(ctx context.Context, conn *pgx.Conn, repoName, commitID string) (int, error) {
@@ -30,15 +113,57 @@ func insertRepo(ctx context.Context, conn *pgx.Conn, repoName, commitID string)
 const insertExtractedFunctionsBatchSize = 32
 
 const insertExtractedFunctionsQuery = `
-INSERT INTO extracted_functions (repo_id, path, docstring, inline_comments, clean_code, clean_code_hash, identifier, start_line, end_line)
+INSERT INTO extracted_functions (repo_id, path, docstring, docstring_summary, docstring_sections, inline_comments, clean_code, clean_code_hash, identifier, qualified_identifier, start_line, end_line, signature, receiver, parameters, return_type, visibility, is_static, is_async, role, token_count, cyclomatic_complexity, max_nesting_depth, parameter_count, comment_ratio)
 VALUES
 	%s
 ON CONFLICT (clean_code_hash)
 DO UPDATE SET
   docstring = TRIM(CONCAT(extracted_functions.docstring, ' ', EXCLUDED.docstring)),
+  docstring_summary = CASE WHEN extracted_functions.docstring_summary = '' THEN EXCLUDED.docstring_summary ELSE extracted_functions.docstring_summary END,
+  docstring_sections = CASE WHEN extracted_functions.docstring_summary = '' THEN EXCLUDED.docstring_sections ELSE extracted_functions.docstring_sections END,
   inline_comments = TRIM(CONCAT(extracted_functions.inline_comments, ' ', EXCLUDED.inline_comments));
 `
 
+const insertMissingExtractedFunctionsQuery = `
+INSERT INTO extracted_functions (repo_id, path, docstring, docstring_summary, docstring_sections, inline_comments, clean_code, clean_code_hash, identifier, qualified_identifier, start_line, end_line, signature, receiver, parameters, return_type, visibility, is_static, is_async, role, token_count, cyclomatic_complexity, max_nesting_depth, parameter_count, comment_ratio)
+VALUES
+	%s
+ON CONFLICT (clean_code_hash)
+DO NOTHING;
+`
+
+// Replacing the functions of a file keeps the IDs of the unchanged functions. Functions are only updated if they
+// belong to the same file, duplicates from other files are left untouched.
+const replaceExtractedFunctionsQuery = `
+INSERT INTO extracted_functions (repo_id, path, docstring, docstring_summary, docstring_sections, inline_comments, clean_code, clean_code_hash, identifier, qualified_identifier, start_line, end_line, signature, receiver, parameters, return_type, visibility, is_static, is_async, role, token_count, cyclomatic_complexity, max_nesting_depth, parameter_count, comment_ratio)
+VALUES
+	%s
+ON CONFLICT (clean_code_hash)
+DO UPDATE SET
+  docstring = EXCLUDED.docstring,
+  docstring_summary = EXCLUDED.docstring_summary,
+  docstring_sections = EXCLUDED.docstring_sections,
+  inline_comments = EXCLUDED.inline_comments,
+  identifier = EXCLUDED.identifier,
+  qualified_identifier = EXCLUDED.qualified_identifier,
+  start_line = EXCLUDED.start_line,
+  end_line = EXCLUDED.end_line,
+  signature = EXCLUDED.signature,
+  receiver = EXCLUDED.receiver,
+  parameters = EXCLUDED.parameters,
+  return_type = EXCLUDED.return_type,
+  visibility = EXCLUDED.visibility,
+  is_static = EXCLUDED.is_static,
+  is_async = EXCLUDED.is_async,
+  role = EXCLUDED.role,
+  token_count = EXCLUDED.token_count,
+  cyclomatic_complexity = EXCLUDED.cyclomatic_complexity,
+  max_nesting_depth = EXCLUDED.max_nesting_depth,
+  parameter_count = EXCLUDED.parameter_count,
+  comment_ratio = EXCLUDED.comment_ratio
+WHERE extracted_functions.repo_id = EXCLUDED.repo_id AND extracted_functions.path = EXCLUDED.path;
+`
+
 func deduplicateExtractedFunctions(extractedFunctions []*ExtractedFunction) []*ExtractedFunction {
 	hashToDuplicateFunctions := map[string][]*ExtractedFunction{}
 	for _, ef := range extractedFunctions {
@@ -56,6 +181,14 @@ func deduplicateExtractedFunctions(extractedFunctions []*ExtractedFunction) []*E
 		}
 
 		deduplicatedFunction := duplicateFunctions[0]
+		// Keep the first summary, the summaries of several docstrings cannot be joined.
+		for _, duplicateFunction := range duplicateFunctions {
+			if duplicateFunction.DocstringSummary != "" {
+				deduplicatedFunction.DocstringSummary = duplicateFunction.DocstringSummary
+				deduplicatedFunction.DocstringSections = duplicateFunction.DocstringSections
+				break
+			}
+		}
 		deduplicatedFunction.Docstring = strings.TrimSpace(strings.Join(docstrings, " "))
 		deduplicatedFunction.InlineComments = strings.TrimSpace(strings.Join(inlineComments, " "))
 		deduplicatedFunctions = append(deduplicatedFunctions, deduplicatedFunction)
@@ -64,6 +197,159 @@ func deduplicateExtractedFunctions(extractedFunctions []*ExtractedFunction) []*E
 }
 
 func insertExtractedFunctionsFromFile(ctx context.Context, conn *pgx.Conn, repoID int, filePath string, extractedFunctions []*ExtractedFunction) error {
+	return insertExtractedFunctions(ctx, conn, insertExtractedFunctionsQuery, repoID, filePath, extractedFunctions)
+}
+
+// insertMissingExtractedFunctionsFromFile inserts the functions that were not extracted before, e.g. when extracting
+// another snapshot of a repo. Existing functions are left untouched, their snapshot docstrings are kept with the
+// snapshot occurrences instead.
+func insertMissingExtractedFunctionsFromFile(ctx context.Context, conn *pgx.Conn, repoID int, filePath string, extractedFunctions []*ExtractedFunction) error {
+	return insertExtractedFunctions(ctx, conn, insertMissingExtractedFunctionsQuery, repoID, filePath, extractedFunctions)
+}
+
+// replaceExtractedFunctionsFromFile replaces the functions previously extracted from the file in the snapshot.
+// The functions of the previous version of the file are deleted once they are not part of any snapshot anymore.
+func replaceExtractedFunctionsFromFile(ctx context.Context, conn *pgx.Conn, repoID int, snapshotID int, filePath string, license string, extractedFunctions []*ExtractedFunction) error {
+	_, err := conn.Exec(ctx, "DELETE FROM extracted_function_snapshots WHERE snapshot_id = $1 AND path = $2", snapshotID, filePath)
+	if err != nil {
+		return err
+	}
+
+	err = insertExtractedFunctions(ctx, conn, replaceExtractedFunctionsQuery, repoID, filePath, extractedFunctions)
+	if err != nil {
+		return err
+	}
+
+	err = insertExtractedFunctionSnapshots(ctx, conn, snapshotID, filePath, license, extractedFunctions)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(
+		ctx,
+		"DELETE FROM extracted_function_calls efc USING extracted_functions ef WHERE ef.id = efc.caller_id AND ef.repo_id = $1 AND ef.path = $2",
+		repoID,
+		filePath,
+	)
+	if err != nil {
+		return err
+	}
+	err = insertExtractedFunctionCalls(ctx, conn, repoID, filePath, extractedFunctions)
+	if err != nil {
+		return err
+	}
+
+	return deleteUnreferencedExtractedFunctions(ctx, conn, repoID, filePath)
+}
+
+func deleteUnreferencedExtractedFunctions(ctx context.Context, conn *pgx.Conn, repoID int, filePath string) error {
+	_, err := conn.Exec(
+		ctx,
+		"DELETE FROM extracted_functions ef WHERE ef.repo_id = $1 AND ef.path = $2 AND NOT EXISTS (SELECT 1 FROM extracted_function_snapshots efs WHERE efs.extracted_function_id = ef.id)",
+		repoID,
+		filePath,
+	)
+	return err
+}
+
+const insertExtractedFunctionSnapshotsQuery = `
+INSERT INTO extracted_function_snapshots (snapshot_id, extracted_function_id, path, license, docstring, start_line, end_line)
+SELECT $1, ef.id, $2, $3, o.docstring, o.start_line, o.end_line
+FROM unnest($4::text[], $5::text[], $6::integer[], $7::integer[]) AS o (clean_code_hash, docstring, start_line, end_line)
+JOIN extracted_functions ef ON ef.clean_code_hash = o.clean_code_hash
+ON CONFLICT DO NOTHING;
+`
+
+// insertExtractedFunctionSnapshots records the occurrences of the functions in the snapshot. The functions have
+// to be inserted first.
+func insertExtractedFunctionSnapshots(ctx context.Context, conn *pgx.Conn, snapshotID int, filePath string, license string, extractedFunctions []*ExtractedFunction) error {
+	if len(extractedFunctions) == 0 {
+		return nil
+	}
+
+	cleanCodeHashes := make([]string, 0, len(extractedFunctions))
+	docstrings := make([]string, 0, len(extractedFunctions))
+	startLines := make([]int, 0, len(extractedFunctions))
+	endLines := make([]int, 0, len(extractedFunctions))
+	for _, ef := range extractedFunctions {
+		cleanCodeHashes = append(cleanCodeHashes, ef.CleanCodeHash)
+		docstrings = append(docstrings, ef.Docstring)
+		startLines = append(startLines, ef.StartLine)
+		endLines = append(endLines, ef.EndLine)
+	}
+
+	_, err := conn.Exec(ctx, insertExtractedFunctionSnapshotsQuery, snapshotID, filePath, license, cleanCodeHashes, docstrings, startLines, endLines)
+	return err
+}
+
+const insertExtractedFunctionCallsQuery = `
+INSERT INTO extracted_function_calls (caller_id, callee_name, receiver)
+SELECT ef.id, c.callee_name, c.receiver
+FROM unnest($3::text[], $4::text[], $5::text[]) AS c (clean_code_hash, callee_name, receiver)
+JOIN extracted_functions ef ON ef.clean_code_hash = c.clean_code_hash AND ef.repo_id = $1 AND ef.path = $2
+ON CONFLICT DO NOTHING;
+`
+
+// insertExtractedFunctionCalls records the calls of the functions extracted from the file. The functions have to be
+// inserted first. Calls are only recorded for the functions extracted from the file, duplicates of functions from
+// other files or repos keep the calls of their own file.
+func insertExtractedFunctionCalls(ctx context.Context, conn *pgx.Conn, repoID int, filePath string, extractedFunctions []*ExtractedFunction) error {
+	cleanCodeHashes, calleeNames, receivers := []string{}, []string{}, []string{}
+	for _, ef := range extractedFunctions {
+		for _, call := range ef.Calls {
+			cleanCodeHashes = append(cleanCodeHashes, ef.CleanCodeHash)
+			calleeNames = append(calleeNames, call.Name)
+			receivers = append(receivers, call.Receiver)
+		}
+	}
+	if len(cleanCodeHashes) == 0 {
+		return nil
+	}
+
+	_, err := conn.Exec(ctx, insertExtractedFunctionCallsQuery, repoID, filePath, cleanCodeHashes, calleeNames, receivers)
+	return err
+}
+
+// Calls are resolved by the unqualified name of the functions of the repo. Calls without a receiver, or on the
+// calling object, are resolved to the only function with the name in the file of the caller, or to the only
+// function with the name in the repo if the file does not have any. Calls on other receivers are left unresolved.
+const resolveRepoFunctionCallsQuery = `
+WITH repo_functions AS (
+	SELECT id, path, substring(identifier from '([^.:]+)$') AS name
+	FROM extracted_functions
+	WHERE repo_id = $1
+),
+candidates AS (
+	SELECT efc.caller_id, efc.callee_name, efc.receiver,
+		array_agg(rf.id) FILTER (WHERE rf.path = caller.path) AS file_callee_ids,
+		array_agg(rf.id) AS repo_callee_ids
+	FROM extracted_function_calls efc
+	JOIN extracted_functions caller ON caller.id = efc.caller_id
+	JOIN repo_functions rf ON rf.name = efc.callee_name
+	WHERE caller.repo_id = $1 AND (efc.receiver = '' OR efc.receiver = ANY($2))
+	GROUP BY efc.caller_id, efc.callee_name, efc.receiver, caller.path
+)
+UPDATE extracted_function_calls efc
+SET callee_id = CASE
+	WHEN cardinality(c.file_callee_ids) = 1 THEN c.file_callee_ids[1]
+	WHEN c.file_callee_ids IS NULL AND cardinality(c.repo_callee_ids) = 1 THEN c.repo_callee_ids[1]
+END
+FROM candidates c
+WHERE efc.caller_id = c.caller_id AND efc.callee_name = c.callee_name AND efc.receiver = c.receiver;
+`
+
+// resolveRepoFunctionCalls resolves the calls of the functions of the repo to the functions they call, once every
+// file of the repo has been extracted.
+func resolveRepoFunctionCalls(ctx context.Context, conn *pgx.Conn, repoID int) error {
+	_, err := conn.Exec(ctx, "UPDATE extracted_function_calls efc SET callee_id = NULL FROM extracted_functions ef WHERE ef.id = efc.caller_id AND ef.repo_id = $1", repoID)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, resolveRepoFunctionCallsQuery, repoID, selfReceivers)
+	return err
+}
+
+func insertExtractedFunctions(ctx context.Context, conn *pgx.Conn, query string, repoID int, filePath string, extractedFunctions []*ExtractedFunction) error {
 	// Deduplicate extracted functions before inserting them because the ON CONFLICT clause does not work when inserting multiple duplicated values.
 	deduplicatedFunctions := deduplicateExtractedFunctions(extractedFunctions)
 	length := len(deduplicatedFunctions)
@@ -75,11 +361,190 @@ func insertExtractedFunctionsFromFile(ctx context.Context, conn *pgx.Conn, repoI
 
 		extractedFunctionsBatch := deduplicatedFunctions[i:end]
 
-		insertValuesParameters, valuesArgs := database.PrepareValuesForBulkInsert(extractedFunctionsBatch, 9, func(valueArgs []any, ef *ExtractedFunction) []any {
-			return append(valueArgs, repoID, filePath, ef.Docstring, ef.InlineComments, ef.CleanCode, ef.CleanCodeHash, ef.Identifier, ef.StartLine, ef.EndLine)
+		insertValuesParameters, valuesArgs := database.PrepareValuesForBulkInsert(extractedFunctionsBatch, 25, func(valueArgs []any, ef *ExtractedFunction) []any {
+			return append(
+				valueArgs,
+				repoID,
+				filePath,
+				ef.Docstring,
+				ef.DocstringSummary,
+				ef.DocstringSections.Marshal(),
+				ef.InlineComments,
+				ef.CleanCode,
+				ef.CleanCodeHash,
+				ef.Identifier,
+				ef.QualifiedIdentifier,
+				ef.StartLine,
+				ef.EndLine,
+				ef.Signature.Format(ef.Identifier),
+				ef.Signature.Receiver,
+				ef.Signature.MarshalParameters(),
+				ef.Signature.ReturnType,
+				ef.Signature.Visibility,
+				ef.Signature.IsStatic,
+				ef.Signature.IsAsync,
+				string(ef.Role),
+				ef.Metrics.TokenCount,
+				ef.Metrics.CyclomaticComplexity,
+				ef.Metrics.MaxNestingDepth,
+				ef.Metrics.ParameterCount,
+				ef.Metrics.CommentRatio,
+			)
+		})
+
+		_, err := conn.Exec(ctx, fmt.Sprintf(query, insertValuesParameters), valuesArgs...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const insertExtractedTypesQuery = `
+INSERT INTO extracted_types (repo_id, path, kind, identifier, docstring, docstring_summary, members, clean_code, clean_code_hash, start_line, end_line)
+VALUES
+	%s
+ON CONFLICT (clean_code_hash)
+DO UPDATE SET
+  docstring = TRIM(CONCAT(extracted_types.docstring, ' ', EXCLUDED.docstring)),
+  docstring_summary = CASE WHEN extracted_types.docstring_summary = '' THEN EXCLUDED.docstring_summary ELSE extracted_types.docstring_summary END;
+`
+
+const insertMissingExtractedTypesQuery = `
+INSERT INTO extracted_types (repo_id, path, kind, identifier, docstring, docstring_summary, members, clean_code, clean_code_hash, start_line, end_line)
+VALUES
+	%s
+ON CONFLICT (clean_code_hash)
+DO NOTHING;
+`
+
+const replaceExtractedTypesQuery = `
+INSERT INTO extracted_types (repo_id, path, kind, identifier, docstring, docstring_summary, members, clean_code, clean_code_hash, start_line, end_line)
+VALUES
+	%s
+ON CONFLICT (clean_code_hash)
+DO UPDATE SET
+  kind = EXCLUDED.kind,
+  identifier = EXCLUDED.identifier,
+  docstring = EXCLUDED.docstring,
+  docstring_summary = EXCLUDED.docstring_summary,
+  members = EXCLUDED.members,
+  start_line = EXCLUDED.start_line,
+  end_line = EXCLUDED.end_line
+WHERE extracted_types.repo_id = EXCLUDED.repo_id AND extracted_types.path = EXCLUDED.path;
+`
+
+func deduplicateExtractedTypes(extractedTypes []*ExtractedType) []*ExtractedType {
+	hashToDuplicateTypes := map[string][]*ExtractedType{}
+	for _, et := range extractedTypes {
+		hashToDuplicateTypes[et.CleanCodeHash] = append(hashToDuplicateTypes[et.CleanCodeHash], et)
+	}
+
+	deduplicatedTypes := make([]*ExtractedType, 0, len(hashToDuplicateTypes))
+	for _, duplicateTypes := range hashToDuplicateTypes {
+		docstrings := make([]string, 0, len(duplicateTypes))
+		for _, duplicateType := range duplicateTypes {
+			docstrings = append(docstrings, duplicateType.Docstring)
+		}
+
+		deduplicatedType := duplicateTypes[0]
+		for _, duplicateType := range duplicateTypes {
+			if duplicateType.DocstringSummary != "" {
+				deduplicatedType.DocstringSummary = duplicateType.DocstringSummary
+				break
+			}
+		}
+		deduplicatedType.Docstring = strings.TrimSpace(strings.Join(docstrings, " "))
+		deduplicatedTypes = append(deduplicatedTypes, deduplicatedType)
+	}
+	return deduplicatedTypes
+}
+
+func insertExtractedTypesFromFile(ctx context.Context, conn *pgx.Conn, repoID int, filePath string, extractedTypes []*ExtractedType) error {
+	return insertExtractedTypes(ctx, conn, insertExtractedTypesQuery, repoID, filePath, extractedTypes)
+}
+
+func insertMissingExtractedTypesFromFile(ctx context.Context, conn *pgx.Conn, repoID int, filePath string, extractedTypes []*ExtractedType) error {
+	return insertExtractedTypes(ctx, conn, insertMissingExtractedTypesQuery, repoID, filePath, extractedTypes)
+}
+
+// replaceExtractedTypesFromFile replaces the types previously extracted from the file in the snapshot, in the same
+// way as functions.
+func replaceExtractedTypesFromFile(ctx context.Context, conn *pgx.Conn, repoID int, snapshotID int, filePath string, license string, extractedTypes []*ExtractedType) error {
+	_, err := conn.Exec(ctx, "DELETE FROM extracted_type_snapshots WHERE snapshot_id = $1 AND path = $2", snapshotID, filePath)
+	if err != nil {
+		return err
+	}
+
+	err = insertExtractedTypes(ctx, conn, replaceExtractedTypesQuery, repoID, filePath, extractedTypes)
+	if err != nil {
+		return err
+	}
+
+	err = insertExtractedTypeSnapshots(ctx, conn, snapshotID, filePath, license, extractedTypes)
+	if err != nil {
+		return err
+	}
+
+	return deleteUnreferencedExtractedTypes(ctx, conn, repoID, filePath)
+}
+
+func deleteUnreferencedExtractedTypes(ctx context.Context, conn *pgx.Conn, repoID int, filePath string) error {
+	_, err := conn.Exec(
+		ctx,
+		"DELETE FROM extracted_types et WHERE et.repo_id = $1 AND et.path = $2 AND NOT EXISTS (SELECT 1 FROM extracted_type_snapshots ets WHERE ets.extracted_type_id = et.id)",
+		repoID,
+		filePath,
+	)
+	return err
+}
+
+const insertExtractedTypeSnapshotsQuery = `
+INSERT INTO extracted_type_snapshots (snapshot_id, extracted_type_id, path, license, docstring, start_line, end_line)
+SELECT $1, et.id, $2, $3, o.docstring, o.start_line, o.end_line
+FROM unnest($4::text[], $5::text[], $6::integer[], $7::integer[]) AS o (clean_code_hash, docstring, start_line, end_line)
+JOIN extracted_types et ON et.clean_code_hash = o.clean_code_hash
+ON CONFLICT DO NOTHING;
+`
+
+func insertExtractedTypeSnapshots(ctx context.Context, conn *pgx.Conn, snapshotID int, filePath string, license string, extractedTypes []*ExtractedType) error {
+	if len(extractedTypes) == 0 {
+		return nil
+	}
+
+	cleanCodeHashes := make([]string, 0, len(extractedTypes))
+	docstrings := make([]string, 0, len(extractedTypes))
+	startLines := make([]int, 0, len(extractedTypes))
+	endLines := make([]int, 0, len(extractedTypes))
+	for _, et := range extractedTypes {
+		cleanCodeHashes = append(cleanCodeHashes, et.CleanCodeHash)
+		docstrings = append(docstrings, et.Docstring)
+		startLines = append(startLines, et.StartLine)
+		endLines = append(endLines, et.EndLine)
+	}
+
+	_, err := conn.Exec(ctx, insertExtractedTypeSnapshotsQuery, snapshotID, filePath, license, cleanCodeHashes, docstrings, startLines, endLines)
+	return err
+}
+
+func insertExtractedTypes(ctx context.Context, conn *pgx.Conn, query string, repoID int, filePath string, extractedTypes []*ExtractedType) error {
+	// Deduplicate extracted types for the same reason as extracted functions.
+	deduplicatedTypes := deduplicateExtractedTypes(extractedTypes)
+	length := len(deduplicatedTypes)
+	for i := 0; i < length; i += insertExtractedFunctionsBatchSize {
+		end := i + insertExtractedFunctionsBatchSize
+		if end > length {
+			end = length
+		}
+
+		extractedTypesBatch := deduplicatedTypes[i:end]
+
+		insertValuesParameters, valuesArgs := database.PrepareValuesForBulkInsert(extractedTypesBatch, 11, func(valueArgs []any, et *ExtractedType) []any {
+			return append(valueArgs, repoID, filePath, et.Kind, et.Identifier, et.Docstring, et.DocstringSummary, strings.Join(et.Members, " "), et.CleanCode, et.CleanCodeHash, et.StartLine, et.EndLine)
 		})
 
-		_, err := conn.Exec(ctx, fmt.Sprintf(insertExtractedFunctionsQuery, insertValuesParameters), valuesArgs...)
+		_, err := conn.Exec(ctx, fmt.Sprintf(query, insertValuesParameters), valuesArgs...)
 		if err != nil {
 			return err
 		}
@@ -87,3 +552,102 @@ func insertExtractedFunctionsFromFile(ctx context.Context, conn *pgx.Conn, repoI
 
 	return nil
 }
+
+func insertExcludedFile(ctx context.Context, conn *pgx.Conn, snapshotID int, filePath string, exclusion *filefilter.Exclusion) error {
+	_, err := conn.Exec(
+		ctx,
+		"INSERT INTO excluded_files (snapshot_id, path, reason, detail) VALUES ($1, $2, $3, $4) ON CONFLICT (snapshot_id, path) DO UPDATE SET reason = EXCLUDED.reason, detail = EXCLUDED.detail",
+		snapshotID,
+		filePath,
+		string(exclusion.Reason),
+		exclusion.Detail,
+	)
+	return err
+}
+
+func insertParsedFile(ctx context.Context, conn *pgx.Conn, snapshotID int, filePath string, language string, parseErrors *ph.ParseErrors) error {
+	_, err := conn.Exec(
+		ctx,
+		"INSERT INTO parsed_files (snapshot_id, path, language, error_nodes, missing_nodes) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (snapshot_id, path) DO UPDATE SET language = EXCLUDED.language, error_nodes = EXCLUDED.error_nodes, missing_nodes = EXCLUDED.missing_nodes",
+		snapshotID,
+		filePath,
+		language,
+		parseErrors.ErrorNodes,
+		parseErrors.MissingNodes,
+	)
+	return err
+}
+
+// deleteFileRecords deletes the exclusion or parse errors recorded for the file in the snapshot.
+func deleteFileRecords(ctx context.Context, conn *pgx.Conn, snapshotID int, filePath string) error {
+	_, err := conn.Exec(ctx, "DELETE FROM excluded_files WHERE snapshot_id = $1 AND path = $2", snapshotID, filePath)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, "DELETE FROM parsed_files WHERE snapshot_id = $1 AND path = $2", snapshotID, filePath)
+	return err
+}
+
+// deleteExtractedFromFile removes a deleted file from the snapshot, and deletes its functions and types once they
+// are not part of any snapshot anymore.
+func deleteExtractedFromFile(ctx context.Context, conn *pgx.Conn, repoID int, snapshotID int, filePath string) error {
+	err := deleteFileRecords(ctx, conn, snapshotID, filePath)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(ctx, "DELETE FROM extracted_function_snapshots WHERE snapshot_id = $1 AND path = $2", snapshotID, filePath)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, "DELETE FROM extracted_type_snapshots WHERE snapshot_id = $1 AND path = $2", snapshotID, filePath)
+	if err != nil {
+		return err
+	}
+
+	err = deleteUnreferencedExtractedFunctions(ctx, conn, repoID, filePath)
+	if err != nil {
+		return err
+	}
+	return deleteUnreferencedExtractedTypes(ctx, conn, repoID, filePath)
+}
+
+// renameExtractedFile moves the functions and types of a renamed file to its new path in the snapshot, so they keep
+// their IDs. Functions and types that other snapshots still reference keep their path, only the snapshot occurrences
+// are moved.
+func renameExtractedFile(ctx context.Context, conn *pgx.Conn, repoID int, snapshotID int, oldFilePath string, newFilePath string) error {
+	// The file is recorded again for the new path, exclusions depend on it.
+	err := deleteFileRecords(ctx, conn, snapshotID, oldFilePath)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(
+		ctx,
+		"UPDATE extracted_functions ef SET path = $1 WHERE ef.repo_id = $2 AND ef.path = $3 AND NOT EXISTS (SELECT 1 FROM extracted_function_snapshots efs WHERE efs.extracted_function_id = ef.id AND efs.snapshot_id <> $4)",
+		newFilePath,
+		repoID,
+		oldFilePath,
+		snapshotID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(
+		ctx,
+		"UPDATE extracted_types et SET path = $1 WHERE et.repo_id = $2 AND et.path = $3 AND NOT EXISTS (SELECT 1 FROM extracted_type_snapshots ets WHERE ets.extracted_type_id = et.id AND ets.snapshot_id <> $4)",
+		newFilePath,
+		repoID,
+		oldFilePath,
+		snapshotID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, "UPDATE extracted_function_snapshots SET path = $1 WHERE snapshot_id = $2 AND path = $3", newFilePath, snapshotID, oldFilePath)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, "UPDATE extracted_type_snapshots SET path = $1 WHERE snapshot_id = $2 AND path = $3", newFilePath, snapshotID, oldFilePath)
+	return err
+}
@@ -3,9 +3,11 @@ package functionextractor
 import (
 	"codesearch-ai-data/internal/database"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v4"
 )
@@ -158,3 +160,519 @@ func TestInsertingLargeExtractedFunctionsBatch(t *testing.T) {
 		t.Fatalf("Expected %d extracted functions, got %d", nExtractedFunctions, extractedFunctionsCount)
 	}
 }
+
+func getExtractedFunctionIDs(ctx context.Context, conn *pgx.Conn) (map[string]int, error) {
+	rows, err := conn.Query(ctx, "SELECT clean_code_hash, id FROM extracted_functions")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[string]int{}
+	for rows.Next() {
+		var cleanCodeHash string
+		var id int
+		err := rows.Scan(&cleanCodeHash, &id)
+		if err != nil {
+			return nil, err
+		}
+		ids[cleanCodeHash] = id
+	}
+	return ids, rows.Err()
+}
+
+func TestReplacingExtractedFunctionsKeepsIDs(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, os.Getenv("CODESEARCH_AI_DATA_TEST_DATABASE_URL"))
+	if err != nil {
+		t.Fatal("Unable to connect to database", err)
+	}
+
+	err = database.InitializeDatabaseSchema(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := database.ResetDatabaseSchema(ctx, conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conn.Close(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	var repoID int
+	repoID, err = insertRepo(ctx, conn, "Test", "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trackedSnapshotID, err := upsertRepoSnapshot(ctx, conn, repoID, TRACKED_SNAPSHOT_REF, "commit", "MIT", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagSnapshotID, err := upsertRepoSnapshot(ctx, conn, repoID, "v1", "commit", "MIT", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileAFunctions := []*ExtractedFunction{
+		{CleanCode: "a", CleanCodeHash: "a", Docstring: "d1"},
+		{CleanCode: "b", CleanCodeHash: "b"},
+	}
+	fileBFunctions := []*ExtractedFunction{{CleanCode: "c", CleanCodeHash: "c"}}
+	for _, snapshotID := range []int{trackedSnapshotID, tagSnapshotID} {
+		for filePath, extractedFunctions := range map[string][]*ExtractedFunction{"/a": fileAFunctions, "/b": fileBFunctions} {
+			err = insertMissingExtractedFunctionsFromFile(ctx, conn, repoID, filePath, extractedFunctions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = insertExtractedFunctionSnapshots(ctx, conn, snapshotID, filePath, "", extractedFunctions)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	idsBefore, err := getExtractedFunctionIDs(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = replaceExtractedFunctionsFromFile(ctx, conn, repoID, trackedSnapshotID, "/a", "", []*ExtractedFunction{
+		{CleanCode: "a", CleanCodeHash: "a", Docstring: "d2", StartLine: 10},
+		{CleanCode: "d", CleanCodeHash: "d"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = deleteExtractedFromFile(ctx, conn, repoID, trackedSnapshotID, "/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idsAfter, err := getExtractedFunctionIDs(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Functions removed from the tracked snapshot are kept while the tag snapshot references them.
+	if len(idsAfter) != 4 {
+		t.Fatalf("Expected 4 extracted functions, got %d", len(idsAfter))
+	}
+	for _, cleanCodeHash := range []string{"a", "b", "c"} {
+		if idsAfter[cleanCodeHash] != idsBefore[cleanCodeHash] {
+			t.Fatalf("Expected function %s to keep ID %d, got %d", cleanCodeHash, idsBefore[cleanCodeHash], idsAfter[cleanCodeHash])
+		}
+	}
+
+	docstring, _, err := getExtractedFunctionDocstringAndInlineComments(ctx, conn, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docstring != "d2" {
+		t.Fatalf("Expected docstring to be replaced with `d2`, got `%s`", docstring)
+	}
+
+	_, err = conn.Exec(ctx, "DELETE FROM repo_snapshots WHERE id = $1", tagSnapshotID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = deleteExtractedFromFile(ctx, conn, repoID, trackedSnapshotID, "/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idsAfter, err = getExtractedFunctionIDs(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := idsAfter["c"]; ok {
+		t.Fatal("Expected function c to be deleted once no snapshot references it")
+	}
+}
+
+func getExtractedFunctionPaths(ctx context.Context, conn *pgx.Conn) (map[string]string, error) {
+	rows, err := conn.Query(ctx, "SELECT clean_code_hash, path FROM extracted_functions")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paths := map[string]string{}
+	for rows.Next() {
+		var cleanCodeHash, path string
+		err := rows.Scan(&cleanCodeHash, &path)
+		if err != nil {
+			return nil, err
+		}
+		paths[cleanCodeHash] = path
+	}
+	return paths, rows.Err()
+}
+
+func TestRenamingExtractedFileKeepsOtherSnapshots(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, os.Getenv("CODESEARCH_AI_DATA_TEST_DATABASE_URL"))
+	if err != nil {
+		t.Fatal("Unable to connect to database", err)
+	}
+
+	err = database.InitializeDatabaseSchema(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := database.ResetDatabaseSchema(ctx, conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conn.Close(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	repoID, err := insertRepo(ctx, conn, "Test", "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trackedSnapshotID, err := upsertRepoSnapshot(ctx, conn, repoID, TRACKED_SNAPSHOT_REF, "commit", "MIT", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagSnapshotID, err := upsertRepoSnapshot(ctx, conn, repoID, "v1", "commit", "MIT", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshotFunctions := map[int][]*ExtractedFunction{
+		trackedSnapshotID: {{CleanCode: "a", CleanCodeHash: "a"}, {CleanCode: "b", CleanCodeHash: "b"}},
+		tagSnapshotID:     {{CleanCode: "a", CleanCodeHash: "a"}},
+	}
+	for snapshotID, extractedFunctions := range snapshotFunctions {
+		err = insertMissingExtractedFunctionsFromFile(ctx, conn, repoID, "/a", extractedFunctions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = insertExtractedFunctionSnapshots(ctx, conn, snapshotID, "/a", "", extractedFunctions)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = renameExtractedFile(ctx, conn, repoID, trackedSnapshotID, "/a", "/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Function a is still at /a in the tag snapshot, only function b moves with the renamed file.
+	paths, err := getExtractedFunctionPaths(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths["a"] != "/a" || paths["b"] != "/c" {
+		t.Fatalf("Expected functions a and b at /a and /c, got %s and %s", paths["a"], paths["b"])
+	}
+
+	var tagSnapshotPath string
+	err = conn.QueryRow(ctx, "SELECT path FROM extracted_function_snapshots WHERE snapshot_id = $1", tagSnapshotID).Scan(&tagSnapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagSnapshotPath != "/a" {
+		t.Fatalf("Expected the tag snapshot to keep /a, got %s", tagSnapshotPath)
+	}
+}
+
+func getResolvedCalls(ctx context.Context, conn *pgx.Conn) (map[string]*int, error) {
+	rows, err := conn.Query(ctx, "SELECT ef.clean_code_hash, efc.callee_name, efc.receiver, efc.callee_id FROM extracted_function_calls efc JOIN extracted_functions ef ON ef.id = efc.caller_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	calls := map[string]*int{}
+	for rows.Next() {
+		var cleanCodeHash, calleeName, receiver string
+		var calleeID *int
+		err := rows.Scan(&cleanCodeHash, &calleeName, &receiver, &calleeID)
+		if err != nil {
+			return nil, err
+		}
+		calls[fmt.Sprintf("%s->%s.%s", cleanCodeHash, receiver, calleeName)] = calleeID
+	}
+	return calls, rows.Err()
+}
+
+func TestResolvingFunctionCalls(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, os.Getenv("CODESEARCH_AI_DATA_TEST_DATABASE_URL"))
+	if err != nil {
+		t.Fatal("Unable to connect to database", err)
+	}
+
+	err = database.InitializeDatabaseSchema(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := database.ResetDatabaseSchema(ctx, conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conn.Close(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	repoID, err := insertRepo(ctx, conn, "Test", "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]*ExtractedFunction{
+		"/a": {
+			{CleanCodeHash: "a", Identifier: "Parser.parse", Calls: []Call{{Name: "tokenize"}, {Name: "validate", Receiver: "self"}, {Name: "helper"}, {Name: "read", Receiver: "file"}}},
+			{CleanCodeHash: "b", Identifier: "Parser.validate"},
+			{CleanCodeHash: "c", Identifier: "tokenize"},
+		},
+		"/b": {
+			{CleanCodeHash: "d", Identifier: "tokenize", Calls: []Call{{Name: "helper"}}},
+			{CleanCodeHash: "e", Identifier: "helper"},
+		},
+		"/c": {
+			{CleanCodeHash: "f", Identifier: "helper"},
+		},
+	}
+	for filePath, extractedFunctions := range files {
+		err = insertExtractedFunctionsFromFile(ctx, conn, repoID, filePath, extractedFunctions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = insertExtractedFunctionCalls(ctx, conn, repoID, filePath, extractedFunctions)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = resolveRepoFunctionCalls(ctx, conn, repoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := getExtractedFunctionIDs(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls, err := getResolvedCalls(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		call       string
+		wantCallee string
+	}{
+		// The function in the same file wins over the functions with the same name in other files.
+		{call: "a->.tokenize", wantCallee: "c"},
+		{call: "a->self.validate", wantCallee: "b"},
+		// Calls to functions with the same name in several other files are ambiguous.
+		{call: "a->.helper", wantCallee: ""},
+		{call: "a->file.read", wantCallee: ""},
+		{call: "d->.helper", wantCallee: "e"},
+	}
+	for _, tt := range tests {
+		calleeID, ok := calls[tt.call]
+		if !ok {
+			t.Fatalf("Expected call %s to be recorded", tt.call)
+		}
+		if tt.wantCallee == "" && calleeID != nil {
+			t.Fatalf("Expected call %s to be unresolved, got %d", tt.call, *calleeID)
+		}
+		if tt.wantCallee != "" && (calleeID == nil || *calleeID != ids[tt.wantCallee]) {
+			t.Fatalf("Expected call %s to be resolved to %d, got %v", tt.call, ids[tt.wantCallee], calleeID)
+		}
+	}
+}
+
+func TestRepoJobs(t *testing.T) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, os.Getenv("CODESEARCH_AI_DATA_TEST_DATABASE_URL"))
+	if err != nil {
+		t.Fatal("Unable to connect to database", err)
+	}
+
+	err = database.InitializeDatabaseSchema(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := database.ResetDatabaseSchema(ctx, conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conn.Close(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	err = EnqueueRepoJobs(ctx, conn, []string{"a", "b"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attempts, err := StartRepoJob(ctx, conn, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The failed attempt inserted the repo, so the next attempt deletes it and starts over.
+	repoID, err := insertRepo(ctx, conn, "a", "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = recordRepoJobRepo(ctx, conn, "a", repoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = FinishRepoJob(ctx, conn, "a", 1500*time.Millisecond, errors.New("clone failed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := GetRepoJob(ctx, conn, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 1 || job.Status != RepoJobFailed || job.Error != "clone failed" || job.Duration != 1500*time.Millisecond {
+		t.Fatalf("unexpected failed job after %d attempts: %+v", attempts, job)
+	}
+
+	attempts, err = StartRepoJob(ctx, conn, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+	var repoCount int
+	err = conn.QueryRow(ctx, "SELECT COUNT(*) FROM repos WHERE name = 'a'").Scan(&repoCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repoCount != 0 {
+		t.Fatal("expected the repo of the failed attempt to be deleted")
+	}
+	err = FinishRepoJob(ctx, conn, "a", time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed attempt that did not insert the repo does not delete it once another run extracted it.
+	_, err = StartRepoJob(ctx, conn, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = FinishRepoJob(ctx, conn, "b", time.Second, errors.New("repo b already exists"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = insertRepo(ctx, conn, "b", "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = StartRepoJob(ctx, conn, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.QueryRow(ctx, "SELECT COUNT(*) FROM repos WHERE name = 'b'").Scan(&repoCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repoCount != 1 {
+		t.Fatal("expected the repo extracted by another run to be kept")
+	}
+	err = FinishRepoJob(ctx, conn, "b", time.Second, errors.New("repo b already exists"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The partial repo of an interrupted attempt is not extracted.
+	_, err = StartRepoJob(ctx, conn, "e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoID, err = insertRepo(ctx, conn, "e", "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = recordRepoJobRepo(ctx, conn, "e", repoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resuming keeps the existing jobs, and the jobs of extracted repos have succeeded whatever their status.
+	_, err = insertRepo(ctx, conn, "d", "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = EnqueueRepoJobs(ctx, conn, []string{"a", "b", "c", "d", "e"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, repoName := range []string{"b", "d"} {
+		job, err := GetRepoJob(ctx, conn, repoName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != RepoJobSucceeded {
+			t.Fatalf("expected a succeeded job for %s, got %+v", repoName, job)
+		}
+	}
+	job, err = GetRepoJob(ctx, conn, "e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != RepoJobRunning {
+		t.Fatalf("expected the interrupted job to be kept, got %+v", job)
+	}
+	job, err = GetRepoJob(ctx, conn, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != RepoJobSucceeded || job.Attempts != 2 || job.Error != "" {
+		t.Fatalf("unexpected succeeded job: %+v", job)
+	}
+	job, err = GetRepoJob(ctx, conn, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != RepoJobPending {
+		t.Fatalf("expected a pending job, got %+v", job)
+	}
+
+	// Enqueueing without resuming resets the jobs.
+	err = EnqueueRepoJobs(ctx, conn, []string{"a"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err = GetRepoJob(ctx, conn, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != RepoJobPending || job.Attempts != 0 {
+		t.Fatalf("expected a reset job, got %+v", job)
+	}
+
+	job, err = GetRepoJob(ctx, conn, "unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job != nil {
+		t.Fatalf("expected no job, got %+v", job)
+	}
+}
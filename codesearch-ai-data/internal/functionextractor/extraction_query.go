@@ -0,0 +1,340 @@
+package functionextractor
+
+import (
+	"codesearch-ai-data/internal/languages"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"sync"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Extraction queries live in queries/<entity>/<language>.scm, where entity is functions or types.
+//
+// When several patterns match the same captured entity node, the first pattern in the file wins. If its predicates
+// fail, the entity is skipped. Supported predicates are #eq?, #not-eq?, #match?, #not-match?, #any-of?
+// and #not-any-of?. Patterns can set properties with the #set! directive, e.g. (#set! kind "class").
+//
+//go:embed queries
+var queryFiles embed.FS
+
+var errNoExtractionQuery = errors.New("no extraction query")
+
+type queryPredicate struct {
+	operator     string
+	capture      string
+	otherCapture string
+	values       []string
+	regexp       *regexp.Regexp
+}
+
+type extractionQuery struct {
+	query      *sitter.Query
+	predicates [][]queryPredicate
+	properties []map[string]string
+}
+
+type queryMatch struct {
+	patternIndex int
+	captures     map[string]*sitter.Node
+}
+
+var extractionQueriesMutex sync.Mutex
+var extractionQueries = map[string]*extractionQuery{}
+
+// getExtractionQuery returns the compiled entity query for the grammar used by files with the extension.
+// It returns errNoExtractionQuery if the language does not have a query for the entity.
+func getExtractionQuery(entity string, language *languages.Language, fileExtension string) (*extractionQuery, error) {
+	grammarName, grammar := language.GrammarForExtension(fileExtension)
+	cacheKey := entity + ":" + grammarName
+
+	extractionQueriesMutex.Lock()
+	defer extractionQueriesMutex.Unlock()
+
+	if extractionQuery, ok := extractionQueries[cacheKey]; ok {
+		return extractionQuery, nil
+	}
+
+	source, err := queryFiles.ReadFile(fmt.Sprintf("queries/%s/%s.scm", entity, language.Name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s", errNoExtractionQuery, language.Name, entity)
+	} else if err != nil {
+		return nil, err
+	}
+
+	extractionQuery, err := newExtractionQuery(source, grammar())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s query: %w", grammarName, entity, err)
+	}
+
+	extractionQueries[cacheKey] = extractionQuery
+	return extractionQuery, nil
+}
+
+// getOptionalExtractionQuery returns nil if the language does not have a query for the entity.
+func getOptionalExtractionQuery(entity string, language *languages.Language, fileExtension string) (*extractionQuery, error) {
+	extractionQuery, err := getExtractionQuery(entity, language, fileExtension)
+	if errors.Is(err, errNoExtractionQuery) {
+		return nil, nil
+	}
+	return extractionQuery, err
+}
+
+func newExtractionQuery(source []byte, grammar *sitter.Language) (*extractionQuery, error) {
+	query, err := sitter.NewQuery(source, grammar)
+	if err != nil {
+		return nil, err
+	}
+
+	patternCount := int(query.PatternCount())
+	extractionQuery := &extractionQuery{
+		query:      query,
+		predicates: make([][]queryPredicate, patternCount),
+		properties: make([]map[string]string, patternCount),
+	}
+
+	for patternIndex := 0; patternIndex < patternCount; patternIndex++ {
+		extractionQuery.properties[patternIndex] = map[string]string{}
+
+		for _, steps := range splitPredicateSteps(query.PredicatesForPattern(uint32(patternIndex))) {
+			operator := query.StringValueForId(steps[0].ValueId)
+			if operator == "set!" {
+				if len(steps) != 3 || steps[1].Type != sitter.QueryPredicateStepTypeString || steps[2].Type != sitter.QueryPredicateStepTypeString {
+					return nil, fmt.Errorf("pattern %d: #set! expects a key and a value", patternIndex)
+				}
+				extractionQuery.properties[patternIndex][query.StringValueForId(steps[1].ValueId)] = query.StringValueForId(steps[2].ValueId)
+				continue
+			}
+
+			predicate, err := newQueryPredicate(query, operator, steps[1:])
+			if err != nil {
+				return nil, fmt.Errorf("pattern %d: %w", patternIndex, err)
+			}
+			extractionQuery.predicates[patternIndex] = append(extractionQuery.predicates[patternIndex], predicate)
+		}
+	}
+
+	return extractionQuery, nil
+}
+
+// splitPredicateSteps splits the flat list of predicate steps at the Done steps.
+func splitPredicateSteps(steps []sitter.QueryPredicateStep) [][]sitter.QueryPredicateStep {
+	predicates := [][]sitter.QueryPredicateStep{}
+	start := 0
+	for i, step := range steps {
+		if step.Type == sitter.QueryPredicateStepTypeDone {
+			if i > start {
+				predicates = append(predicates, steps[start:i])
+			}
+			start = i + 1
+		}
+	}
+	return predicates
+}
+
+func newQueryPredicate(query *sitter.Query, operator string, args []sitter.QueryPredicateStep) (queryPredicate, error) {
+	if len(args) < 2 || args[0].Type != sitter.QueryPredicateStepTypeCapture {
+		return queryPredicate{}, fmt.Errorf("#%s expects a capture followed by arguments", operator)
+	}
+
+	predicate := queryPredicate{operator: operator, capture: query.CaptureNameForId(args[0].ValueId)}
+	switch operator {
+	case "eq?", "not-eq?":
+		if len(args) != 2 {
+			return queryPredicate{}, fmt.Errorf("#%s expects two arguments", operator)
+		}
+		if args[1].Type == sitter.QueryPredicateStepTypeCapture {
+			predicate.otherCapture = query.CaptureNameForId(args[1].ValueId)
+		} else {
+			predicate.values = []string{query.StringValueForId(args[1].ValueId)}
+		}
+	case "match?", "not-match?":
+		if len(args) != 2 || args[1].Type != sitter.QueryPredicateStepTypeString {
+			return queryPredicate{}, fmt.Errorf("#%s expects a capture and a regexp", operator)
+		}
+		re, err := regexp.Compile(query.StringValueForId(args[1].ValueId))
+		if err != nil {
+			return queryPredicate{}, err
+		}
+		predicate.regexp = re
+	case "any-of?", "not-any-of?":
+		for _, arg := range args[1:] {
+			if arg.Type != sitter.QueryPredicateStepTypeString {
+				return queryPredicate{}, fmt.Errorf("#%s expects string arguments", operator)
+			}
+			predicate.values = append(predicate.values, query.StringValueForId(arg.ValueId))
+		}
+	default:
+		return queryPredicate{}, fmt.Errorf("unsupported predicate #%s", operator)
+	}
+
+	return predicate, nil
+}
+
+// matches evaluates the predicate against the captured nodes. Predicates on captures that are
+// missing from the match (e.g. optional captures) are satisfied.
+func (p *queryPredicate) matches(captures map[string]*sitter.Node, code []byte) bool {
+	node, ok := captures[p.capture]
+	if !ok {
+		return true
+	}
+	text := node.Content(code)
+
+	switch p.operator {
+	case "eq?", "not-eq?":
+		other := ""
+		if p.otherCapture != "" {
+			otherNode, ok := captures[p.otherCapture]
+			if !ok {
+				return true
+			}
+			other = otherNode.Content(code)
+		} else {
+			other = p.values[0]
+		}
+		return (text == other) == (p.operator == "eq?")
+	case "match?", "not-match?":
+		return p.regexp.MatchString(text) == (p.operator == "match?")
+	case "any-of?", "not-any-of?":
+		return contains(p.values, text) == (p.operator == "any-of?")
+	}
+
+	return false
+}
+
+// getMatches returns all the matches in the tree, without evaluating the predicates.
+func (eq *extractionQuery) getMatches(rootNode *sitter.Node) []*queryMatch {
+	cursor := sitter.NewQueryCursor()
+	defer cursor.Close()
+	cursor.Exec(eq.query, rootNode)
+
+	matches := []*queryMatch{}
+	for {
+		match, ok := cursor.NextMatch()
+		if !ok {
+			break
+		}
+
+		captures := map[string]*sitter.Node{}
+		for _, capture := range match.Captures {
+			name := eq.query.CaptureNameForId(capture.Index)
+			// Keep the first node for quantified captures.
+			if _, ok := captures[name]; !ok {
+				captures[name] = capture.Node
+			}
+		}
+		matches = append(matches, &queryMatch{int(match.PatternIndex), captures})
+	}
+	return matches
+}
+
+// getWinningMatches returns the winning match for each node captured as entityCapture, in breadth-first order.
+func (eq *extractionQuery) getWinningMatches(matches []*queryMatch, entityCapture string, code []byte) []*queryMatch {
+	nodeMatches := map[*sitter.Node]*queryMatch{}
+	for _, match := range matches {
+		node, ok := match.captures[entityCapture]
+		if !ok {
+			continue
+		}
+		if existingMatch, ok := nodeMatches[node]; ok && existingMatch.patternIndex <= match.patternIndex {
+			continue
+		}
+		nodeMatches[node] = match
+	}
+
+	type nodeWithDepth struct {
+		node  *sitter.Node
+		depth int
+	}
+	nodes := make([]nodeWithDepth, 0, len(nodeMatches))
+	for node := range nodeMatches {
+		nodes = append(nodes, nodeWithDepth{node, getNodeDepth(node)})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].depth != nodes[j].depth {
+			return nodes[i].depth < nodes[j].depth
+		}
+		return nodes[i].node.StartByte() < nodes[j].node.StartByte()
+	})
+
+	winningMatches := make([]*queryMatch, 0, len(nodes))
+	for _, node := range nodes {
+		match := nodeMatches[node.node]
+		if eq.matchesPredicates(match, code) {
+			winningMatches = append(winningMatches, match)
+		}
+	}
+	return winningMatches
+}
+
+type mergedMatch struct {
+	captures   map[string]*sitter.Node
+	properties map[string]string
+}
+
+// mergeMatches merges the matches, in pattern order, for each node captured as entityCapture.
+func (eq *extractionQuery) mergeMatches(matches []*queryMatch, entityCapture string, code []byte) map[*sitter.Node]*mergedMatch {
+	sortedMatches := make([]*queryMatch, len(matches))
+	copy(sortedMatches, matches)
+	sort.SliceStable(sortedMatches, func(i, j int) bool { return sortedMatches[i].patternIndex < sortedMatches[j].patternIndex })
+
+	mergedMatches := map[*sitter.Node]*mergedMatch{}
+	for _, match := range sortedMatches {
+		node, ok := match.captures[entityCapture]
+		if !ok || !eq.matchesPredicates(match, code) {
+			continue
+		}
+
+		merged, ok := mergedMatches[node]
+		if !ok {
+			merged = &mergedMatch{map[string]*sitter.Node{}, map[string]string{}}
+			mergedMatches[node] = merged
+		}
+		for name, captureNode := range match.captures {
+			if _, ok := merged.captures[name]; !ok {
+				merged.captures[name] = captureNode
+			}
+		}
+		for key, value := range eq.properties[match.patternIndex] {
+			if _, ok := merged.properties[key]; !ok {
+				merged.properties[key] = value
+			}
+		}
+	}
+	return mergedMatches
+}
+
+func (eq *extractionQuery) matchesPredicates(match *queryMatch, code []byte) bool {
+	for _, predicate := range eq.predicates[match.patternIndex] {
+		if !predicate.matches(match.captures, code) {
+			return false
+		}
+	}
+	return true
+}
+
+func (eq *extractionQuery) getProperty(match *queryMatch, key string, defaultValue string) string {
+	if value, ok := eq.properties[match.patternIndex][key]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+func (eq *extractionQuery) getCaptureContent(match *queryMatch, capture string, code []byte) string {
+	if node, ok := match.captures[capture]; ok {
+		return node.Content(code)
+	}
+	return ""
+}
+
+func getNodeDepth(node *sitter.Node) int {
+	depth := 0
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		depth++
+	}
+	return depth
+}
@@ -0,0 +1,161 @@
+package functionextractor
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Number of files written in a single transaction. Committing every statement dominates the write time of repos
+// with many small files.
+const FILE_WRITE_BATCH_SIZE = 64
+
+type fileJob struct {
+	relativePath string
+	file         chan *extractedFile
+}
+
+// extractFiles extracts the files yielded by walk with the workers of the repo tree, and calls write with every
+// extracted file in the order they were yielded. Writes are never concurrent. The first write error stops the
+// extraction and is returned.
+func (rt *repoTree) extractFiles(ctx context.Context, walk func(fn func(relativePath string) error) error, write func(relativePath string, file *extractedFile) error) error {
+	nWorkers := rt.nWorkers
+	if nWorkers < 1 {
+		nWorkers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan *fileJob, nWorkers)
+	// Jobs in the order of the walk, bounds the number of files extracted ahead of the writes.
+	pending := make(chan *fileJob, 2*nWorkers)
+
+	wg := &sync.WaitGroup{}
+	for w := 0; w < nWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if ctx.Err() != nil {
+					job.file <- nil
+					continue
+				}
+				job.file <- rt.extractFile(job.relativePath)
+			}
+		}()
+	}
+
+	walkErr := make(chan error, 1)
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		walkErr <- walk(func(relativePath string) error {
+			// Jobs are handed to the workers before they are pending, a pending job is always extracted or
+			// canceled by a worker, so waiting for its file cannot block.
+			job := &fileJob{relativePath, make(chan *extractedFile, 1)}
+			for _, queue := range []chan *fileJob{jobs, pending} {
+				select {
+				case queue <- job:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+	}()
+
+	var err error
+	for job := range pending {
+		if err != nil {
+			// Drain the pending jobs to let the walk stop.
+			continue
+		}
+		file := <-job.file
+		if file == nil {
+			// The extraction was canceled.
+			err = ctx.Err()
+			continue
+		}
+		err = write(job.relativePath, file)
+		if err != nil {
+			cancel()
+		}
+	}
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+	return <-walkErr
+}
+
+// fileBatchWriter writes the files of a repo tree in transactions of up to FILE_WRITE_BATCH_SIZE files.
+type fileBatchWriter struct {
+	conn  *pgx.Conn
+	tx    pgx.Tx
+	files int
+}
+
+func newFileBatchWriter(conn *pgx.Conn) *fileBatchWriter {
+	return &fileBatchWriter{conn: conn}
+}
+
+// writeFile runs write in the current transaction, and commits the transaction once it holds FILE_WRITE_BATCH_SIZE
+// files. The changes of a failed write are rolled back without affecting the other files of the transaction, and
+// its error is returned.
+func (w *fileBatchWriter) writeFile(ctx context.Context, write func(conn *pgx.Conn) error) error {
+	if w.tx == nil {
+		tx, err := w.conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		w.tx = tx
+	}
+
+	savepoint, err := w.tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = write(w.conn)
+	if err != nil {
+		if rollbackErr := savepoint.Rollback(ctx); rollbackErr != nil {
+			return rollbackErr
+		}
+		return err
+	}
+
+	err = savepoint.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	w.files++
+	if w.files >= FILE_WRITE_BATCH_SIZE {
+		return w.commit(ctx)
+	}
+	return nil
+}
+
+// commit commits the files written since the last commit.
+func (w *fileBatchWriter) commit(ctx context.Context) error {
+	if w.tx == nil {
+		return nil
+	}
+	tx := w.tx
+	w.tx, w.files = nil, 0
+	return tx.Commit(ctx)
+}
+
+// rollback rolls back the files written since the last commit. It is a no-op once the writer is committed, so it can
+// be deferred.
+func (w *fileBatchWriter) rollback(ctx context.Context) {
+	if w.tx == nil {
+		return
+	}
+	tx := w.tx
+	w.tx, w.files = nil, 0
+	tx.Rollback(ctx)
+}
@@ -0,0 +1,124 @@
+package functionextractor
+
+import (
+	"codesearch-ai-data/internal/extractionpolicy"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeSyntheticMonorepo copies the test files into nPackages directories of a temporary repo tree.
+func writeSyntheticMonorepo(tb testing.TB, nPackages int) string {
+	testFiles, err := filepath.Glob("../testdata/test*.*")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	repoPath := tb.TempDir()
+	for i := 0; i < nPackages; i++ {
+		packagePath := filepath.Join(repoPath, fmt.Sprintf("pkg%d", i))
+		err := os.Mkdir(packagePath, 0755)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		for _, testFile := range testFiles {
+			code, err := ioutil.ReadFile(testFile)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			err = ioutil.WriteFile(filepath.Join(packagePath, filepath.Base(testFile)), code, 0644)
+			if err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+	return repoPath
+}
+
+func extractSyntheticMonorepo(tb testing.TB, repoPath string, nWorkers int) ([]string, []*extractedFile) {
+	tree := newRepoTree("synthetic-monorepo", repoPath, nWorkers, extractionpolicy.Default())
+	walk := func(fn func(relativePath string) error) error { return walkRepoFiles(repoPath, fn) }
+
+	paths, files := []string{}, []*extractedFile{}
+	err := tree.extractFiles(context.Background(), walk, func(relativePath string, file *extractedFile) error {
+		paths = append(paths, relativePath)
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return paths, files
+}
+
+func TestConcurrentFileExtraction(t *testing.T) {
+	repoPath := writeSyntheticMonorepo(t, 4)
+
+	serialPaths, serialFiles := extractSyntheticMonorepo(t, repoPath, 1)
+	concurrentPaths, concurrentFiles := extractSyntheticMonorepo(t, repoPath, 8)
+
+	if !reflect.DeepEqual(serialPaths, concurrentPaths) {
+		t.Fatalf("expected files in walk order %v, got %v", serialPaths, concurrentPaths)
+	}
+	for i := range serialFiles {
+		if !reflect.DeepEqual(serialFiles[i], concurrentFiles[i]) {
+			t.Fatalf("concurrent extraction of %s differs from serial extraction", serialPaths[i])
+		}
+	}
+}
+
+func TestFileExtractionStopsOnWriteError(t *testing.T) {
+	repoPath := writeSyntheticMonorepo(t, 4)
+	tree := newRepoTree("synthetic-monorepo", repoPath, 4, extractionpolicy.Default())
+	walk := func(fn func(relativePath string) error) error { return walkRepoFiles(repoPath, fn) }
+
+	writeErr := fmt.Errorf("write error")
+	writes := 0
+	err := tree.extractFiles(context.Background(), walk, func(relativePath string, file *extractedFile) error {
+		writes++
+		return writeErr
+	})
+	if err != writeErr {
+		t.Fatalf("expected write error, got %v", err)
+	}
+	if writes != 1 {
+		t.Fatalf("expected extraction to stop after the first write, got %d writes", writes)
+	}
+}
+
+func TestFileExtractionStopsOnCancel(t *testing.T) {
+	repoPath := writeSyntheticMonorepo(t, 4)
+	tree := newRepoTree("synthetic-monorepo", repoPath, 4, extractionpolicy.Default())
+	walk := func(fn func(relativePath string) error) error { return walkRepoFiles(repoPath, fn) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := tree.extractFiles(ctx, walk, func(relativePath string, file *extractedFile) error {
+		if file == nil {
+			t.Fatalf("expected canceled files not to be written, got nil file for %s", relativePath)
+		}
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, got %v", err)
+	}
+}
+
+func BenchmarkFileExtraction(b *testing.B) {
+	repoPath := writeSyntheticMonorepo(b, 32)
+
+	for _, nWorkers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", nWorkers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				extractSyntheticMonorepo(b, repoPath, nWorkers)
+			}
+		})
+	}
+}
@@ -1,11 +1,18 @@
 package functionextractor
 
 import (
+	"codesearch-ai-data/internal/coderoles"
+	"codesearch-ai-data/internal/docstrings"
+	"codesearch-ai-data/internal/extractionpolicy"
+	"codesearch-ai-data/internal/filefilter"
 	"codesearch-ai-data/internal/githelpers"
+	"codesearch-ai-data/internal/languages"
+	"codesearch-ai-data/internal/licenses"
 	ph "codesearch-ai-data/internal/parsinghelpers"
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
@@ -19,28 +26,39 @@ import (
 	sitter "github.com/smacker/go-tree-sitter"
 )
 
-const MAX_FILE_BYTE_SIZE = 1000000 // 1MB
-const DEFAULT_MIN_FUNCTION_LINES = 4
-
-// TODO: Clean up things like:
-// {@link TaskExecutorCustomizer TaskExecutorCustomizers} {@link ThreadPoolTaskExecutor} `something`
-
 type functionExtractor struct {
-	parser   *sitter.Parser
-	minLines int
+	parsers  *parserPool
+	rules    *extractionpolicy.Rules
+	language *languages.Language
 }
 
 type ExtractedFunction struct {
-	ID             int
-	Identifier     string
-	Code           string
-	CleanCode      string
-	CleanCodeHash  string
-	InlineComments string
-	Docstring      string
-	StartLine      int
-	EndLine        int
-	IsTrain        bool
+	ID                  int    `json:"-"`
+	Identifier          string `json:"identifier"`
+	QualifiedIdentifier string `json:"qualifiedIdentifier"`
+	Code                string `json:"code"`
+	CleanCode           string `json:"cleanCode"`
+	CleanCodeHash       string `json:"cleanCodeHash"`
+	InlineComments      string `json:"inlineComments"`
+	Docstring           string `json:"docstring"`
+	// DocstringSummary is the first sentence of the docstring, with inline tags and markup resolved.
+	DocstringSummary  string              `json:"docstringSummary"`
+	DocstringSections docstrings.Sections `json:"docstringSections"`
+	StartLine         int                 `json:"startLine"`
+	EndLine           int                 `json:"endLine"`
+	Signature         Signature           `json:"signature"`
+	IsTrain           bool                `json:"-"`
+	// Role is set from the path of the file and the test framework cues of the code once the file is extracted.
+	Role    coderoles.Role `json:"role"`
+	Metrics Metrics        `json:"metrics"`
+	// Calls are the distinct calls of the function, in order of appearance. Calls in nested functions that are
+	// extracted belong to the nested functions.
+	Calls []Call `json:"calls"`
+	// NearDuplicateClusterID is the ID of the representative function of its near-duplicate cluster, once the
+	// functions have been clustered.
+	NearDuplicateClusterID *int `json:"-"`
+
+	codeRole codeRole
 }
 
 func getSHA1Hash(text string) string {
@@ -49,36 +67,49 @@ func getSHA1Hash(text string) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
-func NewExtractedFunction(identifier string, cleanCode string, inlineComments []string, docstring string, node *sitter.Node, code []byte) *ExtractedFunction {
+func NewExtractedFunction(identifier string, cleanCode string, inlineComments []string, docstring *docstrings.Docstring, node *sitter.Node, code []byte) *ExtractedFunction {
 	return &ExtractedFunction{
-		Identifier:     identifier,
-		Code:           node.Content(code),
-		CleanCode:      cleanCode,
-		CleanCodeHash:  getSHA1Hash(cleanCode),
-		InlineComments: strings.Join(inlineComments, " "),
-		Docstring:      ph.GetPrecedingFunctionDocstring(node, code),
-		StartLine:      int(node.StartPoint().Row),
-		EndLine:        int(node.EndPoint().Row),
+		Identifier:        identifier,
+		Code:              node.Content(code),
+		CleanCode:         cleanCode,
+		CleanCodeHash:     getSHA1Hash(cleanCode),
+		InlineComments:    strings.Join(inlineComments, " "),
+		Docstring:         docstring.Text,
+		DocstringSummary:  docstring.Summary,
+		DocstringSections: docstring.Sections,
+		StartLine:         int(node.StartPoint().Row),
+		EndLine:           int(node.EndPoint().Row),
+		Role:              coderoles.Production,
 	}
 }
 
-func isFunctionRightSize(codeText string, minLines int) bool {
+func isRightSize(codeText string, minLines int, maxLines int) bool {
 	lines := strings.Split(codeText, "\n")
-	return len(lines) >= minLines && len(lines) <= 512
+	return len(lines) >= minLines && len(lines) <= maxLines
 }
 
-func hasFileTooManyColumns(fileText string) bool {
-	lines := strings.Split(fileText, "\n")
-	for _, line := range lines {
-		if len(line) > 1024 {
-			return true
-		}
+type FunctionExtractor interface {
+	Extract(code []byte) ([]*ExtractedFunction, error)
+	// ExtractFromTree extracts the functions of the code from its parsed tree, so the tree can be shared with the
+	// type extractor.
+	ExtractFromTree(tree *sitter.Tree, code []byte) ([]*ExtractedFunction, error)
+}
+
+// ParseError is returned by extractors along with the entities they could extract when the code does not parse
+// completely. Entities containing syntax errors are skipped.
+type ParseError struct {
+	ph.ParseErrors
+}
+
+func newParseError(rootNode *sitter.Node) error {
+	if !rootNode.HasError() {
+		return nil
 	}
-	return false
+	return &ParseError{ph.CountParseErrors(rootNode)}
 }
 
-type FunctionExtractor interface {
-	Extract(code []byte) ([]*ExtractedFunction, error)
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("encountered %d syntax errors and %d missing nodes while parsing", e.ErrorNodes, e.MissingNodes)
 }
 
 func contains(s []string, e string) bool {
@@ -98,67 +129,257 @@ func repoURLExists(repoURL string) bool {
 	return resp.StatusCode == 200
 }
 
-func getFunctionExtractorForFile(filePath string) FunctionExtractor {
-	fileExtension := strings.TrimPrefix(filepath.Ext(filePath), ".")
+func getFunctionExtractorForFile(filePath string, policy *extractionpolicy.Policy) FunctionExtractor {
+	language := languages.ForFile(filePath)
+	if language == nil {
+		return nil
+	}
 
-	switch fileExtension {
-	case "rb":
-		return NewRubyFunctionExtractor(DEFAULT_MIN_FUNCTION_LINES)
-	case "py":
-		return NewPythonFunctionExtractor(DEFAULT_MIN_FUNCTION_LINES)
-	case "php":
-		return NewPhpFunctionExtractor(DEFAULT_MIN_FUNCTION_LINES)
-	case "java":
-		return NewJavaFunctionExtractor(DEFAULT_MIN_FUNCTION_LINES)
-	case "js":
-		return NewJavascriptFunctionExtractor(DEFAULT_MIN_FUNCTION_LINES)
-	case "go":
-		return NewGoFunctionExtractor(DEFAULT_MIN_FUNCTION_LINES)
+	functionExtractor, err := getCachedFunctionExtractor(language, languages.FileExtension(filePath), policy)
+	if err != nil {
+		log.Debugf("No function extractor for %s: %s", filePath, err)
+		return nil
 	}
+	return functionExtractor
+}
 
-	return nil
+// qualifyWithFileScope prefixes the qualified identifiers with the scope implied by the file path (e.g. Python modules).
+func qualifyWithFileScope(filePath string, extractedFunctions []*ExtractedFunction) {
+	language := languages.ForFile(filePath)
+	if language == nil {
+		return
+	}
+	fileScope := language.GetFileScope(filePath)
+	if fileScope == "" {
+		return
+	}
+	for _, extractedFunction := range extractedFunctions {
+		if extractedFunction.QualifiedIdentifier != "" {
+			extractedFunction.QualifiedIdentifier = fileScope + "." + extractedFunction.QualifiedIdentifier
+		}
+	}
 }
 
-func ProcessRepo(ctx context.Context, conn *pgx.Conn, repoName string) error {
+// cloneRepo clones the latest commit of a remote repo into a temporary directory. The returned function removes
+// the clone.
+func cloneRepo(repoName string) (string, func(), error) {
 	repoURL := fmt.Sprintf("https://%s", repoName)
 
 	if !repoURLExists(repoURL) {
-		return fmt.Errorf("repo URL %s does not exist", repoURL)
+		return "", nil, fmt.Errorf("repo URL %s does not exist", repoURL)
+	}
+
+	repoPath, err := ioutil.TempDir("", "cloned-repo")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(repoPath) }
+
+	err = githelpers.CloneRepoWithTimeout(repoURL, repoPath, 300)
+	if err != nil {
+		log.Debugf("Error cloning repo %s: %s", repoName, err)
+		cleanup()
+		return "", nil, err
 	}
+	return repoPath, cleanup, nil
+}
 
-	exists, err := repoExists(ctx, conn, repoName)
+// ProcessRepo extracts functions from the latest commit of a remote repo. Repos that were already extracted
+// are updated to the latest commit if update is set, and rejected otherwise.
+func ProcessRepo(ctx context.Context, conn *pgx.Conn, repoName string, update bool, nFileWorkers int, policy *extractionpolicy.Policy) error {
+	repoID, previousCommitID, exists, err := getTrackedRepo(ctx, conn, repoName)
 	if err != nil {
 		return err
 	}
 
-	if exists {
-		return fmt.Errorf("repo %s already exists", repoURL)
+	if exists && previousCommitID != "" && !update {
+		return fmt.Errorf("repo %s already exists", repoName)
 	}
 
-	repoPath, err := ioutil.TempDir("", "cloned-repo")
+	if !exists {
+		return ExtractRepo(ctx, newPostgresSink(conn, false), repoName, nFileWorkers, policy)
+	}
+
+	repoPath, cleanup, err := cloneRepo(repoName)
 	if err != nil {
 		return err
 	}
+	defer cleanup()
 
-	err = githelpers.CloneRepoWithTimeout(repoURL, repoPath, 300)
+	commitID, err := githelpers.GetRepoCommitID(repoPath)
+	if err != nil {
+		return err
+	}
+
+	upToDate, err := isRepoUpToDate(ctx, conn, repoID, previousCommitID, commitID, policy)
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		log.Debugf("Repo %s is up to date at %s", repoName, commitID)
+		return nil
+	}
+
+	if previousCommitID != "" {
+		// The clone is shallow, fetch the previous commit to diff against it.
+		err = githelpers.FetchRefWithTimeout(repoPath, previousCommitID, 300)
+		if err != nil {
+			log.Debugf("Error fetching previous commit %s of repo %s: %s", previousCommitID, repoName, err)
+		}
+	}
+
+	return updateRepoFunctions(ctx, conn, repoName, repoID, previousCommitID, commitID, repoPath, repoPath, nFileWorkers, policy)
+}
+
+// ExtractRepo extracts functions from the latest commit of a remote repo, and writes them to the sink as the
+// tracked snapshot of the repo.
+func ExtractRepo(ctx context.Context, sink Sink, repoName string, nFileWorkers int, policy *extractionpolicy.Policy) error {
+	repoPath, cleanup, err := cloneRepo(repoName)
 	if err != nil {
-		log.Debugf("Error cloning repo %s: %s", repoName, err)
 		return err
 	}
-	// Clean up cloned repo.
-	defer func() { os.RemoveAll(repoPath) }()
+	defer cleanup()
 
 	commitID, err := githelpers.GetRepoCommitID(repoPath)
 	if err != nil {
 		return err
 	}
 
-	repoID, err := insertRepo(ctx, conn, repoName, commitID)
+	return extractRepoFunctions(ctx, sink, repoName, commitID, repoPath, nFileWorkers, policy)
+}
+
+// ProcessLocalRepo extracts functions from a repo that is already on disk, without any network access.
+// The repo is exported at the commit of ref (defaults to HEAD) into a temporary directory first. Repos that
+// were already extracted are updated to the commit if update is set, and rejected otherwise.
+func ProcessLocalRepo(ctx context.Context, conn *pgx.Conn, repoName string, repoPath string, ref string, update bool, nFileWorkers int, policy *extractionpolicy.Policy) error {
+	repoID, previousCommitID, exists, err := getTrackedRepo(ctx, conn, repoName)
+	if err != nil {
+		return err
+	}
+
+	if exists && previousCommitID != "" && !update {
+		return fmt.Errorf("repo %s already exists", repoName)
+	}
+
+	if !exists {
+		return ExtractLocalRepo(ctx, newPostgresSink(conn, false), repoName, repoPath, ref, nFileWorkers, policy)
+	}
+
+	repoPath, err = filepath.Abs(repoPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := githelpers.IsBareRepo(repoPath); err != nil {
+		return fmt.Errorf("%s is not a git repo: %w", repoPath, err)
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	commitID, err := githelpers.ResolveRepoRef(repoPath, ref)
+	if err != nil {
+		return err
+	}
+
+	upToDate, err := isRepoUpToDate(ctx, conn, repoID, previousCommitID, commitID, policy)
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		log.Debugf("Repo %s is up to date at %s", repoName, commitID)
+		return nil
+	}
+
+	exportPath, err := exportRepoTree(repoName, repoPath, commitID)
+	if err != nil {
+		return err
+	}
+	// Clean up exported repo.
+	defer func() { os.RemoveAll(exportPath) }()
+
+	return updateRepoFunctions(ctx, conn, repoName, repoID, previousCommitID, commitID, repoPath, exportPath, nFileWorkers, policy)
+}
+
+// ExtractLocalRepo extracts functions from a repo that is already on disk, and writes them to the sink as the
+// tracked snapshot of the repo. The repo is exported at the commit of ref (defaults to HEAD) into a temporary
+// directory first, so untracked, ignored and uncommitted files of checkouts are not extracted with the commit.
+func ExtractLocalRepo(ctx context.Context, sink Sink, repoName string, repoPath string, ref string, nFileWorkers int, policy *extractionpolicy.Policy) error {
+	repoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := githelpers.IsBareRepo(repoPath); err != nil {
+		return fmt.Errorf("%s is not a git repo: %w", repoPath, err)
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	commitID, err := githelpers.ResolveRepoRef(repoPath, ref)
+	if err != nil {
+		return err
+	}
+
+	exportPath, err := exportRepoTree(repoName, repoPath, commitID)
 	if err != nil {
 		return err
 	}
+	// Clean up exported repo.
+	defer func() { os.RemoveAll(exportPath) }()
+
+	return extractRepoFunctions(ctx, sink, repoName, commitID, exportPath, nFileWorkers, policy)
+}
+
+// exportRepoTree exports the tree of the repo at the commit into a temporary directory.
+func exportRepoTree(repoName string, gitPath string, commitID string) (string, error) {
+	exportPath, err := ioutil.TempDir("", "exported-repo")
+	if err != nil {
+		return "", err
+	}
+
+	err = githelpers.ExportRepoTree(gitPath, commitID, exportPath)
+	if err != nil {
+		log.Debugf("Error exporting repo %s at %s: %s", repoName, commitID, err)
+		os.RemoveAll(exportPath)
+		return "", err
+	}
+	return exportPath, nil
+}
+
+// getTrackedRepo returns the ID and the tracked commit ID of an extracted repo. The commit ID is empty for repos
+// with ref snapshots only.
+func getTrackedRepo(ctx context.Context, conn *pgx.Conn, repoName string) (int, string, bool, error) {
+	repoID, commitID, err := getRepo(ctx, conn, repoName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return -1, "", false, nil
+	} else if err != nil {
+		return -1, "", false, err
+	}
+	return repoID, commitID, true, nil
+}
+
+// isRepoUpToDate reports whether the tracked snapshot of the repo was extracted at the commit with the policy.
+func isRepoUpToDate(ctx context.Context, conn *pgx.Conn, repoID int, previousCommitID string, commitID string, policy *extractionpolicy.Policy) (bool, error) {
+	if previousCommitID != commitID {
+		return false, nil
+	}
+	policyHash, err := getRepoSnapshotPolicyHash(ctx, conn, repoID, TRACKED_SNAPSHOT_REF)
+	if err != nil {
+		return false, err
+	}
+	return policyHash == policy.Hash(), nil
+}
 
-	err = filepath.Walk(repoPath, func(path string, info fs.FileInfo, err error) error {
+// walkRepoFiles calls fn with the path of every file in the repo tree, relative to the repo root.
+func walkRepoFiles(repoPath string, fn func(relativePath string) error) error {
+	return filepath.Walk(repoPath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
 		if info.IsDir() {
 			// Skip .git directory
 			if info.Name() == ".git" {
@@ -166,43 +387,318 @@ func ProcessRepo(ctx context.Context, conn *pgx.Conn, repoName string) error {
 			}
 			return nil
 		}
+		return fn(strings.TrimPrefix(strings.TrimPrefix(path, repoPath), "/"))
+	})
+}
 
-		if strings.Contains(path, ".pb.") || strings.HasSuffix(path, ".min.js") || info.Size() > MAX_FILE_BYTE_SIZE {
-			return nil
-		}
+// repoTree is an exported or cloned repo tree being extracted.
+type repoTree struct {
+	repoName string
+	path     string
+	filter   *filefilter.Filter
+	// license is the SPDX license expression detected from the license files of the tree.
+	license string
+	// nWorkers is the number of files extracted concurrently.
+	nWorkers int
+	policy   *extractionpolicy.Policy
+}
 
-		relativePath := strings.TrimPrefix(strings.TrimPrefix(path, repoPath), "/")
-		functionExtractor := getFunctionExtractorForFile(relativePath)
-		if functionExtractor == nil {
-			return nil
+func newRepoTree(repoName string, path string, nWorkers int, policy *extractionpolicy.Policy) *repoTree {
+	return &repoTree{repoName, path, filefilter.New(path, policy), licenses.DetectRepoLicense(path), nWorkers, policy}
+}
+
+type extractedFile struct {
+	functions []*ExtractedFunction
+	types     []*ExtractedType
+	// license is the SPDX license expression of the file header, if any.
+	license   string
+	exclusion *filefilter.Exclusion
+	language  string
+	// parseErrors is set for parsed files, entities with syntax errors are not extracted.
+	parseErrors *ph.ParseErrors
+}
+
+// extractFile extracts the functions and types of a file in the repo tree. Both are nil for skipped files, along
+// with the exclusion for excluded files. Only files in a supported language are excluded.
+func (rt *repoTree) extractFile(relativePath string) *extractedFile {
+	functionExtractor := getFunctionExtractorForFile(relativePath, rt.policy)
+	if functionExtractor == nil {
+		return &extractedFile{}
+	}
+	typeExtractor := getTypeExtractorForFile(relativePath, rt.policy)
+
+	path := filepath.Join(rt.path, relativePath)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return &extractedFile{}
+	}
+
+	if exclusion := rt.filter.ExcludePath(relativePath, info.Size()); exclusion != nil {
+		return &extractedFile{exclusion: exclusion}
+	}
+
+	code, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Debugf("Error reading file %s/%s: %s", rt.repoName, relativePath, err)
+		// In case of a read error, skip file.
+		return &extractedFile{}
+	}
+
+	if exclusion := rt.filter.ExcludeCode(relativePath, code); exclusion != nil {
+		return &extractedFile{exclusion: exclusion}
+	}
+
+	// The file is parsed once for both extractors.
+	tree := getParserPool(languages.ForFile(relativePath), languages.FileExtension(relativePath)).parse(code)
+	defer tree.Close()
+
+	parseErrors := &ph.ParseErrors{}
+	extractedFunctions, err := functionExtractor.ExtractFromTree(tree, code)
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		// Keep the functions that parsed without errors.
+		parseErrors = &parseErr.ParseErrors
+	} else if err != nil {
+		log.Debugf("Error extracting functions %s/%s: %s", rt.repoName, relativePath, err)
+		return &extractedFile{}
+	}
+
+	qualifyWithFileScope(relativePath, extractedFunctions)
+	assignFunctionRoles(relativePath, extractedFunctions)
+	file := &extractedFile{
+		functions:   extractedFunctions,
+		license:     licenses.DetectFileLicense(code),
+		language:    languages.ForFile(relativePath).Name,
+		parseErrors: parseErrors,
+	}
+
+	if typeExtractor == nil {
+		return file
+	}
+
+	file.types, err = typeExtractor.ExtractFromTree(tree, code)
+	if err != nil && !errors.As(err, &parseErr) {
+		log.Debugf("Error extracting types %s/%s: %s", rt.repoName, relativePath, err)
+	}
+	return file
+}
+
+// insertFile inserts the functions and types of an extracted file into the snapshot, or records why the file is
+// excluded. Functions and types that were already extracted are left untouched when onlyMissing is set.
+func insertFile(ctx context.Context, conn *pgx.Conn, repoID int, snapshotID int, relativePath string, file *extractedFile, onlyMissing bool) error {
+	if file.exclusion != nil {
+		return insertExcludedFile(ctx, conn, snapshotID, relativePath, file.exclusion)
+	}
+
+	if file.functions == nil {
+		return nil
+	}
+
+	err := insertParsedFile(ctx, conn, snapshotID, relativePath, file.language, file.parseErrors)
+	if err != nil {
+		return err
+	}
+
+	insertFunctions, insertTypes := insertExtractedFunctionsFromFile, insertExtractedTypesFromFile
+	if onlyMissing {
+		insertFunctions, insertTypes = insertMissingExtractedFunctionsFromFile, insertMissingExtractedTypesFromFile
+	}
+
+	err = insertFunctions(ctx, conn, repoID, relativePath, file.functions)
+	if err != nil {
+		return err
+	}
+	err = insertExtractedFunctionSnapshots(ctx, conn, snapshotID, relativePath, file.license, file.functions)
+	if err != nil {
+		return err
+	}
+	err = insertExtractedFunctionCalls(ctx, conn, repoID, relativePath, file.functions)
+	if err != nil {
+		return err
+	}
+
+	if file.types == nil {
+		return nil
+	}
+
+	err = insertTypes(ctx, conn, repoID, relativePath, file.types)
+	if err != nil {
+		return err
+	}
+	return insertExtractedTypeSnapshots(ctx, conn, snapshotID, relativePath, file.license, file.types)
+}
+
+func extractRepoFunctions(ctx context.Context, sink Sink, repoName string, commitID string, repoPath string, nFileWorkers int, policy *extractionpolicy.Policy) error {
+	tree := newRepoTree(repoName, repoPath, nFileWorkers, policy)
+	return tree.extractSnapshot(ctx, sink, TRACKED_SNAPSHOT_REF, commitID)
+}
+
+// getRepoTreeChanges returns the changes to refresh every file of the repo tree, when the previous commit cannot be
+// diffed against. Every file in the tree is modified, and every file that is only in the database is deleted.
+func getRepoTreeChanges(ctx context.Context, conn *pgx.Conn, repoID int, repoPath string) ([]githelpers.FileChange, error) {
+	changes := []githelpers.FileChange{}
+	treePaths := map[string]bool{}
+	err := walkRepoFiles(repoPath, func(relativePath string) error {
+		treePaths[relativePath] = true
+		changes = append(changes, githelpers.FileChange{Status: githelpers.FileModified, Path: relativePath})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	extractedPaths, err := getRepoFilePaths(ctx, conn, repoID)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range extractedPaths {
+		if !treePaths[path] {
+			changes = append(changes, githelpers.FileChange{Status: githelpers.FileDeleted, Path: path})
 		}
+	}
+	return changes, nil
+}
+
+// updateRepoFunctions re-extracts the files changed since the previously extracted commit into the tracked snapshot.
+// The functions and types of unchanged files, and the unchanged functions and types of changed files, keep their IDs.
+// The repo commit ID is only updated once every change has been applied, so a failed update can be retried. Every file
+// is re-extracted if the previous commit was extracted with another policy.
+func updateRepoFunctions(ctx context.Context, conn *pgx.Conn, repoName string, repoID int, previousCommitID string, commitID string, gitPath string, repoPath string, nFileWorkers int, policy *extractionpolicy.Policy) error {
+	previousPolicyHash, err := getRepoSnapshotPolicyHash(ctx, conn, repoID, TRACKED_SNAPSHOT_REF)
+	if err != nil {
+		return err
+	}
+
+	tree := newRepoTree(repoName, repoPath, nFileWorkers, policy)
+	snapshotID, err := upsertRepoSnapshot(ctx, conn, repoID, TRACKED_SNAPSHOT_REF, previousCommitID, tree.license, previousPolicyHash)
+	if err != nil {
+		return err
+	}
 
-		code, err := ioutil.ReadFile(path)
+	var changes []githelpers.FileChange
+	if previousPolicyHash != policy.Hash() {
+		log.Debugf("Repo %s was extracted with another policy, refreshing all files", repoName)
+	} else if previousCommitID != "" {
+		changes, err = githelpers.GetChangedFiles(gitPath, previousCommitID, commitID)
 		if err != nil {
-			log.Debugf("Error reading file %s/%s: %s", repoName, relativePath, err)
-			// In case of a read error, skip file.
-			return nil
+			// The previous commit is not available anymore (e.g. after a force push), refresh the whole tree instead.
+			log.Debugf("Error diffing repo %s, refreshing all files: %s", repoName, err)
+		}
+	}
+	if changes == nil {
+		changes, err = getRepoTreeChanges(ctx, conn, repoID, repoPath)
+		if err != nil {
+			return err
 		}
+	}
 
-		if hasFileTooManyColumns(string(code)) {
-			return nil
+	writer := newFileBatchWriter(conn)
+	defer writer.rollback(ctx)
+
+	// Deleted files are removed first, the other changes are extracted concurrently and looked up by path once their
+	// file is extracted.
+	extractedChanges := []githelpers.FileChange{}
+	changesByPath := map[string]githelpers.FileChange{}
+	for _, change := range changes {
+		if change.Status != githelpers.FileDeleted {
+			extractedChanges = append(extractedChanges, change)
+			changesByPath[change.Path] = change
+			continue
 		}
 
-		extractedFunctions, err := functionExtractor.Extract(code)
+		err = writer.writeFile(ctx, func(conn *pgx.Conn) error {
+			return deleteExtractedFromFile(ctx, conn, repoID, snapshotID, change.Path)
+		})
 		if err != nil {
-			log.Debugf("Error extracting functions %s/%s: %s", repoName, relativePath, err)
-			// In case of a parse error, skip file.
-			return nil
+			return fmt.Errorf("updating %s/%s: %w", repoName, change.Path, err)
 		}
+	}
 
-		err = insertExtractedFunctionsFromFile(ctx, conn, repoID, relativePath, extractedFunctions)
-		if err != nil {
-			log.Debugf("Error inserting functions %s/%s: %s", repoName, relativePath, err)
-			return nil
+	walkChanges := func(fn func(relativePath string) error) error {
+		for _, change := range extractedChanges {
+			if err := fn(change.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = tree.extractFiles(ctx, walkChanges, func(relativePath string, file *extractedFile) error {
+		change, ok := changesByPath[relativePath]
+		if !ok {
+			return fmt.Errorf("updating %s/%s: file is not a change of the repo", repoName, relativePath)
 		}
 
+		err := writer.writeFile(ctx, func(conn *pgx.Conn) error {
+			if change.Status == githelpers.FileRenamed {
+				err := renameExtractedFile(ctx, conn, repoID, snapshotID, change.OldPath, change.Path)
+				if err != nil {
+					return err
+				}
+			}
+			return tree.replaceFile(ctx, conn, repoID, snapshotID, change.Path, file)
+		})
+		if err != nil {
+			return fmt.Errorf("updating %s/%s: %w", repoName, change.Path, err)
+		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	err = writer.commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = resolveRepoFunctionCalls(ctx, conn, repoID)
+	if err != nil {
+		return err
+	}
+
+	err = insertExtractionPolicy(ctx, conn, policy)
+	if err != nil {
+		return err
+	}
+
+	_, err = upsertRepoSnapshot(ctx, conn, repoID, TRACKED_SNAPSHOT_REF, commitID, tree.license, policy.Hash())
+	if err != nil {
+		return err
+	}
+
+	err = updateRepoCommitID(ctx, conn, repoID, commitID)
+	if err != nil {
+		return err
+	}
+	return releaseRepoJobRepo(ctx, conn, repoID)
+}
+
+// replaceFile replaces the functions and types extracted from the file with the ones of its new version.
+func (rt *repoTree) replaceFile(ctx context.Context, conn *pgx.Conn, repoID int, snapshotID int, relativePath string, file *extractedFile) error {
+	err := deleteFileRecords(ctx, conn, snapshotID, relativePath)
+	if err != nil {
+		return err
+	}
+
+	if file.exclusion != nil {
+		err = insertExcludedFile(ctx, conn, snapshotID, relativePath, file.exclusion)
+		if err != nil {
+			return err
+		}
+	}
+
+	if file.parseErrors != nil {
+		err = insertParsedFile(ctx, conn, snapshotID, relativePath, file.language, file.parseErrors)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = replaceExtractedFunctionsFromFile(ctx, conn, repoID, snapshotID, relativePath, file.license, file.functions)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return replaceExtractedTypesFromFile(ctx, conn, repoID, snapshotID, relativePath, file.license, file.types)
 }
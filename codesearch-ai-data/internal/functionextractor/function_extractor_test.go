@@ -1,48 +1,87 @@
 package functionextractor
 
 import (
+	"codesearch-ai-data/internal/extractionpolicy"
+	"codesearch-ai-data/internal/githelpers"
+	"codesearch-ai-data/internal/languages"
+	ph "codesearch-ai-data/internal/parsinghelpers"
+	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"sort"
 	"testing"
 
 	"github.com/hexops/autogold"
 )
 
+// testRules returns the default rules of the language, without a minimum number of lines.
+func testRules(languageName string) *extractionpolicy.Rules {
+	rules := *extractionpolicy.Default().ForLanguage(languageName)
+	rules.MinFunctionLines, rules.MinTypeLines = 0, 0
+	return &rules
+}
+
 func TestFunctionExtractors(t *testing.T) {
 	tests := []struct {
-		name      string
-		path      string
-		extractor FunctionExtractor
+		name string
+		path string
 	}{
 		{
-			name:      "RubyFunctionExtractor",
-			path:      "../testdata/test.rb",
-			extractor: NewRubyFunctionExtractor(0),
+			name: "RubyFunctionExtractor",
+			path: "../testdata/test.rb",
+		},
+		{
+			name: "GoFunctionExtractor",
+			path: "../testdata/test.go",
+		},
+		{
+			name: "PythonFunctionExtractor",
+			path: "../testdata/test.py",
+		},
+		{
+			name: "JavascriptFunctionExtractor",
+			path: "../testdata/test.js",
 		},
 		{
-			name:      "GoFunctionExtractor",
-			path:      "../testdata/test.go",
-			extractor: NewGoFunctionExtractor(0),
+			name: "JavaFunctionExtractor",
+			path: "../testdata/test.java",
 		},
 		{
-			name:      "PythonFunctionExtractor",
-			path:      "../testdata/test.py",
-			extractor: NewPythonFunctionExtractor(0),
+			name: "PhpFunctionExtractor",
+			path: "../testdata/test.php",
 		},
 		{
-			name:      "JavascriptFunctionExtractor",
-			path:      "../testdata/test.js",
-			extractor: NewJavascriptFunctionExtractor(0),
+			name: "TypescriptFunctionExtractor",
+			path: "../testdata/test.ts",
 		},
 		{
-			name:      "JavaFunctionExtractor",
-			path:      "../testdata/test.java",
-			extractor: NewJavaFunctionExtractor(0),
+			name: "TsxFunctionExtractor",
+			path: "../testdata/test.tsx",
 		},
 		{
-			name:      "PhpFunctionExtractor",
-			path:      "../testdata/test.php",
-			extractor: NewPhpFunctionExtractor(0),
+			name: "RustFunctionExtractor",
+			path: "../testdata/test.rs",
+		},
+		{
+			name: "CFunctionExtractor",
+			path: "../testdata/test.c",
+		},
+		{
+			name: "CppFunctionExtractor",
+			path: "../testdata/test.cpp",
+		},
+		{
+			name: "CsharpFunctionExtractor",
+			path: "../testdata/test.cs",
+		},
+		{
+			name: "KotlinFunctionExtractor",
+			path: "../testdata/test.kt",
 		},
 	}
 
@@ -53,7 +92,13 @@ func TestFunctionExtractors(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			extractedFunctions, err := tt.extractor.Extract(testCode)
+			language := languages.ForFile(tt.path)
+			extractor, err := NewQueryFunctionExtractor(language.Name, languages.FileExtension(tt.path), testRules(language.Name))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractedFunctions, err := extractor.Extract(testCode)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -66,3 +111,424 @@ func TestFunctionExtractors(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisteredLanguagesHaveFunctionQueries(t *testing.T) {
+	for _, language := range languages.All() {
+		for _, extension := range language.Extensions {
+			if _, err := NewQueryFunctionExtractor(language.Name, extension, testRules(language.Name)); err != nil {
+				t.Errorf("invalid function query for %s files: %s", extension, err)
+			}
+		}
+	}
+}
+
+func TestTypeExtractors(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		language string
+	}{
+		{name: "JavaTypeExtractor", path: "../testdata/test_types.java", language: "java"},
+		{name: "CsharpTypeExtractor", path: "../testdata/test_types.cs", language: "csharp"},
+		{name: "GoTypeExtractor", path: "../testdata/test_types.go", language: "go"},
+		{name: "PythonTypeExtractor", path: "../testdata/test_types.py", language: "python"},
+		{name: "RubyTypeExtractor", path: "../testdata/test_types.rb", language: "ruby"},
+		{name: "JavascriptTypeExtractor", path: "../testdata/test_types.js", language: "javascript"},
+		{name: "KotlinTypeExtractor", path: "../testdata/test.kt", language: "kotlin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCode, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractor, err := NewQueryTypeExtractor(tt.language, languages.FileExtension(tt.path), testRules(tt.language))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractedTypes, err := extractor.Extract(testCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			autogold.Equal(t, extractedTypes)
+		})
+	}
+}
+
+func TestFunctionSignatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		language string
+	}{
+		{name: "GoSignatures", path: "../testdata/test_signatures.go", language: "go"},
+		{name: "JavaSignatures", path: "../testdata/test_signatures.java", language: "java"},
+		{name: "PythonSignatures", path: "../testdata/test_signatures.py", language: "python"},
+		{name: "JavascriptSignatures", path: "../testdata/test_signatures.js", language: "javascript"},
+		{name: "TypescriptSignatures", path: "../testdata/test_signatures.ts", language: "typescript"},
+		{name: "RustSignatures", path: "../testdata/test_signatures.rs", language: "rust"},
+		{name: "CSignatures", path: "../testdata/test_signatures.c", language: "c"},
+		{name: "CppSignatures", path: "../testdata/test_signatures.cpp", language: "cpp"},
+		{name: "CsharpSignatures", path: "../testdata/test_signatures.cs", language: "csharp"},
+		{name: "PhpSignatures", path: "../testdata/test_signatures.php", language: "php"},
+		{name: "RubySignatures", path: "../testdata/test_signatures.rb", language: "ruby"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCode, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractor, err := NewQueryFunctionExtractor(tt.language, languages.FileExtension(tt.path), testRules(tt.language))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractedFunctions, err := extractor.Extract(testCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signatures := make([]string, 0, len(extractedFunctions))
+			for _, ef := range extractedFunctions {
+				signatures = append(signatures, ef.Signature.Format(ef.Identifier))
+			}
+			sort.Strings(signatures)
+
+			autogold.Equal(t, signatures)
+		})
+	}
+}
+
+func TestQualifiedIdentifiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		language string
+	}{
+		{name: "GoQualifiedIdentifiers", path: "../testdata/test_scopes.go", language: "go"},
+		{name: "JavaQualifiedIdentifiers", path: "../testdata/test_scopes.java", language: "java"},
+		{name: "PythonQualifiedIdentifiers", path: "../testdata/test_scopes.py", language: "python"},
+		{name: "PhpQualifiedIdentifiers", path: "../testdata/test_scopes.php", language: "php"},
+		{name: "RubyQualifiedIdentifiers", path: "../testdata/test_scopes.rb", language: "ruby"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCode, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractor, err := NewQueryFunctionExtractor(tt.language, languages.FileExtension(tt.path), testRules(tt.language))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractedFunctions, err := extractor.Extract(testCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			qualifyWithFileScope(filepath.Base(tt.path), extractedFunctions)
+
+			qualifiedIdentifiers := make([]string, 0, len(extractedFunctions))
+			for _, ef := range extractedFunctions {
+				qualifiedIdentifiers = append(qualifiedIdentifiers, ef.Identifier+" "+ef.QualifiedIdentifier)
+			}
+			sort.Strings(qualifiedIdentifiers)
+
+			autogold.Equal(t, qualifiedIdentifiers)
+		})
+	}
+}
+
+func TestFunctionRoles(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		// filePath is the path of the file in its repo.
+		filePath string
+		language string
+	}{
+		{name: "GoTestFileRoles", path: "../testdata/test_roles.go", filePath: "parser/parser_test.go", language: "go"},
+		{name: "GoProductionFileRoles", path: "../testdata/test_roles.go", filePath: "parser/parser.go", language: "go"},
+		{name: "PythonTestFileRoles", path: "../testdata/test_roles.py", filePath: "tests/test_parser.py", language: "python"},
+		{name: "PythonProductionFileRoles", path: "../testdata/test_roles.py", filePath: "parser.py", language: "python"},
+		{name: "JavaRoles", path: "../testdata/test_roles.java", filePath: "src/main/java/Parser.java", language: "java"},
+		{name: "CsharpRoles", path: "../testdata/test_roles.cs", filePath: "Parser.cs", language: "csharp"},
+		{name: "RustRoles", path: "../testdata/test_roles.rs", filePath: "src/parser.rs", language: "rust"},
+		{name: "JavascriptRoles", path: "../testdata/test_roles.js", filePath: "src/parser.js", language: "javascript"},
+		{name: "RubyRoles", path: "../testdata/test_roles.rb", filePath: "lib/parser.rb", language: "ruby"},
+		{name: "PhpRoles", path: "../testdata/test_roles.php", filePath: "src/Parser.php", language: "php"},
+		{name: "CppRoles", path: "../testdata/test_roles.cpp", filePath: "src/parser.cpp", language: "cpp"},
+		{name: "ExampleFileRoles", path: "../testdata/test_roles.cpp", filePath: "examples/parser.cpp", language: "cpp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCode, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractor, err := NewQueryFunctionExtractor(tt.language, languages.FileExtension(tt.path), testRules(tt.language))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractedFunctions, err := extractor.Extract(testCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assignFunctionRoles(tt.filePath, extractedFunctions)
+
+			roles := make([]string, 0, len(extractedFunctions))
+			for _, ef := range extractedFunctions {
+				roles = append(roles, fmt.Sprintf("%d %s %s", ef.StartLine, ef.Identifier, ef.Role))
+			}
+
+			autogold.Equal(t, roles)
+		})
+	}
+}
+
+func TestFunctionMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		language string
+	}{
+		{name: "GoFunctionMetrics", path: "../testdata/test_metrics.go", language: "go"},
+		{name: "PythonFunctionMetrics", path: "../testdata/test_metrics.py", language: "python"},
+		{name: "JavaFunctionMetrics", path: "../testdata/test_metrics.java", language: "java"},
+		{name: "JavascriptFunctionMetrics", path: "../testdata/test_metrics.js", language: "javascript"},
+		{name: "RustFunctionMetrics", path: "../testdata/test_metrics.rs", language: "rust"},
+		{name: "RubyFunctionMetrics", path: "../testdata/test_metrics.rb", language: "ruby"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCode, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractor, err := NewQueryFunctionExtractor(tt.language, languages.FileExtension(tt.path), testRules(tt.language))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractedFunctions, err := extractor.Extract(testCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			metrics := map[string]Metrics{}
+			for _, ef := range extractedFunctions {
+				metrics[ef.Identifier] = ef.Metrics
+			}
+
+			autogold.Equal(t, metrics)
+		})
+	}
+}
+
+func TestFunctionCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		language string
+	}{
+		{name: "GoFunctionCalls", path: "../testdata/test_calls.go", language: "go"},
+		{name: "PythonFunctionCalls", path: "../testdata/test_calls.py", language: "python"},
+		{name: "JavaFunctionCalls", path: "../testdata/test_calls.java", language: "java"},
+		{name: "JavascriptFunctionCalls", path: "../testdata/test_calls.js", language: "javascript"},
+		{name: "CSharpFunctionCalls", path: "../testdata/test_calls.cs", language: "csharp"},
+		{name: "PHPFunctionCalls", path: "../testdata/test_calls.php", language: "php"},
+		{name: "RubyFunctionCalls", path: "../testdata/test_calls.rb", language: "ruby"},
+		{name: "RustFunctionCalls", path: "../testdata/test_calls.rs", language: "rust"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCode, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractor, err := NewQueryFunctionExtractor(tt.language, languages.FileExtension(tt.path), testRules(tt.language))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractedFunctions, err := extractor.Extract(testCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			calls := map[string][]Call{}
+			for _, ef := range extractedFunctions {
+				if len(ef.Calls) > 0 {
+					calls[ef.Identifier] = ef.Calls
+				}
+			}
+
+			autogold.Equal(t, calls)
+		})
+	}
+}
+
+func TestPartialExtraction(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		language string
+	}{
+		{name: "GoPartialExtraction", path: "../testdata/test_parse_errors.go", language: "go"},
+		{name: "PythonPartialExtraction", path: "../testdata/test_parse_errors.py", language: "python"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCode, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractor, err := NewQueryFunctionExtractor(tt.language, languages.FileExtension(tt.path), testRules(tt.language))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractedFunctions, err := extractor.Extract(testCode)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a parse error, got %v", err)
+			}
+
+			identifiers := make([]string, 0, len(extractedFunctions))
+			for _, ef := range extractedFunctions {
+				identifiers = append(identifiers, ef.Identifier)
+			}
+			sort.Strings(identifiers)
+
+			autogold.Equal(t, struct {
+				Identifiers []string
+				ParseErrors ph.ParseErrors
+			}{identifiers, parseErr.ParseErrors})
+		})
+	}
+}
+
+func TestPolicyRules(t *testing.T) {
+	testCode, err := ioutil.ReadFile("../testdata/test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := extractionpolicy.Parse([]byte(`{"languages": {"go": {"minFunctionLines": 0, "maxFunctionLines": 3, "excludeIdentifiers": ["a"]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extractor, err := NewQueryFunctionExtractor("go", "go", policy.ForLanguage("go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extractedFunctions, err := extractor.Extract(testCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identifiers := make([]string, 0, len(extractedFunctions))
+	for _, ef := range extractedFunctions {
+		identifiers = append(identifiers, ef.Identifier)
+	}
+	sort.Strings(identifiers)
+	autogold.Equal(t, identifiers)
+}
+
+func TestExtractLocalRepoAtHead(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile := func(relativePath string, code string) {
+		err := os.WriteFile(filepath.Join(repoPath, relativePath), []byte(code), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repoPath
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, output)
+		}
+	}
+
+	writeFile("committed.go", "package pkg\n\nfunc committed() int {\n\treturn 1\n}\n")
+	writeFile(".gitignore", "ignored.go\n")
+	runGit("init", "-q")
+	runGit("add", "-A")
+	runGit("commit", "-q", "-m", "Initial commit")
+	commitID, err := githelpers.GetRepoCommitID(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the files of the commit are extracted, as they are in the commit.
+	writeFile("committed.go", "package pkg\n\nfunc committed() int {\n\treturn 1\n}\n\nfunc modified() int {\n\treturn 2\n}\n")
+	writeFile("untracked.go", "package pkg\n\nfunc untracked() int {\n\treturn 3\n}\n")
+	writeFile("ignored.go", "package pkg\n\nfunc ignored() int {\n\treturn 4\n}\n")
+
+	outputPath := t.TempDir()
+	sink, err := NewJSONLSink(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := extractionpolicy.Parse([]byte(`{"languages": {"go": {"minFunctionLines": 0}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ExtractLocalRepo(context.Background(), sink, "local/repo", repoPath, "", 1, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshotFile, err := os.Open(filepath.Join(outputPath, SnapshotFileName("local/repo", TRACKED_SNAPSHOT_REF)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshotFile.Close()
+
+	var snapshotCommitID string
+	identifiers := map[string][]string{}
+	err = readJSONLSnapshot(
+		snapshotFile,
+		func(snapshot *SnapshotRecord) error {
+			snapshotCommitID = snapshot.CommitID
+			return nil
+		},
+		func(relativePath string, file *extractedFile) error {
+			for _, ef := range file.functions {
+				identifiers[relativePath] = append(identifiers[relativePath], ef.Identifier)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snapshotCommitID != commitID {
+		t.Fatalf("expected the snapshot of commit %s, got %s", commitID, snapshotCommitID)
+	}
+	want := map[string][]string{"committed.go": {"committed"}}
+	if !reflect.DeepEqual(identifiers, want) {
+		t.Fatalf("expected %v, got %v", want, identifiers)
+	}
+}
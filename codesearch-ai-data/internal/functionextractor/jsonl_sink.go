@@ -0,0 +1,329 @@
+package functionextractor
+
+import (
+	"bufio"
+	"codesearch-ai-data/internal/filefilter"
+	ph "codesearch-ai-data/internal/parsinghelpers"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// SnapshotRecord is the first record of the JSONL file of a snapshot.
+type SnapshotRecord struct {
+	Repo     string `json:"repo"`
+	Ref      string `json:"ref"`
+	CommitID string `json:"commitId"`
+	// License is the SPDX license expression detected from the license files of the repo tree.
+	License    string          `json:"license"`
+	PolicyHash string          `json:"policyHash"`
+	Policy     json.RawMessage `json:"policy"`
+}
+
+// FileRecord is a file of the snapshot, either excluded or parsed. It precedes the records of the functions and types
+// extracted from the file.
+type FileRecord struct {
+	Repo     string `json:"repo"`
+	Ref      string `json:"ref"`
+	CommitID string `json:"commitId"`
+	Path     string `json:"path"`
+	// Exclusion is set for excluded files, the other fields are only set for parsed files.
+	Exclusion *filefilter.Exclusion `json:"exclusion,omitempty"`
+	Language  string                `json:"language,omitempty"`
+	// License is the SPDX license expression of the file header, if any.
+	License     string          `json:"license,omitempty"`
+	ParseErrors *ph.ParseErrors `json:"parseErrors,omitempty"`
+}
+
+// FunctionRecord is an extracted function along with the snapshot and the file it was extracted from. Lines are
+// zero-based, as in the extracted function.
+type FunctionRecord struct {
+	Repo     string `json:"repo"`
+	Ref      string `json:"ref"`
+	CommitID string `json:"commitId"`
+	Path     string `json:"path"`
+	Language string `json:"language"`
+	// License is the SPDX license expression of the file header, if any.
+	License     string             `json:"license"`
+	ParseErrors ph.ParseErrors     `json:"parseErrors"`
+	Function    *ExtractedFunction `json:"function"`
+}
+
+// TypeRecord is an extracted type along with the snapshot and the file it was extracted from.
+type TypeRecord struct {
+	Repo     string         `json:"repo"`
+	Ref      string         `json:"ref"`
+	CommitID string         `json:"commitId"`
+	Path     string         `json:"path"`
+	Type     *ExtractedType `json:"type"`
+}
+
+// jsonlRecord is a line of a JSONL snapshot file, with a single record set.
+type jsonlRecord struct {
+	Snapshot *SnapshotRecord `json:"snapshot,omitempty"`
+	File     *FileRecord     `json:"file,omitempty"`
+	Function *FunctionRecord `json:"function,omitempty"`
+	Type     *TypeRecord     `json:"type,omitempty"`
+}
+
+// JSONLSink writes each snapshot to its own JSONL file in a directory, starting with a snapshot record followed by a
+// file record per excluded or parsed file, each followed by a record per function and type extracted from it. Files
+// are written under a temporary name, and only renamed once the snapshot is complete.
+type JSONLSink struct {
+	directory string
+
+	snapshot *SnapshotRecord
+	file     *os.File
+	writer   *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func NewJSONLSink(directory string) (*JSONLSink, error) {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLSink{directory: directory}, nil
+}
+
+// SnapshotFileName returns the name of the JSONL file of the snapshot, e.g. `github.com%2Forg%2Frepo.jsonl` for the
+// tracked snapshot and `github.com%2Forg%2Frepo@v1.0.jsonl` for a ref snapshot. The repo name and the ref are
+// escaped, so snapshots never share a file name and names can be unescaped back.
+func SnapshotFileName(repoName string, ref string) string {
+	name := url.QueryEscape(repoName)
+	if ref != TRACKED_SNAPSHOT_REF {
+		name += "@" + url.QueryEscape(ref)
+	}
+	return name + ".jsonl"
+}
+
+func (s *JSONLSink) path() string {
+	return filepath.Join(s.directory, SnapshotFileName(s.snapshot.Repo, s.snapshot.Ref))
+}
+
+func (s *JSONLSink) BeginSnapshot(ctx context.Context, snapshot *RepoSnapshot) error {
+	s.snapshot = &SnapshotRecord{
+		Repo:       snapshot.RepoName,
+		Ref:        snapshot.Ref,
+		CommitID:   snapshot.CommitID,
+		License:    snapshot.License,
+		PolicyHash: snapshot.PolicyHash,
+		Policy:     json.RawMessage(snapshot.PolicyJSON),
+	}
+
+	file, err := os.Create(s.path() + ".tmp")
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.encoder = json.NewEncoder(s.writer)
+	return s.encoder.Encode(&jsonlRecord{Snapshot: s.snapshot})
+}
+
+func (s *JSONLSink) WriteFile(ctx context.Context, relativePath string, file *extractedFile) error {
+	fileRecord := &FileRecord{
+		Repo:      s.snapshot.Repo,
+		Ref:       s.snapshot.Ref,
+		CommitID:  s.snapshot.CommitID,
+		Path:      relativePath,
+		Exclusion: file.exclusion,
+	}
+	if file.exclusion == nil {
+		fileRecord.Language, fileRecord.License = file.language, file.license
+		fileRecord.ParseErrors = &ph.ParseErrors{}
+		if file.parseErrors != nil {
+			fileRecord.ParseErrors = file.parseErrors
+		}
+	}
+	err := s.encoder.Encode(&jsonlRecord{File: fileRecord})
+	if err != nil || file.exclusion != nil {
+		return err
+	}
+
+	for _, extractedFunction := range file.functions {
+		err := s.encoder.Encode(&jsonlRecord{Function: &FunctionRecord{
+			Repo:        s.snapshot.Repo,
+			Ref:         s.snapshot.Ref,
+			CommitID:    s.snapshot.CommitID,
+			Path:        relativePath,
+			Language:    file.language,
+			License:     file.license,
+			ParseErrors: *fileRecord.ParseErrors,
+			Function:    extractedFunction,
+		}})
+		if err != nil {
+			return err
+		}
+	}
+	for _, extractedType := range file.types {
+		err := s.encoder.Encode(&jsonlRecord{Type: &TypeRecord{
+			Repo:     s.snapshot.Repo,
+			Ref:      s.snapshot.Ref,
+			CommitID: s.snapshot.CommitID,
+			Path:     relativePath,
+			Type:     extractedType,
+		}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *JSONLSink) EndSnapshot(ctx context.Context) error {
+	file, writer := s.file, s.writer
+	s.file, s.writer, s.encoder = nil, nil, nil
+
+	err := writer.Flush()
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), s.path())
+}
+
+func (s *JSONLSink) Abort(ctx context.Context) {
+	if s.file == nil {
+		return
+	}
+	file := s.file
+	s.file, s.writer, s.encoder = nil, nil, nil
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// readJSONLSnapshot calls begin with the snapshot record of a JSONL snapshot file, and then write with each file
+// in order, along with its functions and types.
+func readJSONLSnapshot(r io.Reader, begin func(snapshot *SnapshotRecord) error, write func(relativePath string, file *extractedFile) error) error {
+	decoder := json.NewDecoder(r)
+
+	record := &jsonlRecord{}
+	err := decoder.Decode(record)
+	if errors.Is(err, io.EOF) || (err == nil && record.Snapshot == nil) {
+		return errors.New("missing snapshot record")
+	} else if err != nil {
+		return err
+	}
+	snapshot := record.Snapshot
+
+	err = begin(snapshot)
+	if err != nil {
+		return err
+	}
+
+	relativePath, file := "", (*extractedFile)(nil)
+	// checkRecord checks that a record belongs to the snapshot, and to the current file for function and type records.
+	checkRecord := func(kind string, repo string, ref string, commitID string, path string, inFile bool) error {
+		if repo != snapshot.Repo || ref != snapshot.Ref || commitID != snapshot.CommitID {
+			return fmt.Errorf("%s record of %s does not belong to snapshot %q of repo %s", kind, path, snapshot.Ref, snapshot.Repo)
+		}
+		if inFile && (file == nil || file.exclusion != nil || path != relativePath) {
+			return fmt.Errorf("%s record of %s does not follow the record of its file", kind, path)
+		}
+		return nil
+	}
+
+	for {
+		record := &jsonlRecord{}
+		err := decoder.Decode(record)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		switch {
+		case record.File != nil:
+			fileRecord := record.File
+			err := checkRecord("file", fileRecord.Repo, fileRecord.Ref, fileRecord.CommitID, fileRecord.Path, false)
+			if err != nil {
+				return err
+			}
+			if file != nil {
+				err := write(relativePath, file)
+				if err != nil {
+					return err
+				}
+			}
+			relativePath, file = fileRecord.Path, &extractedFile{exclusion: fileRecord.Exclusion}
+			if fileRecord.Exclusion == nil {
+				file.functions = []*ExtractedFunction{}
+				file.language, file.license, file.parseErrors = fileRecord.Language, fileRecord.License, fileRecord.ParseErrors
+				if file.parseErrors == nil {
+					file.parseErrors = &ph.ParseErrors{}
+				}
+			}
+		case record.Function != nil && record.Function.Function != nil:
+			functionRecord := record.Function
+			err := checkRecord("function", functionRecord.Repo, functionRecord.Ref, functionRecord.CommitID, functionRecord.Path, true)
+			if err != nil {
+				return err
+			}
+			file.functions = append(file.functions, functionRecord.Function)
+		case record.Type != nil && record.Type.Type != nil:
+			typeRecord := record.Type
+			err := checkRecord("type", typeRecord.Repo, typeRecord.Ref, typeRecord.CommitID, typeRecord.Path, true)
+			if err != nil {
+				return err
+			}
+			file.types = append(file.types, typeRecord.Type)
+		default:
+			return errors.New("expected a file, function or type record")
+		}
+	}
+
+	if file != nil {
+		return write(relativePath, file)
+	}
+	return nil
+}
+
+// LoadJSONLSnapshot inserts a snapshot file written by the JSONL sink into the database. It fails if the repo
+// already has a snapshot of the ref. Functions and types that were already extracted are left untouched for ref
+// snapshots.
+func LoadJSONLSnapshot(ctx context.Context, conn *pgx.Conn, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var sink *postgresSink
+	begin := func(snapshot *SnapshotRecord) error {
+		sink = newPostgresSink(conn, snapshot.Ref != TRACKED_SNAPSHOT_REF)
+		return sink.BeginSnapshot(ctx, &RepoSnapshot{
+			RepoName:   snapshot.Repo,
+			Ref:        snapshot.Ref,
+			CommitID:   snapshot.CommitID,
+			License:    snapshot.License,
+			PolicyHash: snapshot.PolicyHash,
+			PolicyJSON: string(snapshot.Policy),
+		})
+	}
+	write := func(relativePath string, file *extractedFile) error {
+		return sink.WriteFile(ctx, relativePath, file)
+	}
+
+	err = readJSONLSnapshot(file, begin, write)
+	if sink != nil {
+		defer sink.Abort(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", path, err)
+	}
+	return sink.EndSnapshot(ctx)
+}
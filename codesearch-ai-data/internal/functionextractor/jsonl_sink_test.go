@@ -0,0 +1,143 @@
+package functionextractor
+
+import (
+	"codesearch-ai-data/internal/extractionpolicy"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func TestJSONLSink(t *testing.T) {
+	repoPath := writeSyntheticMonorepo(t, 1)
+	// Excluded files and files without functions are written too.
+	err := os.MkdirAll(filepath.Join(repoPath, "vendor"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for relativePath, code := range map[string]string{"vendor/lib.go": "package lib\n", "empty.go": "package empty\n"} {
+		err := os.WriteFile(filepath.Join(repoPath, relativePath), []byte(code), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputPath := t.TempDir()
+	sink, err := NewJSONLSink(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := newRepoTree("github.com/org/synthetic-monorepo", repoPath, 4, extractionpolicy.Default())
+	err = tree.extractSnapshot(context.Background(), sink, TRACKED_SNAPSHOT_REF, "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshotPath := filepath.Join(outputPath, SnapshotFileName("github.com/org/synthetic-monorepo", TRACKED_SNAPSHOT_REF))
+	snapshotFile, err := os.Open(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshotFile.Close()
+
+	var snapshot *SnapshotRecord
+	files := map[string]*extractedFile{}
+	functionLocations := map[string][]string{}
+	err = readJSONLSnapshot(
+		snapshotFile,
+		func(s *SnapshotRecord) error {
+			snapshot = s
+			return nil
+		},
+		func(relativePath string, file *extractedFile) error {
+			files[relativePath] = file
+			for _, ef := range file.functions {
+				functionLocations[relativePath] = append(functionLocations[relativePath], fmt.Sprintf("%d-%d %s", ef.StartLine, ef.EndLine, ef.Identifier))
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snapshot.Repo != "github.com/org/synthetic-monorepo" || snapshot.CommitID != "commit" || snapshot.PolicyHash != extractionpolicy.Default().Hash() {
+		t.Fatalf("unexpected snapshot record %+v", snapshot)
+	}
+
+	if files["vendor/lib.go"] == nil || files["vendor/lib.go"].exclusion == nil {
+		t.Fatal("expected the vendored file to be read back as excluded")
+	}
+	if files["empty.go"] == nil || files["empty.go"].functions == nil {
+		t.Fatal("expected the file without functions to be read back as parsed")
+	}
+
+	// The files read back are the ones extracted from the repo tree.
+	for relativePath, file := range files {
+		extractedFile := tree.extractFile(relativePath)
+		for _, ef := range extractedFile.functions {
+			ef.codeRole = codeRole{}
+		}
+		// Files without types are read back without types, whether or not their language has a type extractor.
+		if len(extractedFile.types) == 0 {
+			extractedFile.types = nil
+		}
+		if !reflect.DeepEqual(file, extractedFile) {
+			t.Fatalf("file %s differs from the extracted file", relativePath)
+		}
+	}
+
+	autogold.Equal(t, functionLocations)
+}
+
+func TestJSONLSinkAbort(t *testing.T) {
+	outputPath := t.TempDir()
+	sink, err := NewJSONLSink(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	policy := extractionpolicy.Default()
+	err = sink.BeginSnapshot(ctx, &RepoSnapshot{RepoName: "github.com/org/repo", Ref: "v1.0", CommitID: "commit", PolicyHash: policy.Hash(), PolicyJSON: policy.JSON()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.WriteFile(ctx, "a.go", &extractedFile{functions: []*ExtractedFunction{{Identifier: "a"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Abort(ctx)
+
+	entries, err := os.ReadDir(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected an aborted snapshot to leave no file, got %s", entries[0].Name())
+	}
+}
+
+func TestSnapshotFileName(t *testing.T) {
+	tests := []struct {
+		repoName string
+		ref      string
+		want     string
+	}{
+		{repoName: "github.com/org/repo", ref: TRACKED_SNAPSHOT_REF, want: "github.com%2Forg%2Frepo.jsonl"},
+		{repoName: "github.com/org_repo", ref: TRACKED_SNAPSHOT_REF, want: "github.com%2Forg_repo.jsonl"},
+		{repoName: "github.com/org/repo", ref: "release/1.0", want: "github.com%2Forg%2Frepo@release%2F1.0.jsonl"},
+		{repoName: "github.com/org/repo@v1", ref: TRACKED_SNAPSHOT_REF, want: "github.com%2Forg%2Frepo%40v1.jsonl"},
+	}
+
+	for _, tt := range tests {
+		if got := SnapshotFileName(tt.repoName, tt.ref); got != tt.want {
+			t.Errorf("SnapshotFileName(%q, %q) = %q, want %q", tt.repoName, tt.ref, got, tt.want)
+		}
+	}
+}
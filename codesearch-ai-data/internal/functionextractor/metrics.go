@@ -0,0 +1,102 @@
+package functionextractor
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Metrics queries live in queries/metrics/<language>.scm. They capture the nodes the complexity of functions is
+// measured with:
+//
+//	@branch       - a decision point (e.g. a condition, a loop, a case or a short-circuit operator),
+//	@nesting      - a node nesting the code it contains one level deeper (e.g. a loop or a lambda),
+//	@continuation - a nesting node continuing its parent at the same level (e.g. `else if`).
+type metricsQuery struct {
+	query *extractionQuery
+}
+
+// Metrics describe the size and the complexity of a function.
+type Metrics struct {
+	// TokenCount is the number of tokens of the function, without comments.
+	TokenCount int `json:"tokenCount"`
+	// CyclomaticComplexity is one plus the number of branches of the function.
+	CyclomaticComplexity int `json:"cyclomaticComplexity"`
+	// MaxNestingDepth is the number of nesting nodes enclosing the most nested code of the function.
+	MaxNestingDepth int `json:"maxNestingDepth"`
+	ParameterCount  int `json:"parameterCount"`
+	// CommentRatio is the number of lines with inline comments per line of clean code.
+	CommentRatio float64 `json:"commentRatio"`
+}
+
+// fileMetricsNodes holds the nodes captured by the metrics query in a file.
+type fileMetricsNodes struct {
+	branches      map[*sitter.Node]bool
+	nestings      map[*sitter.Node]bool
+	continuations map[*sitter.Node]bool
+}
+
+func (mq *metricsQuery) getMetricsNodes(rootNode *sitter.Node, code []byte) *fileMetricsNodes {
+	nodes := &fileMetricsNodes{map[*sitter.Node]bool{}, map[*sitter.Node]bool{}, map[*sitter.Node]bool{}}
+	for _, match := range mq.query.getMatches(rootNode) {
+		if !mq.query.matchesPredicates(match, code) {
+			continue
+		}
+		if node, ok := match.captures["branch"]; ok {
+			nodes.branches[node] = true
+		}
+		if node, ok := match.captures["nesting"]; ok {
+			nodes.nestings[node] = true
+		}
+		if node, ok := match.captures["continuation"]; ok {
+			nodes.continuations[node] = true
+		}
+	}
+	return nodes
+}
+
+// getComplexity returns the cyclomatic complexity and the maximum nesting depth of the function. Nested functions
+// add to the complexity of the enclosing function.
+func (fmn *fileMetricsNodes) getComplexity(functionNode *sitter.Node) (int, int) {
+	complexity, maxDepth := 1, 0
+
+	var visit func(node *sitter.Node, depth int)
+	visit = func(node *sitter.Node, depth int) {
+		if fmn.branches[node] {
+			complexity++
+		}
+		if node != functionNode && fmn.nestings[node] && !fmn.continuations[node] {
+			depth++
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+		}
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			visit(node.NamedChild(i), depth)
+		}
+	}
+	visit(functionNode, 0)
+
+	return complexity, maxDepth
+}
+
+// getCommentRatio returns the number of lines with comments per non-empty line of the clean code.
+func getCommentRatio(commentNodes []*sitter.Node, cleanCode string) float64 {
+	codeLines := 0
+	for _, line := range strings.Split(cleanCode, "\n") {
+		if strings.TrimSpace(line) != "" {
+			codeLines++
+		}
+	}
+	if codeLines == 0 {
+		return 0
+	}
+
+	commentLines := map[uint32]bool{}
+	for _, commentNode := range commentNodes {
+		for row := commentNode.StartPoint().Row; row <= commentNode.EndPoint().Row; row++ {
+			commentLines[row] = true
+		}
+	}
+	return float64(len(commentLines)) / float64(codeLines)
+}
@@ -0,0 +1,57 @@
+package functionextractor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MetricsColumns are the names of the metrics, as stored in the extracted_functions columns.
+var MetricsColumns = []string{"token_count", "cyclomatic_complexity", "max_nesting_depth", "parameter_count", "comment_ratio"}
+
+type metricsBound struct {
+	column   string
+	operator string
+	value    float64
+}
+
+// MetricsFilter selects functions by their metrics, e.g. to leave out trivial getters or huge switch tables. A nil
+// filter selects every function.
+type MetricsFilter []metricsBound
+
+var metricsBoundRegexp = regexp.MustCompile(`^(\w+)\s*(<=|>=|<|>|=)\s*(\d+(?:\.\d+)?)$`)
+
+// ParseMetricsFilter parses comma separated bounds on the metrics, e.g. "token_count>=20,cyclomatic_complexity<=50".
+// It returns a nil filter for an empty string.
+func ParseMetricsFilter(bounds string) (MetricsFilter, error) {
+	var filter MetricsFilter
+	for _, bound := range strings.Split(bounds, ",") {
+		if bound = strings.TrimSpace(bound); bound == "" {
+			continue
+		}
+
+		match := metricsBoundRegexp.FindStringSubmatch(bound)
+		if match == nil {
+			return nil, fmt.Errorf("invalid metrics bound %q, expected <metric><operator><number>", bound)
+		}
+		if !contains(MetricsColumns, match[1]) {
+			return nil, fmt.Errorf("unknown metric %s, expected one of %s", match[1], strings.Join(MetricsColumns, ", "))
+		}
+		value, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, metricsBound{match[1], match[2], value})
+	}
+	return filter, nil
+}
+
+// Condition returns the SQL condition on the metrics columns of the table, or an empty string for a nil filter.
+func (f MetricsFilter) Condition(table string) string {
+	conditions := make([]string, 0, len(f))
+	for _, bound := range f {
+		conditions = append(conditions, fmt.Sprintf("%s.%s %s %s", table, bound.column, bound.operator, strconv.FormatFloat(bound.value, 'f', -1, 64)))
+	}
+	return strings.Join(conditions, " AND ")
+}
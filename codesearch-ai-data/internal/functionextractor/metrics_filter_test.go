@@ -0,0 +1,33 @@
+package functionextractor
+
+import "testing"
+
+func TestParseMetricsFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		bounds string
+		want   string
+	}{
+		{name: "No bounds", bounds: "", want: ""},
+		{name: "Single bound", bounds: "token_count>=20", want: "ef.token_count >= 20"},
+		{name: "Several bounds", bounds: " token_count > 20, comment_ratio<0.5,max_nesting_depth<=4", want: "ef.token_count > 20 AND ef.comment_ratio < 0.5 AND ef.max_nesting_depth <= 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseMetricsFilter(tt.bounds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filter.Condition("ef"); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	for _, bounds := range []string{"lines>10", "token_count>>10", "token_count>-1", "token_count; DROP TABLE repos"} {
+		if _, err := ParseMetricsFilter(bounds); err == nil {
+			t.Fatalf("expected an error for %q", bounds)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package functionextractor
+
+import (
+	"codesearch-ai-data/internal/extractionpolicy"
+	"codesearch-ai-data/internal/languages"
+	"sync"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// parserPool reuses the parsers of a grammar across files. Parsers cannot be used concurrently, so each parse
+// borrows a parser from the pool for its duration.
+type parserPool struct {
+	pool sync.Pool
+}
+
+var parserPoolsMutex sync.Mutex
+var parserPools = map[string]*parserPool{}
+
+// getParserPool returns the parser pool of the grammar used by files with the extension.
+func getParserPool(language *languages.Language, fileExtension string) *parserPool {
+	grammarName, grammar := language.GrammarForExtension(fileExtension)
+
+	parserPoolsMutex.Lock()
+	defer parserPoolsMutex.Unlock()
+
+	if pool, ok := parserPools[grammarName]; ok {
+		return pool
+	}
+
+	pool := &parserPool{sync.Pool{New: func() any {
+		parser := sitter.NewParser()
+		parser.SetLanguage(grammar())
+		return parser
+	}}}
+	parserPools[grammarName] = pool
+	return pool
+}
+
+// parse parses the code with a pooled parser. The tree has to be closed once its nodes are not used anymore.
+func (pp *parserPool) parse(code []byte) *sitter.Tree {
+	parser := pp.pool.Get().(*sitter.Parser)
+	defer pp.pool.Put(parser)
+	return parser.Parse(nil, code)
+}
+
+// getExtractorCacheKey identifies the extractors of a grammar with the rules of a policy.
+func getExtractorCacheKey(language *languages.Language, fileExtension string, policy *extractionpolicy.Policy) string {
+	grammarName, _ := language.GrammarForExtension(fileExtension)
+	return policy.Hash() + ":" + grammarName
+}
+
+var functionExtractorsMutex sync.Mutex
+var functionExtractors = map[string]FunctionExtractor{}
+
+// getCachedFunctionExtractor returns the function extractor of the grammar used by files with the extension, with the
+// rules of the policy, creating it on first use. Query extractors do not hold any parsing state and can be shared by
+// concurrent extractions.
+func getCachedFunctionExtractor(language *languages.Language, fileExtension string, policy *extractionpolicy.Policy) (FunctionExtractor, error) {
+	key := getExtractorCacheKey(language, fileExtension, policy)
+
+	functionExtractorsMutex.Lock()
+	defer functionExtractorsMutex.Unlock()
+
+	if functionExtractor, ok := functionExtractors[key]; ok {
+		return functionExtractor, nil
+	}
+
+	functionExtractor, err := NewQueryFunctionExtractor(language.Name, fileExtension, policy.ForLanguage(language.Name))
+	if err != nil {
+		return nil, err
+	}
+	functionExtractors[key] = functionExtractor
+	return functionExtractor, nil
+}
+
+var typeExtractorsMutex sync.Mutex
+var typeExtractors = map[string]TypeExtractor{}
+
+// getCachedTypeExtractor returns the type extractor of the grammar used by files with the extension, with the rules of
+// the policy, creating it on first use.
+func getCachedTypeExtractor(language *languages.Language, fileExtension string, policy *extractionpolicy.Policy) (TypeExtractor, error) {
+	key := getExtractorCacheKey(language, fileExtension, policy)
+
+	typeExtractorsMutex.Lock()
+	defer typeExtractorsMutex.Unlock()
+
+	if typeExtractor, ok := typeExtractors[key]; ok {
+		return typeExtractor, nil
+	}
+
+	typeExtractor, err := NewQueryTypeExtractor(language.Name, fileExtension, policy.ForLanguage(language.Name))
+	if err != nil {
+		return nil, err
+	}
+	typeExtractors[key] = typeExtractor
+	return typeExtractor, nil
+}
@@ -0,0 +1,7 @@
+(call_expression
+  function: (field_expression
+    argument: (_) @receiver
+    field: (field_identifier) @name)) @call
+
+(call_expression
+  function: (identifier) @name) @call
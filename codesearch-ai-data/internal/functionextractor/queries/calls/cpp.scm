@@ -0,0 +1,12 @@
+(call_expression
+  function: (field_expression
+    argument: (_) @receiver
+    field: (field_identifier) @name)) @call
+
+(call_expression
+  function: (qualified_identifier
+    scope: (_) @receiver
+    name: (identifier) @name)) @call
+
+(call_expression
+  function: (identifier) @name) @call
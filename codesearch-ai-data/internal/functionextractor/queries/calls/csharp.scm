@@ -0,0 +1,22 @@
+(invocation_expression
+  function: (member_access_expression
+    expression: (_) @receiver
+    name: [
+      (identifier) @name
+      (generic_name
+        (identifier) @name)
+    ])) @call
+
+(invocation_expression
+  function: [
+    (identifier) @name
+    (generic_name
+      (identifier) @name)
+  ]) @call
+
+(object_creation_expression
+  type: [
+    (identifier) @name
+    (generic_name
+      (identifier) @name)
+  ]) @call
@@ -0,0 +1,9 @@
+(method_invocation
+  object: (_) @receiver
+  name: (identifier) @name) @call
+
+(method_invocation
+  name: (identifier) @name) @call
+
+(object_creation_expression
+  type: (type_identifier) @name) @call
@@ -0,0 +1,9 @@
+; Constructor calls cannot be told apart from function calls.
+(call_expression
+  (navigation_expression
+    (_) @receiver
+    (navigation_suffix
+      (simple_identifier) @name))) @call
+
+(call_expression
+  (simple_identifier) @name) @call
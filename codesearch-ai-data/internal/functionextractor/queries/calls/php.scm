@@ -0,0 +1,15 @@
+(member_call_expression
+  object: (_) @receiver
+  name: (name) @name) @call
+
+(scoped_call_expression
+  scope: (_) @receiver
+  name: (name) @name) @call
+
+(function_call_expression
+  function: (qualified_name
+    (name) @name)) @call
+
+(object_creation_expression
+  (qualified_name
+    (name) @name)) @call
@@ -0,0 +1,7 @@
+(call
+  function: (attribute
+    object: (_) @receiver
+    attribute: (identifier) @name)) @call
+
+(call
+  function: (identifier) @name) @call
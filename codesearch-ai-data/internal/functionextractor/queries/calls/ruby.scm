@@ -0,0 +1,6 @@
+(call
+  receiver: (_) @receiver
+  method: (identifier) @name) @call
+
+(call
+  method: (identifier) @name) @call
@@ -0,0 +1,12 @@
+(call_expression
+  function: (field_expression
+    value: (_) @receiver
+    field: (field_identifier) @name)) @call
+
+(call_expression
+  function: (scoped_identifier
+    path: (_) @receiver
+    name: (identifier) @name)) @call
+
+(call_expression
+  function: (identifier) @name) @call
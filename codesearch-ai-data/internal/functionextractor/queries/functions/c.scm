@@ -0,0 +1,14 @@
+; Declarations without a body are `declaration` nodes and are not matched.
+(function_definition
+  declarator: [
+    (function_declarator
+      declarator: (_) @name)
+    (pointer_declarator
+      declarator: (function_declarator
+        declarator: (_) @name))
+    (pointer_declarator
+      declarator: (pointer_declarator
+        declarator: (function_declarator
+          declarator: (_) @name)))
+  ]
+  body: (_) @body) @function
@@ -0,0 +1,38 @@
+; Declarations without a body are `declaration` nodes. Defaulted and deleted functions are
+; `function_definition` nodes without a body. Out-of-line member definitions keep their scope (`Foo::bar`).
+
+; Doc comments of function templates precede the template declaration.
+(template_declaration
+  (function_definition
+    declarator: [
+      (function_declarator
+        declarator: (_) @name)
+      (pointer_declarator
+        declarator: (function_declarator
+          declarator: (_) @name))
+      (pointer_declarator
+        declarator: (pointer_declarator
+          declarator: (function_declarator
+            declarator: (_) @name)))
+      (reference_declarator
+        (function_declarator
+          declarator: (_) @name))
+    ]
+    body: (_) @body) @function) @doc
+
+(function_definition
+  declarator: [
+    (function_declarator
+      declarator: (_) @name)
+    (pointer_declarator
+      declarator: (function_declarator
+        declarator: (_) @name))
+    (pointer_declarator
+      declarator: (pointer_declarator
+        declarator: (function_declarator
+          declarator: (_) @name)))
+    (reference_declarator
+      (function_declarator
+        declarator: (_) @name))
+  ]
+  body: (_) @body) @function
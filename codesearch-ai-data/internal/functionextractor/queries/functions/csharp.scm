@@ -0,0 +1,25 @@
+; Abstract and interface methods do not have a body.
+[
+  (method_declaration
+    name: (identifier) @name
+    body: (_) @body)
+  (constructor_declaration
+    name: (identifier) @name
+    body: (_) @body)
+  (local_function_statement
+    name: (identifier) @name
+    body: (_) @body)
+] @function
+
+; Lambdas assigned to properties (`Func<int> F { get; } = () => ...`) and fields (`Func<int> f = () => ...`).
+; Other lambdas are skipped.
+(property_declaration
+  name: (identifier) @name
+  (lambda_expression) @function) @doc
+
+(field_declaration
+  (variable_declaration
+    (variable_declarator
+      (identifier) @name
+      (equals_value_clause
+        (lambda_expression) @function)))) @doc
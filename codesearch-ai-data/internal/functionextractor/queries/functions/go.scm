@@ -0,0 +1,5 @@
+(function_declaration
+  name: (identifier) @name) @function
+
+(method_declaration
+  name: (field_identifier) @name) @function
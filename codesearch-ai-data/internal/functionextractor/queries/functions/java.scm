@@ -0,0 +1,2 @@
+(method_declaration
+  name: (identifier) @name) @function
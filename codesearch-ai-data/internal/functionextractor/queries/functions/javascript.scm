@@ -0,0 +1,20 @@
+(method_definition
+  name: [(property_identifier) (identifier)] @name) @function
+
+(method_definition) @function
+
+(function_declaration
+  name: (identifier) @name) @function
+
+; Function expressions take the name of the variable or the property they are assigned to. Their docstrings
+; precede the declaration or the property.
+(_
+  (variable_declarator
+    name: (identifier) @name
+    value: [(arrow_function) (function)] @function)) @doc
+
+(pair
+  key: (property_identifier) @name
+  value: [(arrow_function) (function)] @function) @doc
+
+[(arrow_function) (function)] @function
@@ -0,0 +1,16 @@
+; Abstract and interface functions do not have a body.
+(function_declaration
+  (simple_identifier) @name
+  (function_body) @body) @function
+
+; Secondary constructors take the name of the enclosing class.
+(class_declaration
+  (type_identifier) @name
+  (class_body
+    (secondary_constructor) @function))
+
+; Lambdas and anonymous functions assigned to properties (`val f = { x: Int -> ... }`). Other lambdas are skipped.
+(property_declaration
+  (variable_declaration
+    (simple_identifier) @name)
+  [(lambda_literal) (anonymous_function)] @function) @doc
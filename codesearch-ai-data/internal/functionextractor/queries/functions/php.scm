@@ -0,0 +1,6 @@
+[
+  (method_declaration
+    name: (name) @name)
+  (function_definition
+    name: (name) @name)
+] @function
@@ -0,0 +1,5 @@
+; Setter (`def name=`) and operator methods do not have an identifier.
+(method
+  name: [(identifier) (constant)] @name) @function
+
+(method) @function
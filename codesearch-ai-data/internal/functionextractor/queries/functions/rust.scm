@@ -0,0 +1,25 @@
+; Trait methods without a default implementation are function_signature_item nodes and are not matched.
+
+; Functions inside `impl` blocks are prefixed with the implemented type (e.g. `Point` for `impl<T> Display for Point<T>`).
+((impl_item
+  type: [
+    (type_identifier) @scope
+    (scoped_type_identifier) @scope
+    (generic_type
+      type: (_) @scope)
+  ]
+  body: (declaration_list
+    (function_item
+      name: (identifier) @name) @function))
+  (#set! scope-separator "::"))
+
+; Functions inside `trait` blocks are prefixed with the trait name.
+((trait_item
+  name: (type_identifier) @scope
+  body: (declaration_list
+    (function_item
+      name: (identifier) @name) @function))
+  (#set! scope-separator "::"))
+
+(function_item
+  name: (identifier) @name) @function
@@ -0,0 +1,30 @@
+; Signatures without a body (function_signature, method_signature, abstract_method_signature) are skipped.
+
+(method_definition
+  name: [(property_identifier) (identifier)] @name) @function
+
+(method_definition) @function
+
+[
+  (function_declaration
+    name: (identifier) @name)
+  (generator_function_declaration
+    name: (identifier) @name)
+] @function
+
+; Function expressions take the name of the variable, property or class field they are assigned to.
+(_
+  (variable_declarator
+    name: (identifier) @name
+    value: [(arrow_function) (function)] @function)) @doc
+
+[
+  (pair
+    key: (property_identifier) @name
+    value: [(arrow_function) (function)] @function)
+  (public_field_definition
+    name: (property_identifier) @name
+    value: [(arrow_function) (function)] @function)
+] @doc
+
+[(arrow_function) (function)] @function
@@ -0,0 +1,23 @@
+; Default labels do not have a value.
+[
+  (if_statement)
+  (for_statement)
+  (while_statement)
+  (do_statement)
+  (case_statement
+    value: (_))
+  (conditional_expression)
+  (binary_expression
+    operator: ["&&" "||"])
+] @branch
+
+(if_statement
+  alternative: (if_statement) @continuation)
+
+[
+  (if_statement)
+  (for_statement)
+  (while_statement)
+  (do_statement)
+  (switch_statement)
+] @nesting
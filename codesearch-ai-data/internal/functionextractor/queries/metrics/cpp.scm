@@ -0,0 +1,28 @@
+; Default labels do not have a value.
+[
+  (if_statement)
+  (for_statement)
+  (for_range_loop)
+  (while_statement)
+  (do_statement)
+  (case_statement
+    value: (_))
+  (catch_clause)
+  (conditional_expression)
+  (binary_expression
+    operator: ["&&" "||"])
+] @branch
+
+(if_statement
+  alternative: (if_statement) @continuation)
+
+[
+  (if_statement)
+  (for_statement)
+  (for_range_loop)
+  (while_statement)
+  (do_statement)
+  (switch_statement)
+  (try_statement)
+  (lambda_expression)
+] @nesting
@@ -0,0 +1,26 @@
+[
+  (if_statement)
+  (for_statement)
+  (for_each_statement)
+  (while_statement)
+  (do_statement)
+  (case_switch_label)
+  (catch_clause)
+  (conditional_expression)
+  (binary_expression
+    operator: ["&&" "||" "??"])
+] @branch
+
+(if_statement
+  alternative: (if_statement) @continuation)
+
+[
+  (if_statement)
+  (for_statement)
+  (for_each_statement)
+  (while_statement)
+  (do_statement)
+  (switch_statement)
+  (try_statement)
+  (lambda_expression)
+] @nesting
@@ -0,0 +1,21 @@
+[
+  (if_statement)
+  (for_statement)
+  (expression_case)
+  (type_case)
+  (communication_case)
+  (binary_expression
+    operator: ["&&" "||"])
+] @branch
+
+(if_statement
+  alternative: (if_statement) @continuation)
+
+[
+  (if_statement)
+  (for_statement)
+  (expression_switch_statement)
+  (type_switch_statement)
+  (select_statement)
+  (func_literal)
+] @nesting
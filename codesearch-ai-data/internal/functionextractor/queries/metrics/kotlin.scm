@@ -0,0 +1,31 @@
+; When entries without a condition are `else` branches.
+[
+  (if_expression)
+  (for_statement)
+  (while_statement)
+  (do_while_statement)
+  (when_entry
+    (when_condition))
+  (catch_block)
+  (conjunction_expression)
+  (disjunction_expression)
+  (elvis_expression)
+] @branch
+
+(if_expression
+  (control_structure_body)
+  (control_structure_body
+    .
+    (if_expression) @continuation
+    .))
+
+[
+  (if_expression)
+  (for_statement)
+  (while_statement)
+  (do_while_statement)
+  (when_expression)
+  (try_expression)
+  (lambda_literal)
+  (anonymous_function)
+] @nesting
@@ -0,0 +1,28 @@
+; Binary expressions do not have an operator field.
+[
+  (if_statement)
+  (else_if_clause)
+  (for_statement)
+  (foreach_statement)
+  (while_statement)
+  (do_statement)
+  (case_statement)
+  (catch_clause)
+  (conditional_expression)
+  (binary_expression
+    ["&&" "||" "and" "or" "??"])
+] @branch
+
+(else_clause
+  body: (if_statement) @continuation)
+
+[
+  (if_statement)
+  (for_statement)
+  (foreach_statement)
+  (while_statement)
+  (do_statement)
+  (switch_statement)
+  (try_statement)
+  (anonymous_function_creation_expression)
+] @nesting
@@ -0,0 +1,30 @@
+[
+  (if)
+  (elsif)
+  (unless)
+  (while)
+  (until)
+  (for)
+  (when)
+  (rescue)
+  (conditional)
+  (if_modifier)
+  (unless_modifier)
+  (while_modifier)
+  (until_modifier)
+  (rescue_modifier)
+  (binary
+    operator: ["&&" "||" "and" "or"])
+] @branch
+
+[
+  (if)
+  (unless)
+  (while)
+  (until)
+  (for)
+  (case)
+  (begin)
+  (block)
+  (do_block)
+] @nesting
@@ -0,0 +1,28 @@
+; Wildcard match arms (`_ =>`) do not have a pattern. The `?` operator returns early.
+[
+  (if_expression)
+  (if_let_expression)
+  (while_expression)
+  (while_let_expression)
+  (for_expression)
+  (match_arm
+    pattern: (match_pattern
+      (_)))
+  (try_expression)
+  (binary_expression
+    operator: ["&&" "||"])
+] @branch
+
+(else_clause
+  [(if_expression) (if_let_expression)] @continuation)
+
+[
+  (if_expression)
+  (if_let_expression)
+  (while_expression)
+  (while_let_expression)
+  (loop_expression)
+  (for_expression)
+  (match_expression)
+  (closure_expression)
+] @nesting
@@ -0,0 +1,27 @@
+[
+  (if_statement)
+  (for_statement)
+  (for_in_statement)
+  (while_statement)
+  (do_statement)
+  (switch_case)
+  (catch_clause)
+  (ternary_expression)
+  (binary_expression
+    operator: ["&&" "||" "??"])
+] @branch
+
+(else_clause
+  (if_statement) @continuation)
+
+[
+  (if_statement)
+  (for_statement)
+  (for_in_statement)
+  (while_statement)
+  (do_statement)
+  (switch_statement)
+  (try_statement)
+  (arrow_function)
+  (function)
+] @nesting
@@ -0,0 +1,15 @@
+; GoogleTest test macros are parsed as function definitions, e.g. `TEST_F(ParserTest, Parses) {...}`.
+((function_definition
+  declarator: (function_declarator
+    declarator: (identifier) @macro)) @role
+  (#any-of? @macro "TEST" "TEST_F" "TEST_P" "TYPED_TEST" "TYPED_TEST_P" "FUZZ_TEST")
+  (#set! role "test"))
+
+; Google Benchmark functions take a `benchmark::State&`.
+((function_definition
+  declarator: (function_declarator
+    parameters: (parameter_list
+      (parameter_declaration
+        type: (qualified_identifier) @type)))) @role
+  (#eq? @type "benchmark::State")
+  (#set! role "benchmark"))
@@ -0,0 +1,22 @@
+; NUnit, xUnit and MSTest test and fixture methods, test classes, and BenchmarkDotNet benchmarks. Attribute names can be qualified
+; and keep their `Attribute` suffix.
+((method_declaration
+  (attribute_list
+    (attribute
+      name: (_) @attribute))) @role
+  (#match? @attribute "(^|\\.)Benchmark(Attribute)?$")
+  (#set! role "benchmark"))
+
+((method_declaration
+  (attribute_list
+    (attribute
+      name: (_) @attribute))) @role
+  (#match? @attribute "(^|\\.)(Test|TestCase|TestCaseSource|Fact|Theory|TestMethod|DataTestMethod|SetUp|TearDown|OneTimeSetUp|OneTimeTearDown|TestInitialize|TestCleanup)(Attribute)?$")
+  (#set! role "test"))
+
+((class_declaration
+  (attribute_list
+    (attribute
+      name: (_) @attribute))) @role
+  (#match? @attribute "(^|\\.)(TestFixture|TestClass)(Attribute)?$")
+  (#set! role "test"))
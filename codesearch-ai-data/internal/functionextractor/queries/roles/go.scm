@@ -0,0 +1,18 @@
+; Test, fuzz, benchmark and example functions are named after the testing package conventions (`TestXxx`, `Test_xxx`).
+((function_declaration
+  name: (identifier) @name) @role
+  (#match? @name "^(Test|Fuzz)([A-Z0-9_]|$)")
+  (#set! role "test")
+  (#set! test-file-only "true"))
+
+((function_declaration
+  name: (identifier) @name) @role
+  (#match? @name "^Benchmark([A-Z0-9_]|$)")
+  (#set! role "benchmark")
+  (#set! test-file-only "true"))
+
+((function_declaration
+  name: (identifier) @name) @role
+  (#match? @name "^Example([A-Z_]|$)")
+  (#set! role "example")
+  (#set! test-file-only "true"))
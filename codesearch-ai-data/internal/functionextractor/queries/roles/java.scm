@@ -0,0 +1,45 @@
+; JUnit and TestNG test and fixture methods, and JMH benchmarks.
+((method_declaration
+  (modifiers
+    [
+      (marker_annotation
+        name: [
+          (identifier) @annotation
+          (scoped_identifier
+            name: (identifier) @annotation)
+        ])
+      (annotation
+        name: [
+          (identifier) @annotation
+          (scoped_identifier
+            name: (identifier) @annotation)
+        ])
+    ])) @role
+  (#eq? @annotation "Benchmark")
+  (#set! role "benchmark"))
+
+((method_declaration
+  (modifiers
+    [
+      (marker_annotation
+        name: [
+          (identifier) @annotation
+          (scoped_identifier
+            name: (identifier) @annotation)
+        ])
+      (annotation
+        name: [
+          (identifier) @annotation
+          (scoped_identifier
+            name: (identifier) @annotation)
+        ])
+    ])) @role
+  (#any-of? @annotation "Test" "ParameterizedTest" "RepeatedTest" "TestFactory" "TestTemplate" "Property" "BeforeEach" "AfterEach" "BeforeAll" "AfterAll" "Before" "After" "BeforeClass" "AfterClass" "BeforeMethod" "AfterMethod")
+  (#set! role "test"))
+
+; JUnit 3 test cases.
+((class_declaration
+  superclass: (superclass
+    (_) @base)) @role
+  (#match? @base "(^|\\.)TestCase$")
+  (#set! role "test"))
@@ -0,0 +1,23 @@
+; Callbacks of Jest, Mocha, Jasmine and Vitest blocks, e.g. `describe("parser", () => {...})` or
+; `it.skip("parses", function () {...})`.
+((call_expression
+  function: [
+    (identifier) @block
+    (member_expression
+      object: (identifier) @block)
+  ]
+  arguments: (arguments
+    [(arrow_function) (function)] @role))
+  (#eq? @block "bench")
+  (#set! role "benchmark"))
+
+((call_expression
+  function: [
+    (identifier) @block
+    (member_expression
+      object: (identifier) @block)
+  ]
+  arguments: (arguments
+    [(arrow_function) (function)] @role))
+  (#any-of? @block "describe" "context" "suite" "it" "test" "specify" "beforeEach" "afterEach" "beforeAll" "afterAll" "before" "after")
+  (#set! role "test"))
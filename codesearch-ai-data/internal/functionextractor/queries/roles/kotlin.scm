@@ -0,0 +1,22 @@
+; JUnit and TestNG test and fixture functions, and JMH and kotlinx-benchmark benchmarks. Annotations can be qualified.
+((function_declaration
+  (modifiers
+    (annotation
+      [
+        (user_type) @annotation
+        (constructor_invocation
+          (user_type) @annotation)
+      ]))) @role
+  (#match? @annotation "(^|\\.)Benchmark$")
+  (#set! role "benchmark"))
+
+((function_declaration
+  (modifiers
+    (annotation
+      [
+        (user_type) @annotation
+        (constructor_invocation
+          (user_type) @annotation)
+      ]))) @role
+  (#match? @annotation "(^|\\.)(Test|ParameterizedTest|RepeatedTest|TestFactory|TestTemplate|BeforeEach|AfterEach|BeforeAll|AfterAll|Before|After|BeforeClass|AfterClass|BeforeTest|AfterTest)$")
+  (#set! role "test"))
@@ -0,0 +1,12 @@
+; PHPUnit test cases.
+((class_declaration
+  (base_clause
+    (_) @base)) @role
+  (#match? @base "TestCase$")
+  (#set! role "test"))
+
+((method_declaration
+  name: (name) @name) @role
+  (#match? @name "^test")
+  (#set! role "test")
+  (#set! test-file-only "true"))
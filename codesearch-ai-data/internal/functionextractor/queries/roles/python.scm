@@ -0,0 +1,24 @@
+; pytest-benchmark tests take the `benchmark` fixture.
+((function_definition
+  parameters: (parameters
+    (identifier) @parameter)) @role
+  (#eq? @parameter "benchmark")
+  (#set! role "benchmark")
+  (#set! test-file-only "true"))
+
+((function_definition
+  name: (identifier) @name) @role
+  (#match? @name "^test")
+  (#set! role "test")
+  (#set! test-file-only "true"))
+
+; unittest test cases, e.g. `class ParserTest(unittest.TestCase)`.
+((class_definition
+  superclasses: (argument_list
+    [
+      (identifier) @base
+      (attribute
+        attribute: (identifier) @base)
+    ])) @role
+  (#match? @base "TestCase$")
+  (#set! role "test"))
@@ -0,0 +1,19 @@
+; RSpec blocks, e.g. `describe Parser do ... end`.
+((call
+  method: (identifier) @block
+  block: [(do_block) (block)] @role)
+  (#any-of? @block "describe" "context" "feature" "it" "specify" "scenario" "shared_examples" "shared_context" "before" "after" "around" "let" "subject")
+  (#set! role "test"))
+
+; Minitest and Test::Unit test cases.
+((class
+  superclass: (superclass
+    (_) @base)) @role
+  (#match? @base "Test(Case)?$")
+  (#set! role "test"))
+
+((method
+  name: (identifier) @name) @role
+  (#match? @name "^test_")
+  (#set! role "test")
+  (#set! test-file-only "true"))
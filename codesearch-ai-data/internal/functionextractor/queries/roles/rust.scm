@@ -0,0 +1,43 @@
+; Attributes precede the items they apply to, e.g. `#[test]`, `#[tokio::test]` or `#[bench]` functions and
+; `#[cfg(test)]` modules.
+((attribute_item
+  (meta_item
+    [
+      (identifier) @attribute
+      (scoped_identifier
+        name: (identifier) @attribute)
+    ]))
+  .
+  (attribute_item)*
+  .
+  (function_item) @role
+  (#eq? @attribute "bench")
+  (#set! role "benchmark"))
+
+((attribute_item
+  (meta_item
+    [
+      (identifier) @attribute
+      (scoped_identifier
+        name: (identifier) @attribute)
+    ]))
+  .
+  (attribute_item)*
+  .
+  (function_item) @role
+  (#eq? @attribute "test")
+  (#set! role "test"))
+
+((attribute_item
+  (meta_item
+    (identifier) @attribute
+    arguments: (meta_arguments
+      (meta_item
+        (identifier) @argument))))
+  .
+  (attribute_item)*
+  .
+  (mod_item) @role
+  (#eq? @attribute "cfg")
+  (#eq? @argument "test")
+  (#set! role "test"))
@@ -0,0 +1,10 @@
+; Functions are qualified by their enclosing namespaces and classes (`net::Buffer::grow`).
+(([
+  (namespace_definition
+    name: (_) @name)
+  (class_specifier
+    name: (type_identifier) @name)
+  (struct_specifier
+    name: (type_identifier) @name)
+] @scope)
+  (#set! separator "::"))
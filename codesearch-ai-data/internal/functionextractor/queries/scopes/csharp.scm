@@ -0,0 +1,11 @@
+; Methods are qualified by their enclosing namespaces and types (`App.Data.Repository.Save`).
+([
+  (namespace_declaration
+    name: (_) @name)
+  (class_declaration
+    name: (identifier) @name)
+  (struct_declaration
+    name: (identifier) @name)
+  (interface_declaration
+    name: (identifier) @name)
+] @scope)
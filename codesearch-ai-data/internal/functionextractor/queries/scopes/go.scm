@@ -0,0 +1,21 @@
+; Functions are qualified by their package, methods by their receiver type (`pkg.(*Server).Handle`).
+(source_file
+  (package_clause
+    (package_identifier) @name) @file_scope)
+
+((method_declaration
+  receiver: (parameter_list
+    (parameter_declaration
+      type: [
+        (pointer_type (type_identifier) @name)
+        (pointer_type (generic_type type: (type_identifier) @name))
+      ]))) @scope
+  (#set! format "(*%s)"))
+
+(method_declaration
+  receiver: (parameter_list
+    (parameter_declaration
+      type: [
+        (type_identifier) @name
+        (generic_type type: (type_identifier) @name)
+      ]))) @scope
@@ -0,0 +1,14 @@
+; Methods are qualified by their package and enclosing types (`com.example.Outer.Inner#method`).
+(program
+  (package_declaration
+    [(identifier) (scoped_identifier)] @name) @file_scope)
+
+(([
+  (class_declaration
+    name: (identifier) @name)
+  (interface_declaration
+    name: (identifier) @name)
+  (enum_declaration
+    name: (identifier) @name)
+] @scope)
+  (#set! member-separator "#"))
@@ -0,0 +1,7 @@
+; Methods are qualified by their enclosing class (`Queue.drain`).
+([
+  (class_declaration
+    name: (identifier) @name)
+  (class
+    name: (identifier) @name)
+] @scope)
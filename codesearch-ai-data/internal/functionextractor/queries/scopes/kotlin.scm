@@ -0,0 +1,16 @@
+; Functions are qualified by their package, enclosing classes and objects, and the receiver type of extension
+; functions (`com.example.Outer.Inner.method`). Companion object members are qualified by the class.
+(source_file
+  (package_header
+    (identifier) @name) @file_scope)
+
+([
+  (class_declaration
+    (type_identifier) @name)
+  (object_declaration
+    (type_identifier) @name)
+  (function_declaration
+    [(user_type) (nullable_type)] @name
+    .
+    (simple_identifier))
+] @scope)
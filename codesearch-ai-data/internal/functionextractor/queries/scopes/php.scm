@@ -0,0 +1,20 @@
+; Functions are qualified by their namespace, methods by their class (`App\Http\Controller::handle`).
+((namespace_definition
+  name: (namespace_name) @name
+  !body) @file_scope
+  (#set! separator "\\"))
+
+((namespace_definition
+  name: (namespace_name) @name
+  body: (_)) @scope
+  (#set! separator "\\"))
+
+(([
+  (class_declaration
+    name: (name) @name)
+  (interface_declaration
+    name: (name) @name)
+  (trait_declaration
+    name: (name) @name)
+] @scope)
+  (#set! separator "::"))
@@ -0,0 +1,3 @@
+; Methods are qualified by their enclosing classes (`Server.Handler.handle`). Modules are added from the file path.
+(class_definition
+  name: (identifier) @name) @scope
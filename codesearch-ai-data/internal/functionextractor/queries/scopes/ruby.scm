@@ -0,0 +1,8 @@
+; Methods are qualified by their enclosing modules and classes (`Outer.Inner#method`).
+(([
+  (module
+    name: [(constant) (scope_resolution)] @name)
+  (class
+    name: [(constant) (scope_resolution)] @name)
+] @scope)
+  (#set! member-separator "#"))
@@ -0,0 +1,15 @@
+; Functions are qualified by their enclosing modules and the implemented type or trait (`parser::Stack::push`).
+(([
+  (mod_item
+    name: (identifier) @name)
+  (impl_item
+    type: [
+      (type_identifier) @name
+      (scoped_type_identifier) @name
+      (generic_type
+        type: (_) @name)
+    ])
+  (trait_item
+    name: (type_identifier) @name)
+] @scope)
+  (#set! separator "::"))
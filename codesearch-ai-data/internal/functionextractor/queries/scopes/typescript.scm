@@ -0,0 +1,11 @@
+; Methods are qualified by their enclosing namespaces and class (`Store.Queue.drain`).
+([
+  (internal_module
+    name: (_) @name)
+  (class_declaration
+    name: (type_identifier) @name)
+  (abstract_class_declaration
+    name: (type_identifier) @name)
+  (class
+    name: (type_identifier) @name)
+] @scope)
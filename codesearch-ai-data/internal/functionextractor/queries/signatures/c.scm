@@ -0,0 +1,51 @@
+; Static functions have internal linkage, other functions are public.
+((function_definition
+  (storage_class_specifier) @static) @function
+  (#eq? @static "static")
+  (#set! visibility "private"))
+
+; Pointers returned by the function are part of the return type.
+((function_definition
+  type: (_) @return_type
+  declarator: (function_declarator
+    parameters: (parameter_list) @parameters)) @function
+  (#set! visibility "public"))
+
+((function_definition
+  type: (_) @return_type
+  declarator: (pointer_declarator
+    declarator: (function_declarator
+      parameters: (parameter_list) @parameters))) @function
+  (#set! visibility "public")
+  (#set! return-type-suffix "*"))
+
+((function_definition
+  type: (_) @return_type
+  declarator: (pointer_declarator
+    declarator: (pointer_declarator
+      declarator: (function_declarator
+        parameters: (parameter_list) @parameters)))) @function
+  (#set! visibility "public")
+  (#set! return-type-suffix "**"))
+
+; Pointer declarators are part of the type (`const char *text`).
+((parameter_declaration
+  declarator: [
+    (identifier) @parameter.name
+    (pointer_declarator
+      declarator: (identifier) @parameter.name)
+    (pointer_declarator
+      declarator: (pointer_declarator
+        declarator: (identifier) @parameter.name))
+    (array_declarator
+      declarator: (identifier) @parameter.name)
+  ]) @parameter
+  (#set! type-from "parameter"))
+
+; Unnamed parameters, except `(void)`.
+((parameter_declaration
+  !declarator) @parameter @parameter.type
+  (#not-eq? @parameter "void"))
+
+((variadic_parameter) @parameter
+  (#set! variadic "true"))
@@ -0,0 +1,119 @@
+; Methods defined inside a class take the class name as receiver and the visibility of the closest access specifier.
+((class_specifier
+  name: (type_identifier) @receiver
+  body: (field_declaration_list
+    [
+      (function_definition) @function
+      (template_declaration
+        (function_definition) @function)
+    ]))
+  (#set! visibility-from-sibling "access_specifier")
+  (#set! visibility "private"))
+
+((struct_specifier
+  name: (type_identifier) @receiver
+  body: (field_declaration_list
+    [
+      (function_definition) @function
+      (template_declaration
+        (function_definition) @function)
+    ]))
+  (#set! visibility-from-sibling "access_specifier")
+  (#set! visibility "public"))
+
+(field_declaration_list
+  (function_definition
+    (storage_class_specifier) @static
+    (#eq? @static "static")) @function)
+
+; Out-of-line member definitions (`int Parser::parse()`) take the scope as receiver, their visibility is unknown.
+((function_definition
+  declarator: (_
+    declarator: (qualified_identifier
+      scope: (_) @receiver))) @function
+  (#set! visibility ""))
+
+((function_definition
+  declarator: (_
+    (function_declarator
+      declarator: (qualified_identifier
+        scope: (_) @receiver)))) @function
+  (#set! visibility ""))
+
+; Static free functions have internal linkage, other free functions are public.
+((function_definition
+  (storage_class_specifier) @static) @function
+  (#eq? @static "static")
+  (#set! visibility "private"))
+
+; Pointers and references returned by the function are part of the return type.
+((function_definition
+  type: (_)? @return_type
+  declarator: (function_declarator
+    parameters: (parameter_list) @parameters)) @function
+  (#set! visibility "public"))
+
+((function_definition
+  type: (_) @return_type
+  declarator: (pointer_declarator
+    declarator: (function_declarator
+      parameters: (parameter_list) @parameters))) @function
+  (#set! visibility "public")
+  (#set! return-type-suffix "*"))
+
+((function_definition
+  type: (_) @return_type
+  declarator: (pointer_declarator
+    declarator: (pointer_declarator
+      declarator: (function_declarator
+        parameters: (parameter_list) @parameters)))) @function
+  (#set! visibility "public")
+  (#set! return-type-suffix "**"))
+
+((function_definition
+  type: (_) @return_type
+  declarator: (reference_declarator
+    (function_declarator
+      parameters: (parameter_list) @parameters))) @function
+  (#set! visibility "public")
+  (#set! return-type-suffix "&"))
+
+; Pointer and reference declarators are part of the type (`const std::string &input`).
+(([
+  (parameter_declaration
+    declarator: [
+      (identifier) @parameter.name
+      (pointer_declarator
+        declarator: (identifier) @parameter.name)
+      (pointer_declarator
+        declarator: (pointer_declarator
+          declarator: (identifier) @parameter.name))
+      (reference_declarator
+        (identifier) @parameter.name)
+      (array_declarator
+        declarator: (identifier) @parameter.name)
+      (function_declarator
+        declarator: (parenthesized_declarator
+          (pointer_declarator
+            declarator: (identifier) @parameter.name)))
+    ])
+  (optional_parameter_declaration
+    declarator: [
+      (identifier) @parameter.name
+      (pointer_declarator
+        declarator: (identifier) @parameter.name)
+      (reference_declarator
+        (identifier) @parameter.name)
+    ]
+    default_value: (_) @parameter.default)
+]) @parameter
+  (#set! type-from "parameter"))
+
+; Unnamed parameters, except `(void)`.
+((parameter_declaration
+  !declarator) @parameter @parameter.type
+  (#not-eq? @parameter "void"))
+
+((parameter_list
+  "..." @parameter)
+  (#set! variadic "true"))
@@ -0,0 +1,76 @@
+; Methods take the name of the enclosing type.
+([
+  (class_declaration
+    name: (identifier) @receiver
+    body: (declaration_list
+      [(method_declaration) (constructor_declaration)] @function))
+  (struct_declaration
+    name: (identifier) @receiver
+    body: (declaration_list
+      [(method_declaration) (constructor_declaration)] @function))
+  (interface_declaration
+    name: (identifier) @receiver
+    body: (declaration_list
+      [(method_declaration) (constructor_declaration)] @function))
+])
+
+(([
+  (method_declaration
+    (modifier) @visibility)
+  (constructor_declaration
+    (modifier) @visibility)
+] @function)
+  (#any-of? @visibility "public" "protected" "internal" "private"))
+
+(([
+  (method_declaration
+    (modifier) @static)
+  (constructor_declaration
+    (modifier) @static)
+  (local_function_statement
+    (modifier) @static)
+] @function)
+  (#eq? @static "static"))
+
+(([
+  (method_declaration
+    (modifier) @async)
+  (local_function_statement
+    (modifier) @async)
+] @function)
+  (#eq? @async "async"))
+
+(lambda_expression
+  "async" @async) @function
+
+; Members without an access modifier are private.
+(([
+  (method_declaration
+    type: (_) @return_type
+    parameters: (parameter_list) @parameters)
+  (constructor_declaration
+    parameters: (parameter_list) @parameters)
+] @function)
+  (#set! visibility "private"))
+
+[
+  (local_function_statement
+    type: (_) @return_type
+    parameters: (parameter_list) @parameters)
+  (lambda_expression
+    (parameter_list) @parameters)
+] @function
+
+; Parameter modifiers are part of the type (`ref T item`).
+((parameter
+  name: (identifier) @parameter.name
+  (equals_value_clause)? @parameter.default) @parameter
+  (#set! type-from "parameter"))
+
+((parameter_list
+  "params"
+  .
+  (_) @parameter.type
+  .
+  (identifier) @parameter.name @parameter)
+  (#set! variadic "true"))
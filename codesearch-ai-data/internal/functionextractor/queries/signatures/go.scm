@@ -0,0 +1,44 @@
+; Methods take the receiver type name, without the pointer and the type arguments.
+(method_declaration
+  receiver: (parameter_list
+    (parameter_declaration
+      type: [
+        (type_identifier) @receiver
+        (pointer_type (type_identifier) @receiver)
+        (generic_type type: (type_identifier) @receiver)
+        (pointer_type (generic_type type: (type_identifier) @receiver))
+      ]))) @function
+
+; Exported functions are public, other functions are private.
+(([
+  (function_declaration
+    name: (identifier) @name)
+  (method_declaration
+    name: (field_identifier) @name)
+] @function)
+  (#match? @name "^[A-Z]")
+  (#set! visibility "public"))
+
+([
+  (function_declaration
+    parameters: (parameter_list) @parameters
+    result: (_)? @return_type)
+  (method_declaration
+    parameters: (parameter_list) @parameters
+    result: (_)? @return_type)
+] @function
+  (#set! visibility "private"))
+
+; Parameters sharing a type (`a, b int`) match once per name.
+(parameter_declaration
+  name: (identifier) @parameter.name
+  type: (_) @parameter.type) @parameter
+
+(parameter_declaration
+  !name
+  type: (_) @parameter.type) @parameter
+
+((variadic_parameter_declaration
+  name: (identifier)? @parameter.name
+  type: (_) @parameter.type) @parameter
+  (#set! variadic "true"))
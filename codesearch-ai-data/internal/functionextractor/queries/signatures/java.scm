@@ -0,0 +1,40 @@
+; Methods take the name of the enclosing class, interface, enum or record.
+([
+  (class_declaration
+    name: (identifier) @receiver
+    body: (class_body
+      (method_declaration) @function))
+  (interface_declaration
+    name: (identifier) @receiver
+    body: (interface_body
+      (method_declaration) @function))
+  (enum_declaration
+    name: (identifier) @receiver
+    body: (enum_body
+      (enum_body_declarations
+        (method_declaration) @function)))
+])
+
+(method_declaration
+  (modifiers
+    ["public" "protected" "private"] @visibility)) @function
+
+(method_declaration
+  (modifiers
+    "static" @static)) @function
+
+; Methods without an access modifier are package-private.
+((method_declaration
+  type: (_) @return_type
+  parameters: (formal_parameters) @parameters) @function
+  (#set! visibility "package"))
+
+(formal_parameter
+  type: (_) @parameter.type
+  name: (identifier) @parameter.name) @parameter
+
+((spread_parameter
+  (_) @parameter.type
+  (variable_declarator
+    name: (identifier) @parameter.name)) @parameter
+  (#set! variadic "true"))
@@ -0,0 +1,53 @@
+; Methods take the name of the enclosing class.
+(class_declaration
+  name: (identifier) @receiver
+  body: (class_body
+    (method_definition) @function))
+
+([
+  (method_definition
+    "static" @static)
+  (method_definition
+    "async" @async)
+  (function_declaration
+    "async" @async)
+  (generator_function_declaration
+    "async" @async)
+  (function
+    "async" @async)
+  (arrow_function
+    "async" @async)
+] @function)
+
+[
+  (method_definition
+    parameters: (formal_parameters) @parameters)
+  (function_declaration
+    parameters: (formal_parameters) @parameters)
+  (generator_function_declaration
+    parameters: (formal_parameters) @parameters)
+  (function
+    parameters: (formal_parameters) @parameters)
+  (arrow_function
+    parameters: (formal_parameters) @parameters)
+] @function
+
+; Arrow functions with a single parameter do not have a parameter list.
+(arrow_function
+  parameter: (identifier) @parameter.name @parameter) @function @parameters
+
+(formal_parameters
+  [
+    (identifier) @parameter.name
+    (object_pattern) @parameter.name
+    (array_pattern) @parameter.name
+  ] @parameter)
+
+(formal_parameters
+  (assignment_pattern
+    left: (_) @parameter.name) @parameter)
+
+((formal_parameters
+  (rest_pattern
+    (_) @parameter.name) @parameter)
+  (#set! variadic "true"))
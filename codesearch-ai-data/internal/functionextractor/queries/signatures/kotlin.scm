@@ -0,0 +1,88 @@
+; Extension functions take the name of their receiver type, and members the name of the enclosing class or object.
+(function_declaration
+  [(user_type) (nullable_type)] @receiver
+  .
+  (simple_identifier)) @function
+
+([
+  (class_declaration
+    (type_identifier) @receiver
+    (class_body
+      [(function_declaration) (secondary_constructor)] @function))
+  (object_declaration
+    (type_identifier) @receiver
+    (class_body
+      (function_declaration) @function))
+])
+
+; Companion object members are called on the class, like static methods.
+((class_declaration
+  (type_identifier) @receiver
+  (class_body
+    (companion_object
+      (class_body
+        (function_declaration) @function))))
+  (#set! static "true"))
+
+([
+  (function_declaration
+    (modifiers
+      (visibility_modifier) @visibility))
+  (secondary_constructor
+    (modifiers
+      (visibility_modifier) @visibility))
+] @function)
+
+((function_declaration
+  (modifiers
+    (function_modifier) @async)) @function
+  (#eq? @async "suspend"))
+
+; The return type follows the colon after the parameters, expression bodies without one infer it. Declarations
+; without a visibility modifier are public.
+((function_declaration
+  ":"
+  .
+  (_) @return_type
+  .
+  (function_body)) @function @parameters
+  (#set! visibility "public"))
+
+((function_declaration) @function @parameters
+  (#set! visibility "public"))
+
+((secondary_constructor) @function @parameters
+  (#set! visibility "public"))
+
+((anonymous_function
+  ":"
+  .
+  (_) @return_type
+  .
+  (function_body)) @function @parameters)
+
+(anonymous_function) @function @parameters
+
+(lambda_literal
+  (lambda_parameters) @parameters) @function
+
+((parameter_modifiers
+  (parameter_modifier) @modifier)
+  .
+  (parameter
+    (simple_identifier) @parameter.name
+    .
+    (_) @parameter.type) @parameter
+  (#eq? @modifier "vararg")
+  (#set! variadic "true"))
+
+(parameter
+  (simple_identifier) @parameter.name
+  .
+  (_) @parameter.type) @parameter
+
+(lambda_parameters
+  (variable_declaration
+    (simple_identifier) @parameter.name
+    .
+    (_)? @parameter.type) @parameter)
@@ -0,0 +1,44 @@
+; Methods take the name of the enclosing class, interface or trait.
+([
+  (class_declaration
+    name: (name) @receiver
+    body: (declaration_list
+      (method_declaration) @function))
+  (interface_declaration
+    name: (name) @receiver
+    body: (declaration_list
+      (method_declaration) @function))
+  (trait_declaration
+    name: (name) @receiver
+    body: (declaration_list
+      (method_declaration) @function))
+])
+
+(method_declaration
+  (visibility_modifier) @visibility) @function
+
+(method_declaration
+  (static_modifier) @static) @function
+
+; Methods without a visibility modifier are public.
+(([
+  (method_declaration
+    parameters: (formal_parameters) @parameters
+    return_type: (_)? @return_type)
+  (function_definition
+    parameters: (formal_parameters) @parameters
+    return_type: (_)? @return_type)
+] @function)
+  (#set! visibility "public"))
+
+; Parameter names do not include the `$` sigil.
+(simple_parameter
+  type: (_)? @parameter.type
+  name: (variable_name
+    (name) @parameter.name)) @parameter
+
+((variadic_parameter
+  type: (_)? @parameter.type
+  name: (variable_name
+    (name) @parameter.name)) @parameter
+  (#set! variadic "true"))
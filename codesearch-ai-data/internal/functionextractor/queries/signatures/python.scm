@@ -0,0 +1,65 @@
+; Methods take the name of the enclosing class.
+(class_definition
+  name: (identifier) @receiver
+  body: (block
+    [
+      (function_definition) @function
+      (decorated_definition
+        definition: (function_definition) @function)
+    ]))
+
+(decorated_definition
+  (decorator
+    (identifier) @static
+    (#eq? @static "staticmethod"))
+  definition: (function_definition) @function)
+
+(function_definition
+  "async" @async) @function
+
+; Names starting with an underscore are private by convention, dunder methods are public.
+((function_definition
+  name: (identifier) @name) @function
+  (#match? @name "^_")
+  (#not-match? @name "^__.*__$")
+  (#set! visibility "private"))
+
+((function_definition
+  parameters: (parameters) @parameters
+  return_type: (_)? @return_type) @function
+  (#set! visibility "public"))
+
+(parameters
+  (identifier) @parameter.name @parameter)
+
+(parameters
+  [
+    (default_parameter
+      name: (identifier) @parameter.name)
+    (typed_default_parameter
+      name: (identifier) @parameter.name
+      type: (_) @parameter.type)
+  ] @parameter)
+
+(parameters
+  (typed_parameter
+    .
+    (identifier) @parameter.name
+    type: (_) @parameter.type) @parameter)
+
+((parameters
+  [
+    (list_splat_pattern
+      (identifier) @parameter.name)
+    (dictionary_splat_pattern
+      (identifier) @parameter.name)
+    (typed_parameter
+      [
+        (list_splat_pattern
+          (identifier) @parameter.name)
+        (dictionary_splat_pattern
+          (identifier) @parameter.name)
+      ]
+      type: (_) @parameter.type)
+  ] @parameter)
+  (#set! variadic "true"))
@@ -0,0 +1,35 @@
+; Methods take the name of the enclosing class or module. Their visibility is set by the closest preceding
+; `public`, `protected` or `private` section.
+(([
+  (class
+    name: [(constant) (scope_resolution)] @receiver
+    (method) @function)
+  (module
+    name: [(constant) (scope_resolution)] @receiver
+    (method) @function)
+])
+  (#set! visibility-from-sibling "identifier")
+  (#set! visibility "public"))
+
+(method
+  parameters: (method_parameters)? @parameters) @function
+
+(method_parameters
+  [
+    (identifier) @parameter.name
+    (optional_parameter
+      name: (identifier) @parameter.name)
+    (keyword_parameter
+      name: (identifier) @parameter.name)
+    (block_parameter
+      name: (identifier) @parameter.name)
+  ] @parameter)
+
+((method_parameters
+  [
+    (splat_parameter
+      name: (identifier) @parameter.name)
+    (hash_splat_parameter
+      name: (identifier) @parameter.name)
+  ] @parameter)
+  (#set! variadic "true"))
@@ -0,0 +1,53 @@
+; Functions inside `impl` and `trait` blocks take the implemented type or the trait name as receiver.
+; Associated functions without a self parameter are static.
+(impl_item
+  type: [
+    (type_identifier) @receiver
+    (scoped_type_identifier) @receiver
+    (generic_type
+      type: (_) @receiver)
+  ]
+  body: (declaration_list
+    (function_item) @function))
+
+(trait_item
+  name: (type_identifier) @receiver
+  body: (declaration_list
+    (function_item) @function))
+
+(([
+  (impl_item
+    body: (declaration_list
+      (function_item
+        parameters: (parameters) @self) @function))
+  (trait_item
+    body: (declaration_list
+      (function_item
+        parameters: (parameters) @self) @function))
+])
+  (#not-match? @self "^\\(\\s*(&\\s*('\\w+\\s+)?)?(mut\\s+)?self\\b")
+  (#set! static "true"))
+
+(function_item
+  (function_modifiers
+    "async" @async)) @function
+
+; `pub` is public, restricted visibilities (`pub(crate)`, `pub(super)`, `pub(in path)`) are internal.
+((function_item
+  (visibility_modifier) @pub) @function
+  (#eq? @pub "pub")
+  (#set! visibility "public"))
+
+((function_item
+  (visibility_modifier)) @function
+  (#set! visibility "internal"))
+
+((function_item
+  parameters: (parameters) @parameters
+  return_type: (_)? @return_type) @function
+  (#set! visibility "private"))
+
+; Self parameters are covered by the receiver and static flag.
+(parameter
+  pattern: (_) @parameter.name
+  type: (_) @parameter.type) @parameter
@@ -0,0 +1,68 @@
+; Methods take the name of the enclosing class.
+([
+  (class_declaration
+    name: (type_identifier) @receiver
+    body: (class_body
+      (method_definition) @function))
+  (abstract_class_declaration
+    name: (type_identifier) @receiver
+    body: (class_body
+      (method_definition) @function))
+])
+
+(method_definition
+  (accessibility_modifier) @visibility) @function
+
+([
+  (method_definition
+    "static" @static)
+  (method_definition
+    "async" @async)
+  (function_declaration
+    "async" @async)
+  (generator_function_declaration
+    "async" @async)
+  (function
+    "async" @async)
+  (arrow_function
+    "async" @async)
+] @function)
+
+[
+  (method_definition
+    parameters: (formal_parameters) @parameters
+    return_type: (type_annotation (_) @return_type)?)
+  (function_declaration
+    parameters: (formal_parameters) @parameters
+    return_type: (type_annotation (_) @return_type)?)
+  (generator_function_declaration
+    parameters: (formal_parameters) @parameters
+    return_type: (type_annotation (_) @return_type)?)
+  (function
+    parameters: (formal_parameters) @parameters
+    return_type: (type_annotation (_) @return_type)?)
+  (arrow_function
+    parameters: (formal_parameters) @parameters
+    return_type: (type_annotation (_) @return_type)?)
+] @function
+
+; Arrow functions with a single parameter do not have a parameter list.
+(arrow_function
+  parameter: (identifier) @parameter.name @parameter) @function @parameters
+
+(formal_parameters
+  [
+    (required_parameter
+      [(identifier) (this) (object_pattern) (array_pattern)] @parameter.name
+      (type_annotation (_) @parameter.type)?)
+    (optional_parameter
+      [(identifier) (this) (object_pattern) (array_pattern)] @parameter.name
+      (type_annotation (_) @parameter.type)?)
+  ] @parameter)
+
+((formal_parameters
+  (required_parameter
+    (rest_pattern
+      (_) @parameter.name)
+    (type_annotation (_) @parameter.type)?) @parameter)
+  (#set! variadic "true"))
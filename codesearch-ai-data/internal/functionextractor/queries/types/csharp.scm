@@ -0,0 +1,30 @@
+((class_declaration
+  name: (identifier) @name) @type
+  (#set! kind "class"))
+
+((struct_declaration
+  name: (identifier) @name) @type
+  (#set! kind "struct"))
+
+((interface_declaration
+  name: (identifier) @name) @type
+  (#set! kind "interface"))
+
+((enum_declaration
+  name: (identifier) @name) @type
+  (#set! kind "enum"))
+
+; Members are attached to their closest enclosing type. Constructors are skipped.
+(method_declaration
+  name: (identifier) @member)
+
+(property_declaration
+  name: (identifier) @member)
+
+(field_declaration
+  (variable_declaration
+    (variable_declarator
+      (identifier) @member)))
+
+(enum_member_declaration
+  name: (identifier) @member)
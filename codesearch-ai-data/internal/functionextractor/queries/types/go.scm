@@ -0,0 +1,55 @@
+; Doc comments precede the type declaration, unless it groups several type specs.
+((type_declaration
+  .
+  (type_spec
+    name: (type_identifier) @name
+    type: (struct_type)) @type
+  .) @doc
+  (#set! kind "struct"))
+
+((type_declaration
+  .
+  (type_spec
+    name: (type_identifier) @name
+    type: (interface_type)) @type
+  .) @doc
+  (#set! kind "interface"))
+
+((type_declaration
+  .
+  (type_spec
+    name: (type_identifier) @name) @type
+  .) @doc
+  (#set! kind "type"))
+
+((type_spec
+  name: (type_identifier) @name
+  type: (struct_type)) @type
+  (#set! kind "struct"))
+
+((type_spec
+  name: (type_identifier) @name
+  type: (interface_type)) @type
+  (#set! kind "interface"))
+
+((type_spec
+  name: (type_identifier) @name) @type
+  (#set! kind "type"))
+
+; Struct fields and interface methods are attached to their enclosing type.
+(field_declaration
+  name: (field_identifier) @member)
+
+(method_spec
+  name: (field_identifier) @member)
+
+; Methods are attached to their receiver type declared in the same file.
+(method_declaration
+  receiver: (parameter_list
+    (parameter_declaration
+      type: [
+        (type_identifier) @receiver
+        (pointer_type
+          (type_identifier) @receiver)
+      ]))
+  name: (field_identifier) @member)
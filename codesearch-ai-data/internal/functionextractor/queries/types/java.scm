@@ -0,0 +1,22 @@
+((class_declaration
+  name: (identifier) @name) @type
+  (#set! kind "class"))
+
+((interface_declaration
+  name: (identifier) @name) @type
+  (#set! kind "interface"))
+
+((enum_declaration
+  name: (identifier) @name) @type
+  (#set! kind "enum"))
+
+; Members are attached to their closest enclosing type. Constructors are skipped.
+(method_declaration
+  name: (identifier) @member)
+
+(field_declaration
+  declarator: (variable_declarator
+    name: (identifier) @member))
+
+(enum_constant
+  name: (identifier) @member)
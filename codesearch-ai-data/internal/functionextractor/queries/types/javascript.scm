@@ -0,0 +1,16 @@
+; Doc comments of exported classes precede the export statement.
+((export_statement
+  declaration: (class_declaration
+    name: (identifier) @name) @type) @doc
+  (#set! kind "class"))
+
+((class_declaration
+  name: (identifier) @name) @type
+  (#set! kind "class"))
+
+; Members are attached to their closest enclosing class.
+(method_definition
+  name: (_) @member)
+
+(public_field_definition
+  property: (_) @member)
@@ -0,0 +1,41 @@
+((class_declaration
+  "interface"
+  (type_identifier) @name) @type
+  (#set! kind "interface"))
+
+((class_declaration
+  (modifiers
+    (class_modifier) @modifier)
+  (type_identifier) @name) @type
+  (#eq? @modifier "enum")
+  (#set! kind "enum"))
+
+((class_declaration
+  "enum"
+  (type_identifier) @name) @type
+  (#set! kind "enum"))
+
+((class_declaration
+  (type_identifier) @name) @type
+  (#set! kind "class"))
+
+((object_declaration
+  (type_identifier) @name) @type
+  (#set! kind "object"))
+
+; Members are attached to their closest enclosing type. Constructors, and local functions and variables, are skipped.
+(class_body
+  [
+    (function_declaration
+      (simple_identifier) @member)
+    (property_declaration
+      (variable_declaration
+        (simple_identifier) @member))
+  ])
+
+(class_parameter
+  ["val" "var"]
+  (simple_identifier) @member)
+
+(enum_entry
+  (simple_identifier) @member)
@@ -0,0 +1,21 @@
+((class_definition
+  name: (identifier) @name) @type
+  (#set! kind "class"))
+
+; Methods and class attributes are attached to their closest enclosing class.
+(class_definition
+  body: (block
+    (function_definition
+      name: (identifier) @member)))
+
+(class_definition
+  body: (block
+    (decorated_definition
+      definition: (function_definition
+        name: (identifier) @member))))
+
+(class_definition
+  body: (block
+    (expression_statement
+      (assignment
+        left: (identifier) @member))))
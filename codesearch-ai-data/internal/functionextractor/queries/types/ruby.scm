@@ -0,0 +1,14 @@
+((module
+  name: (_) @name) @type
+  (#set! kind "module"))
+
+((class
+  name: (_) @name) @type
+  (#set! kind "class"))
+
+; Methods are attached to their closest enclosing module or class.
+(method
+  name: (_) @member)
+
+(singleton_method
+  name: (_) @member)
@@ -0,0 +1,205 @@
+package functionextractor
+
+import (
+	"codesearch-ai-data/internal/docstrings"
+	"codesearch-ai-data/internal/extractionpolicy"
+	"codesearch-ai-data/internal/languages"
+	ph "codesearch-ai-data/internal/parsinghelpers"
+	"fmt"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Function queries live in queries/functions/<language>.scm. Each pattern captures:
+//
+//	@function - the function node (required),
+//	@name     - the function name, the identifier is empty if missing,
+//	@scope    - the enclosing type, prepended to the identifier with the scope-separator property (default "."),
+//	@doc      - the node the docstring comment is attached to, defaults to @function,
+//	@body     - the function body, used to require one (e.g. to skip abstract methods).
+//
+// Functions are skipped by name with the excluded identifiers of the extraction policy, not in the queries.
+type QueryFunctionExtractor struct {
+	*functionExtractor
+	query          *extractionQuery
+	signatureQuery *signatureQuery
+	scopeQuery     *scopeQuery
+	roleQuery      *roleQuery
+	metricsQuery   *metricsQuery
+	callQuery      *callQuery
+}
+
+// NewQueryFunctionExtractor returns the extractor of the functions of files with the extension. Functions named after
+// an excluded identifier of the rules, or outside of their line range, are skipped.
+func NewQueryFunctionExtractor(languageName string, fileExtension string, rules *extractionpolicy.Rules) (*QueryFunctionExtractor, error) {
+	language := languages.ByName(languageName)
+	if language == nil {
+		return nil, fmt.Errorf("unknown language: %s", languageName)
+	}
+
+	query, err := getExtractionQuery("functions", language, fileExtension)
+	if err != nil {
+		return nil, err
+	}
+
+	// Signatures are optional, functions of languages without a signature query have an empty signature.
+	var functionSignatureQuery *signatureQuery
+	signatureExtractionQuery, err := getOptionalExtractionQuery("signatures", language, fileExtension)
+	if err != nil {
+		return nil, err
+	} else if signatureExtractionQuery != nil {
+		functionSignatureQuery = &signatureQuery{signatureExtractionQuery}
+	}
+
+	// Functions of languages without a scope query are qualified by their name only.
+	var functionScopeQuery *scopeQuery
+	scopeExtractionQuery, err := getOptionalExtractionQuery("scopes", language, fileExtension)
+	if err != nil {
+		return nil, err
+	} else if scopeExtractionQuery != nil {
+		functionScopeQuery = &scopeQuery{scopeExtractionQuery}
+	}
+
+	// Functions of languages without a role query are classified by the path of their file only.
+	var functionRoleQuery *roleQuery
+	roleExtractionQuery, err := getOptionalExtractionQuery("roles", language, fileExtension)
+	if err != nil {
+		return nil, err
+	} else if roleExtractionQuery != nil {
+		functionRoleQuery = &roleQuery{roleExtractionQuery}
+	}
+
+	// Functions of languages without a metrics query only have size metrics.
+	var functionMetricsQuery *metricsQuery
+	metricsExtractionQuery, err := getOptionalExtractionQuery("metrics", language, fileExtension)
+	if err != nil {
+		return nil, err
+	} else if metricsExtractionQuery != nil {
+		functionMetricsQuery = &metricsQuery{metricsExtractionQuery}
+	}
+
+	// Functions of languages without a call query do not have calls.
+	var functionCallQuery *callQuery
+	callExtractionQuery, err := getOptionalExtractionQuery("calls", language, fileExtension)
+	if err != nil {
+		return nil, err
+	} else if callExtractionQuery != nil {
+		functionCallQuery = &callQuery{callExtractionQuery}
+	}
+
+	return &QueryFunctionExtractor{&functionExtractor{getParserPool(language, fileExtension), rules, language}, query, functionSignatureQuery, functionScopeQuery, functionRoleQuery, functionMetricsQuery, functionCallQuery}, nil
+}
+
+func (qfe *QueryFunctionExtractor) getIdentifier(match *queryMatch, code []byte) string {
+	identifier := qfe.query.getCaptureContent(match, "name", code)
+	if _, ok := match.captures["scope"]; ok {
+		scope := qfe.query.getCaptureContent(match, "scope", code)
+		identifier = scope + qfe.query.getProperty(match, "scope-separator", ".") + identifier
+	}
+	return identifier
+}
+
+// Extract extracts the functions of the code. If the code does not parse completely, the functions without syntax
+// errors are returned along with a *ParseError.
+func (qfe *QueryFunctionExtractor) Extract(code []byte) ([]*ExtractedFunction, error) {
+	tree := qfe.parsers.parse(code)
+	defer tree.Close()
+	return qfe.ExtractFromTree(tree, code)
+}
+
+func (qfe *QueryFunctionExtractor) ExtractFromTree(tree *sitter.Tree, code []byte) ([]*ExtractedFunction, error) {
+	rootNode := tree.RootNode()
+	parseErr := newParseError(rootNode)
+
+	signatures := map[*sitter.Node]*Signature{}
+	if qfe.signatureQuery != nil {
+		signatures = qfe.signatureQuery.getSignatures(rootNode, code)
+	}
+
+	scopes := &functionScopes{}
+	if qfe.scopeQuery != nil {
+		scopes = qfe.scopeQuery.getScopes(rootNode, code)
+	}
+
+	roles := functionRoles{}
+	if qfe.roleQuery != nil {
+		roles = qfe.roleQuery.getRoles(rootNode, code)
+	}
+
+	var metricsNodes *fileMetricsNodes
+	if qfe.metricsQuery != nil {
+		metricsNodes = qfe.metricsQuery.getMetricsNodes(rootNode, code)
+	}
+
+	extractedFunctions := []*ExtractedFunction{}
+	functionNodes := map[*sitter.Node]*ExtractedFunction{}
+	for _, match := range qfe.query.getWinningMatches(qfe.query.getMatches(rootNode), "function", code) {
+		node := match.captures["function"]
+		if node.HasError() {
+			continue
+		}
+		name := qfe.query.getCaptureContent(match, "name", code)
+		if qfe.rules.ExcludesIdentifier(name) {
+			continue
+		}
+		identifier := qfe.getIdentifier(match, code)
+
+		skipNodeFn, err := qfe.language.GetSkipNodeFn(node)
+		if err != nil {
+			return nil, err
+		}
+
+		filteredNodes, commentNodes := ph.StripComments(node, skipNodeFn)
+		inlineComments := ph.StripCommentNodesDelimiters(commentNodes, code)
+		prettyFormattedCode := ph.PrettyFormatNodes(filteredNodes, code)
+
+		if !isRightSize(prettyFormattedCode, qfe.rules.MinFunctionLines, qfe.rules.MaxFunctionLines) {
+			continue
+		}
+
+		docstringNode, ok := match.captures["doc"]
+		if !ok {
+			docstringNode = node
+		}
+		docstring := docstrings.Parse(qfe.language.GetDocstring(docstringNode, identifier, code), qfe.language.Name)
+
+		extractedFunction := NewExtractedFunction(
+			identifier,
+			prettyFormattedCode,
+			inlineComments,
+			docstring,
+			node,
+			code,
+		)
+		extractedFunction.QualifiedIdentifier = scopes.qualify(node, name)
+		if signature, ok := signatures[node]; ok {
+			extractedFunction.Signature = *signature
+		}
+		extractedFunction.codeRole = roles.forFunction(node)
+		extractedFunction.Metrics = Metrics{
+			TokenCount:     len(filteredNodes),
+			ParameterCount: len(extractedFunction.Signature.Parameters),
+			CommentRatio:   getCommentRatio(commentNodes, prettyFormattedCode),
+		}
+		if metricsNodes != nil {
+			extractedFunction.Metrics.CyclomaticComplexity, extractedFunction.Metrics.MaxNestingDepth = metricsNodes.getComplexity(node)
+		}
+		extractedFunctions = append(extractedFunctions, extractedFunction)
+		functionNodes[node] = extractedFunction
+	}
+
+	if qfe.callQuery != nil {
+		isFunctionNode := make(map[*sitter.Node]bool, len(functionNodes))
+		for node := range functionNodes {
+			isFunctionNode[node] = true
+		}
+		for node, calls := range qfe.callQuery.getCalls(rootNode, isFunctionNode, code) {
+			functionNodes[node].Calls = calls
+		}
+	}
+
+	if parseErr != nil {
+		return extractedFunctions, parseErr
+	}
+	return extractedFunctions, nil
+}
@@ -0,0 +1,186 @@
+package functionextractor
+
+import (
+	"codesearch-ai-data/internal/docstrings"
+	"codesearch-ai-data/internal/extractionpolicy"
+	"codesearch-ai-data/internal/languages"
+	ph "codesearch-ai-data/internal/parsinghelpers"
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+type ExtractedType struct {
+	ID            int      `json:"-"`
+	Kind          string   `json:"kind"`
+	Identifier    string   `json:"identifier"`
+	Members       []string `json:"members"`
+	Code          string   `json:"code"`
+	CleanCode     string   `json:"cleanCode"`
+	CleanCodeHash string   `json:"cleanCodeHash"`
+	Docstring     string   `json:"docstring"`
+	// DocstringSummary is the first sentence of the docstring, with inline tags and markup resolved.
+	DocstringSummary string `json:"docstringSummary"`
+	StartLine        int    `json:"startLine"`
+	EndLine          int    `json:"endLine"`
+	IsTrain          bool   `json:"-"`
+}
+
+type TypeExtractor interface {
+	Extract(code []byte) ([]*ExtractedType, error)
+	// ExtractFromTree extracts the types of the code from its parsed tree, so the tree can be shared with the
+	// function extractor.
+	ExtractFromTree(tree *sitter.Tree, code []byte) ([]*ExtractedType, error)
+}
+
+// Type queries live in queries/types/<language>.scm. Type patterns capture:
+//
+//	@type - the type node (required), its kind is set with the kind property (e.g. (#set! kind "class")),
+//	@name - the type name,
+//	@doc  - the node the docstring comment is attached to, defaults to @type.
+//
+// Member patterns capture the member name as @member. Members are attached to their closest enclosing type,
+// or to the type named by the @receiver capture in the same file (e.g. Go methods).
+type QueryTypeExtractor struct {
+	parsers  *parserPool
+	rules    *extractionpolicy.Rules
+	language *languages.Language
+	query    *extractionQuery
+}
+
+func NewQueryTypeExtractor(languageName string, fileExtension string, rules *extractionpolicy.Rules) (*QueryTypeExtractor, error) {
+	language := languages.ByName(languageName)
+	if language == nil {
+		return nil, fmt.Errorf("unknown language: %s", languageName)
+	}
+
+	query, err := getExtractionQuery("types", language, fileExtension)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryTypeExtractor{getParserPool(language, fileExtension), rules, language, query}, nil
+}
+
+func getTypeExtractorForFile(filePath string, policy *extractionpolicy.Policy) TypeExtractor {
+	language := languages.ForFile(filePath)
+	if language == nil {
+		return nil
+	}
+
+	typeExtractor, err := getCachedTypeExtractor(language, languages.FileExtension(filePath), policy)
+	if err != nil {
+		if !errors.Is(err, errNoExtractionQuery) {
+			log.Debugf("No type extractor for %s: %s", filePath, err)
+		}
+		return nil
+	}
+	return typeExtractor
+}
+
+func appendMember(members []string, member string) []string {
+	if member == "" || contains(members, member) {
+		return members
+	}
+	return append(members, member)
+}
+
+// getEnclosingType returns the closest type node containing the node.
+func getEnclosingType(node *sitter.Node, typeNodes map[*sitter.Node]*ExtractedType) *ExtractedType {
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		if extractedType, ok := typeNodes[parent]; ok {
+			return extractedType
+		}
+	}
+	return nil
+}
+
+// Extract extracts the types of the code. If the code does not parse completely, the types without syntax errors
+// are returned along with a *ParseError.
+func (qte *QueryTypeExtractor) Extract(code []byte) ([]*ExtractedType, error) {
+	tree := qte.parsers.parse(code)
+	defer tree.Close()
+	return qte.ExtractFromTree(tree, code)
+}
+
+func (qte *QueryTypeExtractor) ExtractFromTree(tree *sitter.Tree, code []byte) ([]*ExtractedType, error) {
+	rootNode := tree.RootNode()
+	parseErr := newParseError(rootNode)
+
+	matches := qte.query.getMatches(rootNode)
+
+	extractedTypes := []*ExtractedType{}
+	nodes := []*sitter.Node{}
+	typeNodes := map[*sitter.Node]*ExtractedType{}
+	for _, match := range qte.query.getWinningMatches(matches, "type", code) {
+		node := match.captures["type"]
+		if node.HasError() {
+			continue
+		}
+
+		docstringNode, ok := match.captures["doc"]
+		if !ok {
+			docstringNode = node
+		}
+		identifier := qte.query.getCaptureContent(match, "name", code)
+		docstring := docstrings.Parse(qte.language.GetDocstring(docstringNode, identifier, code), qte.language.Name)
+
+		extractedType := &ExtractedType{
+			Kind:             qte.query.getProperty(match, "kind", "type"),
+			Identifier:       identifier,
+			Members:          []string{},
+			Code:             node.Content(code),
+			Docstring:        docstring.Text,
+			DocstringSummary: docstring.Summary,
+			StartLine:        int(node.StartPoint().Row),
+			EndLine:          int(node.EndPoint().Row),
+		}
+		extractedTypes = append(extractedTypes, extractedType)
+		nodes = append(nodes, node)
+		typeNodes[node] = extractedType
+	}
+
+	for _, match := range matches {
+		memberNode, ok := match.captures["member"]
+		if !ok || !qte.query.matchesPredicates(match, code) {
+			continue
+		}
+
+		member := memberNode.Content(code)
+		if receiver := qte.query.getCaptureContent(match, "receiver", code); receiver != "" {
+			for _, extractedType := range extractedTypes {
+				if extractedType.Identifier == receiver {
+					extractedType.Members = appendMember(extractedType.Members, member)
+				}
+			}
+		} else if extractedType := getEnclosingType(memberNode, typeNodes); extractedType != nil {
+			extractedType.Members = appendMember(extractedType.Members, member)
+		}
+	}
+
+	filteredTypes := make([]*ExtractedType, 0, len(extractedTypes))
+	for i, extractedType := range extractedTypes {
+		node := nodes[i]
+		skipNodeFn, err := qte.language.GetSkipNodeFn(node)
+		if err != nil {
+			return nil, err
+		}
+
+		filteredNodes, _ := ph.StripComments(node, skipNodeFn)
+		prettyFormattedCode := ph.PrettyFormatNodes(filteredNodes, code)
+		if !isRightSize(prettyFormattedCode, qte.rules.MinTypeLines, qte.rules.MaxTypeLines) {
+			continue
+		}
+
+		extractedType.CleanCode = prettyFormattedCode
+		extractedType.CleanCodeHash = getSHA1Hash(prettyFormattedCode)
+		filteredTypes = append(filteredTypes, extractedType)
+	}
+
+	if parseErr != nil {
+		return filteredTypes, parseErr
+	}
+	return filteredTypes, nil
+}
@@ -0,0 +1,157 @@
+package functionextractor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Repo jobs track the extraction of the repos of a repo list, so an interrupted list can be resumed.
+const (
+	RepoJobPending   = "pending"
+	RepoJobRunning   = "running"
+	RepoJobSucceeded = "succeeded"
+	RepoJobFailed    = "failed"
+)
+
+type RepoJob struct {
+	RepoName string
+	// Status is pending, running, succeeded or failed. Jobs of interrupted runs are left running.
+	Status string
+	// Attempts is the number of times the extraction of the repo was started.
+	Attempts int
+	// Error is the error of the last failed attempt.
+	Error    string
+	Duration time.Duration
+	// ExtractedFunctions is the number of functions in the snapshots of the repo when the last attempt finished.
+	ExtractedFunctions int
+}
+
+// EnqueueRepoJobs adds a pending job for each repo. Repos with a job keep it if keepExisting is set, and their job
+// is reset otherwise. With keepExisting, the jobs of repos that are already in the database have succeeded, unless the
+// repo is the partial repo of an attempt that did not finish, so repos extracted before their jobs were tracked, or by
+// a run without resume, are not extracted again.
+func EnqueueRepoJobs(ctx context.Context, conn *pgx.Conn, repoNames []string, keepExisting bool) error {
+	if len(repoNames) == 0 {
+		return nil
+	}
+
+	onConflict := "DO UPDATE SET status = EXCLUDED.status, attempts = 0, error = '', started_at = NULL, duration_ms = 0, extracted_functions = 0"
+	if keepExisting {
+		onConflict = "DO NOTHING"
+	}
+	_, err := conn.Exec(
+		ctx,
+		"INSERT INTO repo_jobs (repo_name, status) SELECT unnest($1::text[]), $2 ON CONFLICT (repo_name) "+onConflict,
+		repoNames,
+		RepoJobPending,
+	)
+	if err != nil || !keepExisting {
+		return err
+	}
+
+	_, err = conn.Exec(
+		ctx,
+		`UPDATE repo_jobs j SET status = $2, error = ''
+FROM repos r
+WHERE r.name = j.repo_name AND j.repo_name = ANY($1) AND j.status <> $2 AND j.repo_id IS DISTINCT FROM r.id`,
+		repoNames,
+		RepoJobSucceeded,
+	)
+	return err
+}
+
+// GetRepoJob returns nil if the repo does not have a job.
+func GetRepoJob(ctx context.Context, conn *pgx.Conn, repoName string) (*RepoJob, error) {
+	job := &RepoJob{RepoName: repoName}
+	var durationMs int64
+	err := conn.QueryRow(
+		ctx,
+		"SELECT status, attempts, error, duration_ms, extracted_functions FROM repo_jobs WHERE repo_name = $1",
+		repoName,
+	).Scan(&job.Status, &job.Attempts, &job.Error, &durationMs, &job.ExtractedFunctions)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	job.Duration = time.Duration(durationMs) * time.Millisecond
+	return job, nil
+}
+
+// StartRepoJob starts a new attempt of the job of the repo, and returns the number of attempts so far. The repo
+// inserted by a previous attempt that did not succeed is deleted first, so the extraction starts over instead of
+// failing on the partially extracted repo. Repos that the attempts did not insert are kept.
+func StartRepoJob(ctx context.Context, conn *pgx.Conn, repoName string) (int, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
+		"DELETE FROM repos WHERE id = (SELECT repo_id FROM repo_jobs WHERE repo_name = $1 AND status <> $2)",
+		repoName,
+		RepoJobSucceeded,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var attempts int
+	err = tx.QueryRow(
+		ctx,
+		`INSERT INTO repo_jobs (repo_name, status, attempts, started_at) VALUES ($1, $2, 1, now())
+ON CONFLICT (repo_name) DO UPDATE SET status = EXCLUDED.status, attempts = repo_jobs.attempts + 1, error = '', started_at = EXCLUDED.started_at
+RETURNING attempts`,
+		repoName,
+		RepoJobRunning,
+	).Scan(&attempts)
+	if err != nil {
+		return 0, err
+	}
+	return attempts, tx.Commit(ctx)
+}
+
+// recordRepoJobRepo records the repo inserted by the running attempt of the job of the repo, so that the next attempt
+// deletes it if this one does not succeed.
+func recordRepoJobRepo(ctx context.Context, conn *pgx.Conn, repoName string, repoID int) error {
+	_, err := conn.Exec(ctx, "UPDATE repo_jobs SET repo_id = $2 WHERE repo_name = $1 AND status = $3", repoName, repoID, RepoJobRunning)
+	return err
+}
+
+// releaseRepoJobRepo keeps the repo from being deleted by the next attempt of a job that did not finish, once the repo
+// was extracted outside of the attempts of the job.
+func releaseRepoJobRepo(ctx context.Context, conn *pgx.Conn, repoID int) error {
+	_, err := conn.Exec(ctx, "UPDATE repo_jobs SET repo_id = NULL WHERE repo_id = $1 AND status <> $2", repoID, RepoJobRunning)
+	return err
+}
+
+const finishRepoJobQuery = `
+UPDATE repo_jobs SET
+	status = $2,
+	error = $3,
+	duration_ms = $4,
+	repo_id = CASE WHEN $2 = $5 THEN NULL ELSE repo_id END,
+	extracted_functions = (
+		SELECT COUNT(DISTINCT efs.extracted_function_id)
+		FROM extracted_function_snapshots efs
+		JOIN repo_snapshots s ON s.id = efs.snapshot_id
+		JOIN repos r ON r.id = s.repo_id
+		WHERE r.name = $1
+	)
+WHERE repo_name = $1`
+
+// FinishRepoJob records the outcome of the running attempt of the job of the repo. The job failed if jobErr is set,
+// and the repo inserted by its attempts is kept once it succeeded.
+func FinishRepoJob(ctx context.Context, conn *pgx.Conn, repoName string, duration time.Duration, jobErr error) error {
+	status, errorMessage := RepoJobSucceeded, ""
+	if jobErr != nil {
+		status, errorMessage = RepoJobFailed, jobErr.Error()
+	}
+	_, err := conn.Exec(ctx, finishRepoJobQuery, repoName, status, errorMessage, duration.Milliseconds(), RepoJobSucceeded)
+	return err
+}
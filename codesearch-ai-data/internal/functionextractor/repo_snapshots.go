@@ -0,0 +1,99 @@
+package functionextractor
+
+import (
+	"codesearch-ai-data/internal/extractionpolicy"
+	"codesearch-ai-data/internal/githelpers"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ProcessRepoSnapshots extracts the refs of a remote repo (e.g. release tags) as additional snapshots. The tracked
+// snapshot of the repo is left untouched.
+func ProcessRepoSnapshots(ctx context.Context, conn *pgx.Conn, repoName string, refs []string, nFileWorkers int, policy *extractionpolicy.Policy) error {
+	repoPath, cleanup, err := cloneRepo(repoName)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	for _, ref := range refs {
+		// The clone is shallow, fetch each ref separately.
+		err = githelpers.FetchRefWithTimeout(repoPath, ref, 300)
+		if err != nil {
+			return fmt.Errorf("fetching ref %s of repo %s: %w", ref, repoName, err)
+		}
+
+		commitID, err := githelpers.ResolveRepoRef(repoPath, "FETCH_HEAD")
+		if err != nil {
+			return err
+		}
+
+		err = extractRepoSnapshot(ctx, conn, repoName, repoPath, ref, commitID, nFileWorkers, policy)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ProcessLocalRepoSnapshots extracts the refs of a repo that is already on disk as additional snapshots.
+func ProcessLocalRepoSnapshots(ctx context.Context, conn *pgx.Conn, repoName string, repoPath string, refs []string, nFileWorkers int, policy *extractionpolicy.Policy) error {
+	repoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		commitID, err := githelpers.ResolveRepoRef(repoPath, ref)
+		if err != nil {
+			return err
+		}
+
+		err = extractRepoSnapshot(ctx, conn, repoName, repoPath, ref, commitID, nFileWorkers, policy)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractRepoSnapshot extracts the commit as the snapshot of the ref. Repos extracted for the first time are
+// inserted without a tracked commit. Functions that were already extracted keep their IDs and docstrings, the
+// docstrings of the snapshot are stored with its occurrences.
+func extractRepoSnapshot(ctx context.Context, conn *pgx.Conn, repoName string, gitPath string, ref string, commitID string, nFileWorkers int, policy *extractionpolicy.Policy) error {
+	if ref == TRACKED_SNAPSHOT_REF {
+		return errors.New("snapshot ref cannot be empty")
+	}
+
+	repoID, _, exists, err := getTrackedRepo(ctx, conn, repoName)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		snapshotExists, err := repoSnapshotExists(ctx, conn, repoID, ref)
+		if err != nil {
+			return err
+		}
+		if snapshotExists {
+			return fmt.Errorf("snapshot %s of repo %s already exists", ref, repoName)
+		}
+	}
+
+	exportPath, err := exportRepoTree(repoName, gitPath, commitID)
+	if err != nil {
+		return err
+	}
+	// Clean up exported repo.
+	defer func() { os.RemoveAll(exportPath) }()
+
+	tree := newRepoTree(repoName, exportPath, nFileWorkers, policy)
+	return tree.extractSnapshot(ctx, newPostgresSink(conn, true), ref, commitID)
+}
@@ -0,0 +1,78 @@
+package functionextractor
+
+import (
+	"codesearch-ai-data/internal/coderoles"
+	"codesearch-ai-data/internal/languages"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Role queries live in queries/roles/<language>.scm. They classify functions from test framework cues in the code:
+//
+//	@role - the function, or a node enclosing functions (e.g. a test class or a describe block).
+//
+// Patterns set the role property to test, benchmark or example. Patterns relying on naming conventions only (e.g. Go
+// TestXxx functions) set the test-file-only property, they do not apply to functions in production files. When several
+// patterns match the same node, the first pattern whose predicates hold wins. Functions take the role of the innermost
+// classified node enclosing them, and the role implied by the path of their file otherwise.
+type roleQuery struct {
+	query *extractionQuery
+}
+
+// codeRole is the role of a function according to its code, if any.
+type codeRole struct {
+	role         coderoles.Role
+	testFileOnly bool
+}
+
+type functionRoles map[*sitter.Node]codeRole
+
+func (rq *roleQuery) getRoles(rootNode *sitter.Node, code []byte) functionRoles {
+	// Unlike entities, nodes fall back to the next matching pattern when the predicates of a pattern fail.
+	nodeMatches := map[*sitter.Node]*queryMatch{}
+	for _, match := range rq.query.getMatches(rootNode) {
+		node, ok := match.captures["role"]
+		if !ok || !rq.query.matchesPredicates(match, code) {
+			continue
+		}
+		if existingMatch, ok := nodeMatches[node]; ok && existingMatch.patternIndex <= match.patternIndex {
+			continue
+		}
+		nodeMatches[node] = match
+	}
+
+	roles := functionRoles{}
+	for node, match := range nodeMatches {
+		role := coderoles.Role(rq.query.getProperty(match, "role", ""))
+		if !role.IsValid() {
+			continue
+		}
+		roles[node] = codeRole{role, rq.query.getProperty(match, "test-file-only", "") == "true"}
+	}
+	return roles
+}
+
+// forFunction returns the role of the innermost classified node enclosing the function.
+func (fr functionRoles) forFunction(functionNode *sitter.Node) codeRole {
+	for node := functionNode; node != nil; node = node.Parent() {
+		if role, ok := fr[node]; ok {
+			return role
+		}
+	}
+	return codeRole{}
+}
+
+// assignFunctionRoles sets the role of the functions from the path of their file, unless their code tells otherwise.
+func assignFunctionRoles(filePath string, extractedFunctions []*ExtractedFunction) {
+	if languages.ForFile(filePath) == nil {
+		return
+	}
+	pathRole := coderoles.ForPath(filePath)
+	for _, extractedFunction := range extractedFunctions {
+		extractedFunction.Role = pathRole
+		codeRole := extractedFunction.codeRole
+		if codeRole.role != "" && (!codeRole.testFileOnly || pathRole != coderoles.Production) {
+			extractedFunction.Role = codeRole.role
+		}
+	}
+}
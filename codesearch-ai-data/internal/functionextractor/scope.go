@@ -0,0 +1,103 @@
+package functionextractor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Scope queries live in queries/scopes/<language>.scm. They qualify function names with the enclosing scopes:
+//
+//	@scope      - a scope node enclosing the function (e.g. a class), or the function itself (e.g. a Go method receiver),
+//	@file_scope - a scope applying to the functions following it in the file (e.g. a Go package clause),
+//	@name       - the scope name.
+//
+// The scope name is formatted with the format property (default "%s"). It is followed by the separator property
+// (default ".") when followed by another scope, and by the member-separator property (defaults to separator) when
+// followed by the function name. When several patterns match the same scope node, the first pattern wins.
+type scopeQuery struct {
+	query *extractionQuery
+}
+
+type scope struct {
+	name            string
+	separator       string
+	memberSeparator string
+}
+
+type fileScope struct {
+	scope
+	startByte uint32
+}
+
+// functionScopes holds the scopes of a file.
+type functionScopes struct {
+	scopes     map[*sitter.Node]scope
+	fileScopes []fileScope
+}
+
+func (sq *scopeQuery) newScope(match *queryMatch, code []byte) scope {
+	separator := sq.query.getProperty(match, "separator", ".")
+	return scope{
+		name:            fmt.Sprintf(sq.query.getProperty(match, "format", "%s"), normalizeSignatureText(sq.query.getCaptureContent(match, "name", code))),
+		separator:       separator,
+		memberSeparator: sq.query.getProperty(match, "member-separator", separator),
+	}
+}
+
+func (sq *scopeQuery) getScopes(rootNode *sitter.Node, code []byte) *functionScopes {
+	matches := sq.query.getMatches(rootNode)
+
+	scopes := map[*sitter.Node]scope{}
+	for _, match := range sq.query.getWinningMatches(matches, "scope", code) {
+		scopes[match.captures["scope"]] = sq.newScope(match, code)
+	}
+
+	fileScopes := []fileScope{}
+	for _, match := range sq.query.getWinningMatches(matches, "file_scope", code) {
+		fileScopes = append(fileScopes, fileScope{sq.newScope(match, code), match.captures["file_scope"].StartByte()})
+	}
+	sort.Slice(fileScopes, func(i, j int) bool { return fileScopes[i].startByte < fileScopes[j].startByte })
+
+	return &functionScopes{scopes, fileScopes}
+}
+
+// qualify returns the function name prefixed by its scopes, from the outermost to the innermost.
+func (fs *functionScopes) qualify(functionNode *sitter.Node, name string) string {
+	if name == "" {
+		return ""
+	}
+
+	enclosingScopes := []scope{}
+	for node := functionNode; node != nil; node = node.Parent() {
+		if s, ok := fs.scopes[node]; ok {
+			enclosingScopes = append([]scope{s}, enclosingScopes...)
+		}
+	}
+
+	// The closest file scope preceding the function applies, e.g. PHP namespaces declared without a block.
+	for i := len(fs.fileScopes) - 1; i >= 0; i-- {
+		if fs.fileScopes[i].startByte <= functionNode.StartByte() {
+			enclosingScopes = append([]scope{fs.fileScopes[i].scope}, enclosingScopes...)
+			break
+		}
+	}
+
+	return qualifyName(enclosingScopes, name)
+}
+
+func qualifyName(scopes []scope, name string) string {
+	var sb strings.Builder
+	for i, s := range scopes {
+		sb.WriteString(s.name)
+		if i < len(scopes)-1 {
+			sb.WriteString(s.separator)
+		} else {
+			sb.WriteString(s.memberSeparator)
+		}
+	}
+	sb.WriteString(name)
+	return sb.String()
+}
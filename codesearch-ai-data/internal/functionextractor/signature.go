@@ -0,0 +1,230 @@
+package functionextractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+type Parameter struct {
+	Name       string `json:"name"`
+	Type       string `json:"type,omitempty"`
+	IsVariadic bool   `json:"isVariadic,omitempty"`
+}
+
+// Visibilities are the normalized visibilities set by the signature queries.
+var Visibilities = []string{"public", "protected", "internal", "package", "private"}
+
+func IsKnownVisibility(visibility string) bool {
+	return contains(Visibilities, visibility)
+}
+
+// Signature is the language independent signature of an extracted function.
+type Signature struct {
+	Receiver   string      `json:"receiver,omitempty"`
+	Parameters []Parameter `json:"parameters,omitempty"`
+	ReturnType string      `json:"returnType,omitempty"`
+	Visibility string      `json:"visibility,omitempty"`
+	IsStatic   bool        `json:"isStatic,omitempty"`
+	IsAsync    bool        `json:"isAsync,omitempty"`
+}
+
+// Signature queries live in queries/signatures/<language>.scm. Unlike function queries, the captures and properties
+// of all the patterns matching a function are merged, the first pattern in the file wins for each of them.
+// Function patterns capture:
+//
+//	@function    - the function node, the same node captured by the function query (required),
+//	@receiver    - the receiver or enclosing type name,
+//	@parameters  - the parameter list node,
+//	@return_type - the return type, followed by the return-type-suffix property if set (e.g. C pointers),
+//	@visibility  - a node whose text is the visibility, defaults to the visibility keyword of the closest preceding
+//	               sibling with the visibility-from-sibling property type, and then to the visibility property,
+//	@static      - a node that marks the function as static, or the static property set to "true",
+//	@async       - a node that marks the function as async, or the async property set to "true".
+//
+// Parameter patterns capture the parameter node as @parameter, its name as @parameter.name and its type
+// as @parameter.type. When the type is split around the name (e.g. C `char *name`), the type-from property set to
+// "parameter" uses the parameter text without the name, and without the default value captured as @parameter.default.
+// Variadic parameters set the variadic property to "true". Parameters belong to the function whose @parameters node
+// is their parent.
+type signatureQuery struct {
+	query *extractionQuery
+}
+
+func normalizeSignatureText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func getMergedCaptureContent(merged *mergedMatch, capture string, code []byte) string {
+	if node, ok := merged.captures[capture]; ok {
+		return normalizeSignatureText(node.Content(code))
+	}
+	return ""
+}
+
+func hasMergedFlag(merged *mergedMatch, flag string) bool {
+	_, ok := merged.captures[flag]
+	return ok || merged.properties[flag] == "true"
+}
+
+// getParameterTextWithoutName returns the parameter text without the name and the default value.
+func getParameterTextWithoutName(parameterNode *sitter.Node, nameNode *sitter.Node, defaultNode *sitter.Node, code []byte) string {
+	end := parameterNode.EndByte()
+	if defaultNode != nil {
+		end = defaultNode.StartByte()
+	}
+	text := string(code[parameterNode.StartByte():end])
+	if nameNode != nil {
+		text = string(code[parameterNode.StartByte():nameNode.StartByte()]) + string(code[nameNode.EndByte():end])
+	}
+	return strings.TrimRight(text, " \t\n=")
+}
+
+var visibilityKeywords = []string{"public", "protected", "private"}
+
+// getSiblingVisibility returns the visibility keyword of the closest preceding sibling with the node type,
+// e.g. C++ access specifiers or Ruby `private` sections.
+func getSiblingVisibility(node *sitter.Node, siblingType string, code []byte) string {
+	for sibling := node.PrevNamedSibling(); sibling != nil; sibling = sibling.PrevNamedSibling() {
+		if sibling.Type() != siblingType {
+			continue
+		}
+		visibility := strings.TrimSuffix(strings.TrimSpace(sibling.Content(code)), ":")
+		if contains(visibilityKeywords, visibility) {
+			return visibility
+		}
+	}
+	return ""
+}
+
+// getParameters returns the parameters grouped by their parameter list node. The first pattern wins for each
+// parameter name node, or parameter node for parameters without a name (e.g. Go `func(int)`).
+func (sq *signatureQuery) getParameters(matches []*queryMatch, code []byte) map[*sitter.Node][]Parameter {
+	nodeMatches := map[*sitter.Node]*queryMatch{}
+	for _, match := range matches {
+		parameterNode, ok := match.captures["parameter"]
+		if !ok || !sq.query.matchesPredicates(match, code) {
+			continue
+		}
+		if nameNode, ok := match.captures["parameter.name"]; ok {
+			parameterNode = nameNode
+		}
+		if existingMatch, ok := nodeMatches[parameterNode]; ok && existingMatch.patternIndex <= match.patternIndex {
+			continue
+		}
+		nodeMatches[parameterNode] = match
+	}
+
+	// Sort by the name node, parameters can share a parameter node (e.g. Go `a, b int`).
+	nodes := make([]*sitter.Node, 0, len(nodeMatches))
+	for node := range nodeMatches {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].StartByte() < nodes[j].StartByte() })
+
+	parameters := map[*sitter.Node][]Parameter{}
+	for _, node := range nodes {
+		match := nodeMatches[node]
+		parameterNode := match.captures["parameter"]
+		parameterType := sq.query.getCaptureContent(match, "parameter.type", code)
+		if sq.query.getProperty(match, "type-from", "") == "parameter" {
+			parameterType = getParameterTextWithoutName(parameterNode, match.captures["parameter.name"], match.captures["parameter.default"], code)
+		}
+
+		parameters[parameterNode.Parent()] = append(parameters[parameterNode.Parent()], Parameter{
+			Name:       normalizeSignatureText(sq.query.getCaptureContent(match, "parameter.name", code)),
+			Type:       normalizeSignatureText(parameterType),
+			IsVariadic: sq.query.getProperty(match, "variadic", "") == "true",
+		})
+	}
+	return parameters
+}
+
+// getSignatures returns the signatures of all the functions in the tree.
+func (sq *signatureQuery) getSignatures(rootNode *sitter.Node, code []byte) map[*sitter.Node]*Signature {
+	matches := sq.query.getMatches(rootNode)
+	parameters := sq.getParameters(matches, code)
+
+	signatures := map[*sitter.Node]*Signature{}
+	for functionNode, merged := range sq.query.mergeMatches(matches, "function", code) {
+		signature := &Signature{
+			Receiver:   getMergedCaptureContent(merged, "receiver", code),
+			Parameters: parameters[merged.captures["parameters"]],
+			ReturnType: getMergedCaptureContent(merged, "return_type", code),
+			Visibility: strings.ToLower(getMergedCaptureContent(merged, "visibility", code)),
+			IsStatic:   hasMergedFlag(merged, "static"),
+			IsAsync:    hasMergedFlag(merged, "async"),
+		}
+		if suffix, ok := merged.properties["return-type-suffix"]; ok && signature.ReturnType != "" {
+			signature.ReturnType += " " + suffix
+		}
+		if siblingType, ok := merged.properties["visibility-from-sibling"]; ok && signature.Visibility == "" {
+			signature.Visibility = getSiblingVisibility(functionNode, siblingType, code)
+		}
+		if signature.Visibility == "" {
+			signature.Visibility = merged.properties["visibility"]
+		}
+		signatures[functionNode] = signature
+	}
+	return signatures
+}
+
+// Format returns a language independent signature line, e.g. `public static Repository.groupBy(items: List<T>): Map<K, T>`.
+func (s *Signature) Format(identifier string) string {
+	parts := []string{}
+	if s.Visibility != "" {
+		parts = append(parts, s.Visibility)
+	}
+	if s.IsStatic {
+		parts = append(parts, "static")
+	}
+	if s.IsAsync {
+		parts = append(parts, "async")
+	}
+
+	if s.Receiver != "" && !strings.HasPrefix(identifier, s.Receiver) {
+		identifier = s.Receiver + "." + identifier
+	}
+
+	parameters := make([]string, 0, len(s.Parameters))
+	for _, parameter := range s.Parameters {
+		formattedParameter := parameter.Name
+		if parameter.IsVariadic {
+			formattedParameter = "..." + formattedParameter
+		}
+		if parameter.Type != "" {
+			formattedParameter = fmt.Sprintf("%s: %s", formattedParameter, parameter.Type)
+		}
+		parameters = append(parameters, formattedParameter)
+	}
+
+	signature := fmt.Sprintf("%s(%s)", identifier, strings.Join(parameters, ", "))
+	if s.ReturnType != "" {
+		signature += ": " + s.ReturnType
+	}
+	return strings.Join(append(parts, signature), " ")
+}
+
+// MarshalParameters returns the parameters as a JSON array.
+func (s *Signature) MarshalParameters() string {
+	if len(s.Parameters) == 0 {
+		return "[]"
+	}
+	parameters, err := json.Marshal(s.Parameters)
+	if err != nil {
+		return "[]"
+	}
+	return string(parameters)
+}
+
+// UnmarshalParameters sets the parameters from a JSON array.
+func (s *Signature) UnmarshalParameters(parameters string) error {
+	s.Parameters = nil
+	if parameters == "" || parameters == "[]" {
+		return nil
+	}
+	return json.Unmarshal([]byte(parameters), &s.Parameters)
+}
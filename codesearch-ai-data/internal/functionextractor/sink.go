@@ -0,0 +1,159 @@
+package functionextractor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// RepoSnapshot is the snapshot of a repo being written to a sink.
+type RepoSnapshot struct {
+	RepoName string
+	// Ref is the ref of the snapshot, TRACKED_SNAPSHOT_REF for the latest commit of the repo.
+	Ref      string
+	CommitID string
+	// License is the SPDX license expression detected from the license files of the repo tree.
+	License    string
+	PolicyHash string
+	// PolicyJSON is the policy the snapshot is extracted with, with every rule resolved.
+	PolicyJSON string
+}
+
+// Sink receives the files extracted from repo snapshots. Sinks write a single snapshot at a time: BeginSnapshot is
+// followed by the extracted and excluded files of the snapshot in walk order, and by EndSnapshot once every file is
+// written. Abort discards the snapshot being written, it is a no-op after EndSnapshot so it can be deferred.
+type Sink interface {
+	BeginSnapshot(ctx context.Context, snapshot *RepoSnapshot) error
+	WriteFile(ctx context.Context, relativePath string, file *extractedFile) error
+	EndSnapshot(ctx context.Context) error
+	Abort(ctx context.Context)
+}
+
+// extractSnapshot extracts every file of the repo tree at the commit, and writes them to the sink as the snapshot
+// of the ref.
+func (rt *repoTree) extractSnapshot(ctx context.Context, sink Sink, ref string, commitID string) error {
+	err := sink.BeginSnapshot(ctx, &RepoSnapshot{
+		RepoName:   rt.repoName,
+		Ref:        ref,
+		CommitID:   commitID,
+		License:    rt.license,
+		PolicyHash: rt.policy.Hash(),
+		PolicyJSON: rt.policy.JSON(),
+	})
+	if err != nil {
+		return err
+	}
+	defer sink.Abort(ctx)
+
+	walk := func(fn func(relativePath string) error) error { return walkRepoFiles(rt.path, fn) }
+	err = rt.extractFiles(ctx, walk, func(relativePath string, file *extractedFile) error {
+		if file.exclusion == nil && file.functions == nil {
+			return nil
+		}
+		return sink.WriteFile(ctx, relativePath, file)
+	})
+	if err != nil {
+		return err
+	}
+	return sink.EndSnapshot(ctx)
+}
+
+// postgresSink inserts the snapshots into the database, in batches of files. Insertion errors are logged and skip
+// the file. Calls are resolved once every file of the snapshot is inserted.
+type postgresSink struct {
+	conn *pgx.Conn
+	// onlyMissing leaves the functions and types that were already extracted untouched, e.g. for ref snapshots.
+	onlyMissing bool
+
+	snapshot   *RepoSnapshot
+	repoID     int
+	snapshotID int
+	writer     *fileBatchWriter
+}
+
+func newPostgresSink(conn *pgx.Conn, onlyMissing bool) *postgresSink {
+	return &postgresSink{conn: conn, onlyMissing: onlyMissing}
+}
+
+// BeginSnapshot inserts the repo if it was never extracted, and the snapshot. It fails if the repo already has a
+// snapshot of the ref.
+func (s *postgresSink) BeginSnapshot(ctx context.Context, snapshot *RepoSnapshot) error {
+	repoID, _, exists, err := getTrackedRepo(ctx, s.conn, snapshot.RepoName)
+	if err != nil {
+		return err
+	}
+
+	trackedCommitID := ""
+	if snapshot.Ref == TRACKED_SNAPSHOT_REF {
+		trackedCommitID = snapshot.CommitID
+	}
+
+	if !exists {
+		repoID, err = insertRepo(ctx, s.conn, snapshot.RepoName, trackedCommitID)
+		if err != nil {
+			return err
+		}
+		err = recordRepoJobRepo(ctx, s.conn, snapshot.RepoName, repoID)
+		if err != nil {
+			return err
+		}
+	} else {
+		snapshotExists, err := repoSnapshotExists(ctx, s.conn, repoID, snapshot.Ref)
+		if err != nil {
+			return err
+		}
+		if snapshotExists {
+			return fmt.Errorf("snapshot %q of repo %s already exists", snapshot.Ref, snapshot.RepoName)
+		}
+		if trackedCommitID != "" {
+			// The repo only had ref snapshots so far.
+			err = updateRepoCommitID(ctx, s.conn, repoID, trackedCommitID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = insertExtractionPolicyJSON(ctx, s.conn, snapshot.PolicyHash, snapshot.PolicyJSON)
+	if err != nil {
+		return err
+	}
+
+	snapshotID, err := upsertRepoSnapshot(ctx, s.conn, repoID, snapshot.Ref, snapshot.CommitID, snapshot.License, snapshot.PolicyHash)
+	if err != nil {
+		return err
+	}
+
+	s.snapshot, s.repoID, s.snapshotID, s.writer = snapshot, repoID, snapshotID, newFileBatchWriter(s.conn)
+	return nil
+}
+
+func (s *postgresSink) WriteFile(ctx context.Context, relativePath string, file *extractedFile) error {
+	err := s.writer.writeFile(ctx, func(conn *pgx.Conn) error {
+		return insertFile(ctx, conn, s.repoID, s.snapshotID, relativePath, file, s.onlyMissing)
+	})
+	if err != nil {
+		log.Debugf("Error inserting file %s/%s: %s", s.snapshot.RepoName, relativePath, err)
+	}
+	return nil
+}
+
+func (s *postgresSink) EndSnapshot(ctx context.Context) error {
+	err := s.writer.commit(ctx)
+	if err != nil {
+		return err
+	}
+	err = resolveRepoFunctionCalls(ctx, s.conn, s.repoID)
+	if err != nil {
+		return err
+	}
+	return releaseRepoJobRepo(ctx, s.conn, s.repoID)
+}
+
+func (s *postgresSink) Abort(ctx context.Context) {
+	if s.writer != nil {
+		s.writer.rollback(ctx)
+	}
+}
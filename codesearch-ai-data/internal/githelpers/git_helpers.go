@@ -1,9 +1,14 @@
 package githelpers
 
 import (
+	"archive/tar"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -32,8 +37,192 @@ func GetRepoCommitID(repoPath string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// FetchRefWithTimeout fetches a single commit or ref into a shallow clone, e.g. the previously extracted commit of
+// a repo or a release tag. The fetched commit is available as FETCH_HEAD.
+func FetchRefWithTimeout(repoPath string, ref string, timeoutSeconds int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSeconds)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "fetch", "--depth=1", "origin", ref)
+	cmd.Dir = repoPath
+	err := cmd.Run()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+const (
+	FileAdded    = "A"
+	FileModified = "M"
+	FileDeleted  = "D"
+	FileRenamed  = "R"
+)
+
+type FileChange struct {
+	Status string
+	Path   string
+	// OldPath is the path before the rename for renamed files.
+	OldPath string
+}
+
+// GetChangedFiles returns the files changed between two commits. Type changes and copies are reported as modified
+// and added files respectively.
+func GetChangedFiles(repoPath, fromCommitID, toCommitID string) ([]FileChange, error) {
+	cmd := exec.Command("git", "diff", "--name-status", "-z", "-M", fromCommitID, toCommitID)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("diffing %s..%s: %w", fromCommitID, toCommitID, err)
+	}
+	return parseNameStatus(string(output))
+}
+
+// parseNameStatus parses the NUL separated output of `git diff --name-status -z`. Renames and copies are followed
+// by both the old and the new path, the other statuses by a single path.
+func parseNameStatus(output string) ([]FileChange, error) {
+	fields := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	if len(fields) == 1 && fields[0] == "" {
+		return []FileChange{}, nil
+	}
+
+	changes := []FileChange{}
+	for i := 0; i < len(fields); {
+		status := fields[i]
+		if status == "" {
+			return nil, fmt.Errorf("invalid name status output at field %d", i)
+		}
+
+		switch status[:1] {
+		case "R", "C":
+			if i+2 >= len(fields) {
+				return nil, fmt.Errorf("missing paths for status %s", status)
+			}
+			if status[:1] == "R" {
+				changes = append(changes, FileChange{Status: FileRenamed, Path: fields[i+2], OldPath: fields[i+1]})
+			} else {
+				changes = append(changes, FileChange{Status: FileAdded, Path: fields[i+2]})
+			}
+			i += 3
+		case "A", "M", "D", "T":
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("missing path for status %s", status)
+			}
+			changeStatus := status[:1]
+			if changeStatus == "T" {
+				changeStatus = FileModified
+			}
+			changes = append(changes, FileChange{Status: changeStatus, Path: fields[i+1]})
+			i += 2
+		default:
+			return nil, fmt.Errorf("unsupported status %s", status)
+		}
+	}
+	return changes, nil
+}
+
 func checkoutRepoCommitID(repoPath, commitID string) error {
 	cmd := exec.Command("git", "checkout", commitID)
 	cmd.Dir = repoPath
 	return cmd.Run()
 }
+
+func IsBareRepo(repoPath string) (bool, error) {
+	cmd := exec.Command("git", "rev-parse", "--is-bare-repository")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(output)) == "true", nil
+}
+
+func ResolveRepoRef(repoPath, ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", ref+"^{commit}")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("resolving ref %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ExportRepoTree writes the tree of the given commit into exportPath without touching the repo's
+// working tree or index. It works for both bare repos and regular checkouts.
+func ExportRepoTree(repoPath, commitID, exportPath string) error {
+	cmd := exec.Command("git", "archive", "--format=tar", commitID)
+	cmd.Dir = repoPath
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	err = extractTar(stdout, exportPath)
+	if err != nil {
+		// Drain the remaining output so that git can exit.
+		io.Copy(io.Discard, stdout)
+		cmd.Wait()
+		return err
+	}
+
+	return cmd.Wait()
+}
+
+func extractTar(r io.Reader, destPath string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		targetPath := filepath.Join(destPath, header.Name)
+		// Guard against archive entries escaping the destination directory.
+		if !strings.HasPrefix(targetPath, filepath.Clean(destPath)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid archive entry %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(targetPath, 0755)
+		case tar.TypeReg:
+			err = writeFile(targetPath, tr)
+		default:
+			// Skip symlinks, submodules and other special entries.
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
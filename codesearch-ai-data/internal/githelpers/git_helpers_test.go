@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -50,3 +53,125 @@ func TestRepoTimeout(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %s: %s", args, err, output)
+	}
+}
+
+func TestExportBareRepoTree(t *testing.T) {
+	tmpPath := t.TempDir()
+	repoPath := filepath.Join(tmpPath, "repo")
+	bareRepoPath := filepath.Join(tmpPath, "repo.git")
+	exportPath := filepath.Join(tmpPath, "export")
+
+	err := os.MkdirAll(filepath.Join(repoPath, "pkg"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(repoPath, "pkg", "a.go"), []byte("package pkg\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runGit(t, repoPath, "init", "-q")
+	runGit(t, repoPath, "add", "-A")
+	runGit(t, repoPath, "commit", "-q", "-m", "Initial commit")
+	runGit(t, tmpPath, "clone", "-q", "--bare", repoPath, bareRepoPath)
+
+	isBare, err := IsBareRepo(bareRepoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isBare {
+		t.Fatalf("Expected %s to be a bare repo", bareRepoPath)
+	}
+
+	wantCommitID, err := GetRepoCommitID(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotCommitID, err := ResolveRepoRef(bareRepoPath, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotCommitID != wantCommitID {
+		t.Fatalf("Want commit id: %s, got %s", wantCommitID, gotCommitID)
+	}
+
+	err = ExportRepoTree(bareRepoPath, gotCommitID, exportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(exportPath, "pkg", "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "package pkg\n" {
+		t.Fatalf("Unexpected exported file content %q", content)
+	}
+}
+
+func TestGetChangedFiles(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile := func(path string, content string) {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(repoPath, path)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(repoPath, path), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("modified.go", "package pkg\n")
+	writeFile("deleted.go", "package pkg\n\nfunc deleted() {}\n")
+	writeFile("old/renamed.go", "package old\n\nfunc renamed() {\n\treturn\n}\n")
+	runGit(t, repoPath, "init", "-q")
+	runGit(t, repoPath, "add", "-A")
+	runGit(t, repoPath, "commit", "-q", "-m", "Initial commit")
+	fromCommitID, err := GetRepoCommitID(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile("modified.go", "package pkg\n\nfunc modified() {}\n")
+	writeFile("added.go", "package pkg\n")
+	writeFile("new/renamed.go", "package old\n\nfunc renamed() {\n\treturn\n}\n")
+	err = os.RemoveAll(filepath.Join(repoPath, "old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Remove(filepath.Join(repoPath, "deleted.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repoPath, "add", "-A")
+	runGit(t, repoPath, "commit", "-q", "-m", "Second commit")
+	toCommitID, err := GetRepoCommitID(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := GetChangedFiles(repoPath, fromCommitID, toCommitID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantChanges := []FileChange{
+		{Status: FileAdded, Path: "added.go"},
+		{Status: FileDeleted, Path: "deleted.go"},
+		{Status: FileModified, Path: "modified.go"},
+		{Status: FileRenamed, Path: "new/renamed.go", OldPath: "old/renamed.go"},
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Fatalf("Want changes %+v, got %+v", wantChanges, changes)
+	}
+}
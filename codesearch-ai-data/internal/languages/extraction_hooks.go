@@ -0,0 +1,132 @@
+package languages
+
+import (
+	ph "codesearch-ai-data/internal/parsinghelpers"
+	"path/filepath"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// GetDocstring returns the docstring of the function or type with the @doc node, the comments preceding the node
+// by default.
+func (l *Language) GetDocstring(docstringNode *sitter.Node, identifier string, code []byte) string {
+	if l.Docstring != nil {
+		return l.Docstring(docstringNode, identifier, code)
+	}
+	return ph.GetPrecedingFunctionDocstring(docstringNode, code)
+}
+
+// GetSkipNodeFn returns a function to skip additional nodes when cleaning the code of the node, nil by default.
+func (l *Language) GetSkipNodeFn(node *sitter.Node) (ph.SkipNodeFn, error) {
+	if l.SkipNodes != nil {
+		return l.SkipNodes(node)
+	}
+	return nil, nil
+}
+
+// GetFileScope returns the scope implied by the file path, empty by default.
+func (l *Language) GetFileScope(filePath string) string {
+	if l.FileScope != nil {
+		return l.FileScope(filePath)
+	}
+	return ""
+}
+
+// GetSnippetCode returns the code of a StackOverflow snippet ready to be parsed, the snippet itself by default.
+func (l *Language) GetSnippetCode(code string) string {
+	if l.SnippetCode != nil {
+		return l.SnippetCode(code)
+	}
+	return code
+}
+
+// We have to traverse the entire function subtree to remove nested function docstrings.
+func skipPythonDocstringNodes(node *sitter.Node) (ph.SkipNodeFn, error) {
+	docstringNodes, err := ph.GetPythonDocstringNodes(node)
+	if err != nil {
+		return nil, err
+	}
+	return ph.SkipPythonDocstringNodesFn(docstringNodes), nil
+}
+
+func getPythonDocstring(docstringNode *sitter.Node, identifier string, code []byte) string {
+	return ph.GetPythonDocstring(ph.GetPythonDocstringNode(docstringNode), code)
+}
+
+// getPythonModuleName returns the dotted module name of a Python file, e.g. `pkg.sub` for `pkg/sub/__init__.py`.
+func getPythonModuleName(filePath string) string {
+	modulePath := strings.TrimSuffix(filepath.ToSlash(filePath), filepath.Ext(filePath))
+	if modulePath == "__init__" {
+		return ""
+	}
+	modulePath = strings.TrimSuffix(modulePath, "/__init__")
+	return strings.ReplaceAll(modulePath, "/", ".")
+}
+
+func getTypescriptDocstring(docstringNode *sitter.Node, identifier string, code []byte) string {
+	return ph.GetPrecedingFunctionDocstring(getTypescriptDocstringNode(docstringNode, identifier, code), code)
+}
+
+func isTypescriptOverloadSignature(node *sitter.Node, identifier string, code []byte) bool {
+	if node.Type() == "export_statement" && node.NamedChildCount() > 0 {
+		node = node.NamedChild(0)
+	}
+	return node.Type() == "function_signature" && ph.FindNamedIdentifier(node, code) == identifier
+}
+
+// getTypescriptDocstringNode returns the node that the docstring comment is attached to. The comment precedes
+// the export statement for exported functions, the decorators for decorated methods and the first overload
+// signature for overloaded functions.
+func getTypescriptDocstringNode(node *sitter.Node, identifier string, code []byte) *sitter.Node {
+	docstringNode := node
+	if parent := node.Parent(); parent != nil && parent.Type() == "export_statement" {
+		docstringNode = parent
+	}
+
+	for {
+		prevNode := docstringNode.PrevNamedSibling()
+		if prevNode == nil {
+			break
+		}
+
+		if prevNode.Type() == "decorator" || isTypescriptOverloadSignature(prevNode, identifier, code) {
+			docstringNode = prevNode
+		} else {
+			break
+		}
+	}
+
+	return docstringNode
+}
+
+// Only doc comments are docstrings in Rust.
+func getRustDocstring(docstringNode *sitter.Node, identifier string, code []byte) string {
+	return ph.GetPrecedingRustDocstring(docstringNode, code)
+}
+
+func getCsharpDocstring(docstringNode *sitter.Node, identifier string, code []byte) string {
+	return ph.GetXMLDocCommentText(ph.GetPrecedingFunctionDocstring(docstringNode, code))
+}
+
+// Only KDoc comments are docstrings in Kotlin.
+func getKotlinDocstring(docstringNode *sitter.Node, identifier string, code []byte) string {
+	return ph.GetPrecedingKDocDocstring(docstringNode, code)
+}
+
+func addPHPTagsIfMissing(code string) string {
+	if !strings.HasPrefix(code, "<?php") {
+		code = "<?php\n" + code
+	}
+	if !strings.HasSuffix(code, "?>") {
+		code = code + "\n?>"
+	}
+	return code
+}
+
+func skipPHPTagNodes(node *sitter.Node) (ph.SkipNodeFn, error) {
+	return func(node *sitter.Node) bool {
+		nodeType := node.Type()
+		return nodeType == "php_tag" || nodeType == "?>"
+	}, nil
+}
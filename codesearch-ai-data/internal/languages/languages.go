@@ -0,0 +1,173 @@
+package languages
+
+import (
+	ph "codesearch-ai-data/internal/parsinghelpers"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+type Language struct {
+	// Name is the canonical language name used throughout the pipeline.
+	Name string
+	// Extensions are the file extensions (without the leading dot) handled by the language.
+	Extensions []string
+	// Grammar returns the tree-sitter grammar used to parse the language.
+	Grammar func() *sitter.Language
+	// ExtensionGrammars overrides Grammar for specific extensions (e.g. TSX files).
+	ExtensionGrammars map[string]func() *sitter.Language
+	// SOTags are StackOverflow tags that have to match exactly.
+	SOTags []string
+	// SOTagPrefixes are StackOverflow tag prefixes, for things like python-3.x, ruby-on-rails, c++11, etc.
+	SOTagPrefixes []string
+	// QueryAliases are the lowercase names used to refer to the language in search queries.
+	QueryAliases []string
+
+	// The extraction hooks cover the language specifics that cannot be expressed in the extraction queries of the
+	// functionextractor package.
+	//
+	// Docstring extracts the docstring from the @doc node of a function or type. Defaults to the comments preceding
+	// the node.
+	Docstring func(docstringNode *sitter.Node, identifier string, code []byte) string
+	// SkipNodes returns a function to skip additional nodes of a function or type when cleaning its code.
+	SkipNodes func(node *sitter.Node) (ph.SkipNodeFn, error)
+	// FileScope returns the scope implied by the file path, for languages where the path is part of the qualified
+	// names (e.g. Python modules).
+	FileScope func(filePath string) string
+	// SnippetCode completes a StackOverflow code snippet so that it parses as a file (e.g. adds the PHP tags).
+	SnippetCode func(code string) string
+}
+
+// GrammarForExtension returns the grammar used to parse files with the extension, and a name identifying it
+// (the language name, or e.g. typescript/tsx for extension specific grammars).
+func (l *Language) GrammarForExtension(fileExtension string) (string, func() *sitter.Language) {
+	if grammar, ok := l.ExtensionGrammars[fileExtension]; ok {
+		return l.Name + "/" + fileExtension, grammar
+	}
+	return l.Name, l.Grammar
+}
+
+func (l *Language) NewParser() *sitter.Parser {
+	parser := sitter.NewParser()
+	parser.SetLanguage(l.Grammar())
+	return parser
+}
+
+func (l *Language) NewParserForFile(filePath string) *sitter.Parser {
+	_, grammar := l.GrammarForExtension(FileExtension(filePath))
+	parser := sitter.NewParser()
+	parser.SetLanguage(grammar())
+	return parser
+}
+
+func (l *Language) HasFileExtension(filePath string) bool {
+	return contains(l.Extensions, FileExtension(filePath))
+}
+
+func (l *Language) MatchesSOTag(tag string) bool {
+	tagLower := strings.ToLower(tag)
+	if contains(l.SOTags, tagLower) {
+		return true
+	}
+	for _, prefix := range l.SOTagPrefixes {
+		if strings.HasPrefix(tagLower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Language) MatchesAnySOTag(tags []string) bool {
+	for _, tag := range tags {
+		if l.MatchesSOTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+var nameToLanguage = map[string]*Language{}
+var extensionToLanguage = map[string]*Language{}
+var queryAliasToLanguage = map[string]*Language{}
+var queryAliasesRegexp *regexp.Regexp
+
+func init() {
+	queryAliases := []string{}
+	for _, language := range registeredLanguages {
+		nameToLanguage[language.Name] = language
+		for _, extension := range language.Extensions {
+			extensionToLanguage[extension] = language
+		}
+		for _, alias := range language.QueryAliases {
+			queryAliasToLanguage[alias] = language
+			queryAliases = append(queryAliases, alias)
+		}
+	}
+	queryAliasesRegexp = newQueryAliasesRegexp(queryAliases)
+}
+
+// newQueryAliasesRegexp matches any of the aliases as a whole word. Longer aliases come first so that an alias wins
+// over its prefixes, and aliases ending in a non-word character (`c++`, `c#`) are not followed by \b, since it would never match.
+func newQueryAliasesRegexp(aliases []string) *regexp.Regexp {
+	sort.SliceStable(aliases, func(i, j int) bool { return len(aliases[i]) > len(aliases[j]) })
+
+	patterns := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		pattern := `\b` + regexp.QuoteMeta(alias)
+		if isWordChar(alias[len(alias)-1]) {
+			pattern += `\b`
+		}
+		patterns = append(patterns, pattern)
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(patterns, "|"))
+}
+
+// All returns the registered languages in registration order.
+func All() []*Language {
+	return registeredLanguages
+}
+
+func ByName(name string) *Language {
+	return nameToLanguage[name]
+}
+
+func ForFile(filePath string) *Language {
+	return extensionToLanguage[FileExtension(filePath)]
+}
+
+// ForSOTags returns the languages matching any of the tags, in registration order.
+func ForSOTags(tags []string) []*Language {
+	matchingLanguages := []*Language{}
+	for _, language := range registeredLanguages {
+		if language.MatchesAnySOTag(tags) {
+			matchingLanguages = append(matchingLanguages, language)
+		}
+	}
+	return matchingLanguages
+}
+
+// FindInQuery returns the first language mentioned in a search query, or nil if there is none.
+func FindInQuery(query string) *Language {
+	return queryAliasToLanguage[strings.ToLower(queryAliasesRegexp.FindString(query))]
+}
+
+// FileExtension returns the extension of the file without the leading dot.
+func FileExtension(filePath string) string {
+	return strings.TrimPrefix(filepath.Ext(filePath), ".")
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
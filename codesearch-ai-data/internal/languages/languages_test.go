@@ -0,0 +1,111 @@
+package languages
+
+import "testing"
+
+func TestForFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"a/b/main.go", "go"},
+		{"src/App.tsx", "typescript"},
+		{"lib/index.mjs", "javascript"},
+		{"include/vector.h", "cpp"},
+		{"src/main.c", "c"},
+		{"Program.cs", "csharp"},
+		{"build.gradle.kts", "kotlin"},
+		{"README.md", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			name := ""
+			if language := ForFile(test.path); language != nil {
+				name = language.Name
+			}
+			if name != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, name)
+			}
+		})
+	}
+}
+
+func TestFindInQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"sort a list in python", "python"},
+		{"read file golang", "go"},
+		{"C++ vector sort", "cpp"},
+		{"c# linq group by", "csharp"},
+		{"malloc in c language", "c"},
+		{"c99 variable length array", "c"},
+		{"malloc in c", ""},
+		{"objective c block syntax", ""},
+		{"option c of the switch", ""},
+		{"c++ malloc", "cpp"},
+		{"javascript array map", "javascript"},
+		{"java stream filter", "java"},
+		{"kotlin coroutine scope", "kotlin"},
+		{"parse json", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			name := ""
+			if language := FindInQuery(test.query); language != nil {
+				name = language.Name
+			}
+			if name != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, name)
+			}
+		})
+	}
+}
+
+func TestForSOTags(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		expected []string
+	}{
+		{[]string{"python-3.x", "pandas"}, []string{"python"}},
+		{[]string{"javascript"}, []string{"javascript"}},
+		{[]string{"c++11"}, []string{"cpp"}},
+		{[]string{"c#", "linq"}, []string{"csharp"}},
+		{[]string{"c", "pointers"}, []string{"c"}},
+		{[]string{"kotlin-coroutines"}, []string{"kotlin"}},
+		{[]string{"android", "java"}, []string{"java", "kotlin"}},
+		{[]string{"html"}, []string{}},
+	}
+
+	for _, test := range tests {
+		matchingLanguages := ForSOTags(test.tags)
+		if len(matchingLanguages) != len(test.expected) {
+			t.Fatalf("expected %v for tags %v, got %d languages", test.expected, test.tags, len(matchingLanguages))
+		}
+		for i, language := range matchingLanguages {
+			if language.Name != test.expected[i] {
+				t.Fatalf("expected %v for tags %v, got %s", test.expected, test.tags, language.Name)
+			}
+		}
+	}
+}
+
+func TestPythonModuleName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "server.py", want: "server"},
+		{path: "server/handler.py", want: "server.handler"},
+		{path: "server/__init__.py", want: "server"},
+		{path: "__init__.py", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := getPythonModuleName(tt.path); got != tt.want {
+			t.Errorf("getPythonModuleName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package languages
+
+import (
+	"codesearch-ai-data/internal/grammars/kotlin"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+	"github.com/smacker/go-tree-sitter/cpp"
+	"github.com/smacker/go-tree-sitter/csharp"
+	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/php"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/ruby"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+)
+
+// Adding a language only requires registering it here, with its extraction hooks, and adding its extraction queries
+// to the functionextractor package.
+var registeredLanguages = []*Language{
+	{
+		Name:         "java",
+		Extensions:   []string{"java"},
+		Grammar:      java.GetLanguage,
+		SOTags:       []string{"java", "spring"},
+		QueryAliases: []string{"java"},
+	},
+	{
+		Name:          "python",
+		Extensions:    []string{"py"},
+		Grammar:       python.GetLanguage,
+		SOTags:        []string{"django", "numpy"},
+		SOTagPrefixes: []string{"python"},
+		QueryAliases:  []string{"python", "py"},
+		Docstring:     getPythonDocstring,
+		SkipNodes:     skipPythonDocstringNodes,
+		FileScope:     getPythonModuleName,
+	},
+	{
+		Name:         "php",
+		Extensions:   []string{"php"},
+		Grammar:      php.GetLanguage,
+		SOTags:       []string{"php", "laravel"},
+		QueryAliases: []string{"php"},
+		SkipNodes:    skipPHPTagNodes,
+		SnippetCode:  addPHPTagsIfMissing,
+	},
+	{
+		Name:          "ruby",
+		Extensions:    []string{"rb"},
+		Grammar:       ruby.GetLanguage,
+		SOTagPrefixes: []string{"ruby"},
+		QueryAliases:  []string{"ruby"},
+	},
+	{
+		Name:         "javascript",
+		Extensions:   []string{"js", "jsx", "mjs", "cjs"},
+		Grammar:      javascript.GetLanguage,
+		SOTags:       []string{"javascript", "jquery", "node.js", "reactjs"},
+		QueryAliases: []string{"javascript", "js"},
+	},
+	{
+		Name:         "go",
+		Extensions:   []string{"go"},
+		Grammar:      golang.GetLanguage,
+		SOTags:       []string{"go"},
+		QueryAliases: []string{"go", "golang"},
+	},
+	{
+		Name:              "typescript",
+		Extensions:        []string{"ts", "mts", "cts", "tsx"},
+		Grammar:           typescript.GetLanguage,
+		ExtensionGrammars: map[string]func() *sitter.Language{"tsx": tsx.GetLanguage},
+		SOTagPrefixes:     []string{"typescript", "angular"},
+		QueryAliases:      []string{"typescript", "ts"},
+		Docstring:         getTypescriptDocstring,
+	},
+	{
+		Name:          "rust",
+		Extensions:    []string{"rs"},
+		Grammar:       rust.GetLanguage,
+		SOTagPrefixes: []string{"rust"},
+		QueryAliases:  []string{"rust"},
+		Docstring:     getRustDocstring,
+	},
+	{
+		// A bare "c" is too common in queries (e.g. "objective c", "option c") to be an alias.
+		Name:         "c",
+		Extensions:   []string{"c"},
+		Grammar:      c.GetLanguage,
+		SOTags:       []string{"c"},
+		QueryAliases: []string{"c language", "c99", "c11"},
+	},
+	{
+		// Headers are parsed as C++, which is mostly a superset of C.
+		Name:          "cpp",
+		Extensions:    []string{"h", "cc", "cpp", "cxx", "hh", "hpp", "hxx"},
+		Grammar:       cpp.GetLanguage,
+		SOTagPrefixes: []string{"c++"},
+		QueryAliases:  []string{"cpp", "c++"},
+	},
+	{
+		Name:          "csharp",
+		Extensions:    []string{"cs"},
+		Grammar:       csharp.GetLanguage,
+		SOTagPrefixes: []string{"c#", ".net"},
+		QueryAliases:  []string{"csharp", "c#"},
+		Docstring:     getCsharpDocstring,
+	},
+	{
+		Name:          "kotlin",
+		Extensions:    []string{"kt", "kts"},
+		Grammar:       kotlin.GetLanguage,
+		SOTags:        []string{"android"},
+		SOTagPrefixes: []string{"kotlin"},
+		QueryAliases:  []string{"kotlin", "kt"},
+		Docstring:     getKotlinDocstring,
+	},
+}
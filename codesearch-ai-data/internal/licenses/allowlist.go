@@ -0,0 +1,120 @@
+package licenses
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Allowlist is a set of allowed SPDX license IDs. A nil allowlist allows every license.
+type Allowlist map[string]bool
+
+// ParseAllowlist parses comma separated SPDX license IDs, e.g. "MIT,Apache-2.0". It returns a nil allowlist for
+// an empty string.
+func ParseAllowlist(spdxIDs string) Allowlist {
+	var allowlist Allowlist
+	for _, spdxID := range strings.Split(spdxIDs, ",") {
+		if spdxID = strings.TrimSpace(spdxID); spdxID == "" {
+			continue
+		}
+		if allowlist == nil {
+			allowlist = Allowlist{}
+		}
+		// SPDX license IDs are matched case-insensitively.
+		allowlist[strings.ToUpper(spdxID)] = true
+	}
+	return allowlist
+}
+
+// Allows reports whether code under the SPDX license expression can be used with the allowed licenses. OR
+// expressions need one allowed alternative and AND expressions need every license to be allowed. Exceptions (WITH)
+// only add permissions, so they are ignored. Empty and invalid expressions are not allowed.
+func (a Allowlist) Allows(expression string) bool {
+	if a == nil {
+		return true
+	}
+
+	p := &expressionParser{tokens: tokenizeExpression(expression)}
+	allowed, ok := p.parseOr(a)
+	return ok && p.pos == len(p.tokens) && allowed
+}
+
+// Filter returns the license expressions that are allowed.
+func (a Allowlist) Filter(expressions []string) []string {
+	allowed := []string{}
+	for _, expression := range expressions {
+		if a.Allows(expression) {
+			allowed = append(allowed, expression)
+		}
+	}
+	return allowed
+}
+
+var expressionTokenRegexp = regexp.MustCompile(`[()]|[^()\s]+`)
+
+func tokenizeExpression(expression string) []string {
+	return expressionTokenRegexp.FindAllString(expression, -1)
+}
+
+type expressionParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *expressionParser) peekOperator(operator string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], operator)
+}
+
+func (p *expressionParser) parseOr(allowlist Allowlist) (bool, bool) {
+	allowed, ok := p.parseAnd(allowlist)
+	for ok && p.peekOperator("OR") {
+		p.pos++
+		var alternativeAllowed bool
+		alternativeAllowed, ok = p.parseAnd(allowlist)
+		allowed = allowed || alternativeAllowed
+	}
+	return allowed, ok
+}
+
+func (p *expressionParser) parseAnd(allowlist Allowlist) (bool, bool) {
+	allowed, ok := p.parseLicense(allowlist)
+	for ok && p.peekOperator("AND") {
+		p.pos++
+		var otherAllowed bool
+		otherAllowed, ok = p.parseLicense(allowlist)
+		allowed = allowed && otherAllowed
+	}
+	return allowed, ok
+}
+
+func (p *expressionParser) parseLicense(allowlist Allowlist) (bool, bool) {
+	if p.pos == len(p.tokens) {
+		return false, false
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+
+	if token == "(" {
+		allowed, ok := p.parseOr(allowlist)
+		if !ok || p.pos == len(p.tokens) || p.tokens[p.pos] != ")" {
+			return false, false
+		}
+		p.pos++
+		return allowed, true
+	}
+
+	if token == ")" || strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH") {
+		return false, false
+	}
+
+	if p.peekOperator("WITH") {
+		if p.pos+1 == len(p.tokens) {
+			return false, false
+		}
+		p.pos += 2
+	}
+
+	spdxID := strings.ToUpper(token)
+	// "GPL-2.0+" is allowed by either "GPL-2.0+" or "GPL-2.0".
+	return allowlist[spdxID] || allowlist[strings.TrimSuffix(spdxID, "+")], true
+}
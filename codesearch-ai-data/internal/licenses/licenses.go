@@ -0,0 +1,183 @@
+package licenses
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// NO_ASSERTION is the SPDX ID of repos with license files that do not match any of the bundled license texts.
+const NO_ASSERTION = "NOASSERTION"
+
+// Minimum share of the word trigrams of a license text that have to be present in a license file to match it.
+const MIN_LICENSE_COVERAGE = 0.8
+
+const MAX_LICENSE_FILE_BYTE_SIZE = 256 * 1024
+
+// Number of lines at the start of a file that are searched for an SPDX license identifier.
+const SPDX_HEADER_LINES = 30
+
+//go:embed texts
+var licenseTextsFS embed.FS
+
+type licenseText struct {
+	spdxID   string
+	shingles map[string]struct{}
+}
+
+var licenseTexts = mustLoadLicenseTexts()
+
+func mustLoadLicenseTexts() []*licenseText {
+	texts := []*licenseText{}
+	err := fs.WalkDir(licenseTextsFS, "texts", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		text, err := licenseTextsFS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		spdxID := strings.TrimSuffix(d.Name(), ".txt")
+		texts = append(texts, &licenseText{spdxID, shingles(normalizeWords(string(text)))})
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return texts
+}
+
+var nonWordCharsRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// normalizeWords returns the lowercased words of a license text, without punctuation and formatting. Copyright
+// lines differ between copies of a license, but they are short enough not to matter.
+func normalizeWords(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "licence", "license")
+	return strings.Fields(nonWordCharsRegexp.ReplaceAllString(text, " "))
+}
+
+// shingles returns the set of word trigrams.
+func shingles(words []string) map[string]struct{} {
+	s := map[string]struct{}{}
+	for i := 0; i+3 <= len(words); i++ {
+		s[strings.Join(words[i:i+3], " ")] = struct{}{}
+	}
+	return s
+}
+
+// MatchLicenseText matches the text of a license file against the bundled license texts, and returns the SPDX ID of
+// the closest license text. It returns an empty string if no license text is contained in the file.
+func MatchLicenseText(text string) string {
+	fileShingles := shingles(normalizeWords(text))
+	if len(fileShingles) == 0 {
+		return ""
+	}
+
+	bestSPDXID, bestSimilarity := "", 0.0
+	for _, lt := range licenseTexts {
+		common := 0
+		for shingle := range lt.shingles {
+			if _, ok := fileShingles[shingle]; ok {
+				common++
+			}
+		}
+
+		coverage := float64(common) / float64(len(lt.shingles))
+		if coverage < MIN_LICENSE_COVERAGE {
+			continue
+		}
+
+		// Shorter licenses contained in longer ones (e.g. BSD-2-Clause in BSD-3-Clause) are disambiguated by their
+		// Dice similarity to the file.
+		similarity := 2 * float64(common) / float64(len(lt.shingles)+len(fileShingles))
+		if similarity > bestSimilarity {
+			bestSPDXID, bestSimilarity = lt.spdxID, similarity
+		}
+	}
+	return bestSPDXID
+}
+
+var licenseFileNameRegexp = regexp.MustCompile(`(?i)^(un)?(licen[cs]e|copying)([-._].*)?$`)
+
+// Lesser GPL license files are usually distributed along with the GPL license they extend.
+var lesserGPLBaseLicenses = map[string]string{
+	"LGPL-2.1": "GPL-2.0",
+	"LGPL-3.0": "GPL-3.0",
+}
+
+// DetectRepoLicense detects the license of a repo tree from the LICENSE and COPYING files in its root directory.
+// Several detected licenses are combined with AND, since we cannot tell whether they apply to the whole repo. It
+// returns NO_ASSERTION for unknown licenses and an empty string for repos without license files.
+func DetectRepoLicense(repoPath string) string {
+	entries, err := os.ReadDir(repoPath)
+	if err != nil {
+		return ""
+	}
+
+	hasLicenseFiles := false
+	spdxIDs := map[string]bool{}
+	for _, entry := range entries {
+		if entry.IsDir() || !licenseFileNameRegexp.MatchString(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.Size() > MAX_LICENSE_FILE_BYTE_SIZE {
+			continue
+		}
+
+		text, err := os.ReadFile(filepath.Join(repoPath, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		hasLicenseFiles = true
+		if spdxID := MatchLicenseText(string(text)); spdxID != "" {
+			spdxIDs[spdxID] = true
+		}
+	}
+
+	if !hasLicenseFiles {
+		return ""
+	}
+	if len(spdxIDs) == 0 {
+		return NO_ASSERTION
+	}
+
+	for lesserGPL, gpl := range lesserGPLBaseLicenses {
+		if spdxIDs[lesserGPL] {
+			delete(spdxIDs, gpl)
+		}
+	}
+
+	sortedSPDXIDs := make([]string, 0, len(spdxIDs))
+	for spdxID := range spdxIDs {
+		sortedSPDXIDs = append(sortedSPDXIDs, spdxID)
+	}
+	sort.Strings(sortedSPDXIDs)
+	return strings.Join(sortedSPDXIDs, " AND ")
+}
+
+var spdxHeaderRegexp = regexp.MustCompile(`SPDX-License-Identifier:\s*([^\r\n]+)`)
+
+// Comment terminators that can follow the license expression on the same line.
+var spdxHeaderSuffixRegexp = regexp.MustCompile(`\s*(\*/|-->|"""|''')?\s*$`)
+
+// DetectFileLicense returns the license expression of the SPDX-License-Identifier header at the start of a file, or
+// an empty string if the file has no such header.
+func DetectFileLicense(code []byte) string {
+	header := string(code)
+	if lines := strings.SplitN(header, "\n", SPDX_HEADER_LINES+1); len(lines) > SPDX_HEADER_LINES {
+		header = strings.Join(lines[:SPDX_HEADER_LINES], "\n")
+	}
+
+	match := spdxHeaderRegexp.FindStringSubmatch(header)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(spdxHeaderSuffixRegexp.ReplaceAllString(match[1], ""))
+}
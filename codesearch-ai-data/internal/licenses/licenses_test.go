@@ -0,0 +1,142 @@
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readLicenseText(t *testing.T, spdxID string) string {
+	text, err := licenseTextsFS.ReadFile("texts/" + spdxID + ".txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(text)
+}
+
+func TestMatchLicenseText(t *testing.T) {
+	mit := strings.Replace(readLicenseText(t, "MIT"), "Copyright (c) <year> <copyright holders>", "Copyright (c) 2015-2022 Jane Doe and contributors", 1)
+	// License files are often reflowed and lose their title.
+	mitReflowed := strings.Join(strings.Fields(strings.TrimPrefix(mit, "MIT License")), " ")
+	bsd3 := strings.Replace(readLicenseText(t, "BSD-3-Clause"), "the copyright holder nor", "Example Inc. nor", 1)
+	apacheWithoutAppendix := readLicenseText(t, "Apache-2.0")
+	apacheWithoutAppendix = apacheWithoutAppendix[:strings.Index(apacheWithoutAppendix, "APPENDIX")]
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "MIT", text: mit, want: "MIT"},
+		{name: "reflowed MIT", text: mitReflowed, want: "MIT"},
+		{name: "BSD-2-Clause", text: readLicenseText(t, "BSD-2-Clause"), want: "BSD-2-Clause"},
+		{name: "BSD-3-Clause with organization", text: bsd3, want: "BSD-3-Clause"},
+		{name: "ISC", text: readLicenseText(t, "ISC"), want: "ISC"},
+		{name: "0BSD", text: readLicenseText(t, "0BSD"), want: "0BSD"},
+		{name: "Apache-2.0 without appendix", text: apacheWithoutAppendix, want: "Apache-2.0"},
+		{name: "GPL-3.0", text: readLicenseText(t, "GPL-3.0"), want: "GPL-3.0"},
+		{name: "LGPL-3.0", text: readLicenseText(t, "LGPL-3.0"), want: "LGPL-3.0"},
+		{name: "custom license", text: "You may use this code for non-commercial purposes only, with the written permission of the author.", want: ""},
+		{name: "empty", text: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchLicenseText(tt.text)
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectRepoLicense(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{name: "no license files", files: map[string]string{"README.md": "# Project"}, want: ""},
+		{name: "single license file", files: map[string]string{"LICENSE.md": readLicenseText(t, "MIT")}, want: "MIT"},
+		{name: "dual licensed", files: map[string]string{"LICENSE-MIT": readLicenseText(t, "MIT"), "LICENSE-APACHE": readLicenseText(t, "Apache-2.0")}, want: "Apache-2.0 AND MIT"},
+		{name: "lesser GPL", files: map[string]string{"COPYING": readLicenseText(t, "GPL-3.0"), "COPYING.LESSER": readLicenseText(t, "LGPL-3.0")}, want: "LGPL-3.0"},
+		{name: "unknown license", files: map[string]string{"LICENSE": "All rights reserved."}, want: NO_ASSERTION},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := t.TempDir()
+			for name, content := range tt.files {
+				err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := DetectRepoLicense(repoPath)
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectFileLicense(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{name: "line comment", code: "// SPDX-License-Identifier: MIT\npackage main\n", want: "MIT"},
+		{name: "block comment", code: "/* SPDX-License-Identifier: GPL-2.0-only WITH Linux-syscall-note */\n#include <stdio.h>\n", want: "GPL-2.0-only WITH Linux-syscall-note"},
+		{name: "expression", code: "#!/usr/bin/env python\n# SPDX-License-Identifier: (Apache-2.0 OR MIT)\n", want: "(Apache-2.0 OR MIT)"},
+		{name: "no header", code: "package main\n", want: ""},
+		{name: "header after the first lines", code: strings.Repeat("\n", SPDX_HEADER_LINES) + "// SPDX-License-Identifier: MIT\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectFileLicense([]byte(tt.code))
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAllowlistAllows(t *testing.T) {
+	allowlist := ParseAllowlist("MIT, apache-2.0,BSD-3-Clause,GPL-2.0")
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{expression: "MIT", want: true},
+		{expression: "Apache-2.0", want: true},
+		{expression: "GPL-3.0", want: false},
+		{expression: "Apache-2.0 OR GPL-3.0", want: true},
+		{expression: "Apache-2.0 AND GPL-3.0", want: false},
+		{expression: "MIT AND (BSD-3-Clause OR GPL-3.0)", want: true},
+		{expression: "(MIT AND GPL-3.0) OR LGPL-3.0", want: false},
+		{expression: "Apache-2.0 WITH LLVM-exception", want: true},
+		{expression: "GPL-2.0+", want: true},
+		{expression: NO_ASSERTION, want: false},
+		{expression: "", want: false},
+		{expression: "MIT OR", want: false},
+		{expression: "(MIT", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got := allowlist.Allows(tt.expression)
+			if got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if !ParseAllowlist("").Allows("") {
+		t.Fatal("expected an empty allowlist to allow every license")
+	}
+}
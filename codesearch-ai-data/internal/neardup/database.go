@@ -0,0 +1,235 @@
+package neardup
+
+import (
+	"codesearch-ai-data/internal/database"
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const updateClustersBatchSize = 10_000
+
+type extractedFunctionCode struct {
+	ID        int
+	CleanCode string
+}
+
+// The clean code of an extracted function does not change, so signatures are only computed for new functions.
+// Signatures of deleted functions are deleted with them.
+func newUnsignedExtractedFunctionCodesPaginator(conn *pgx.Conn, pageSize int) *database.Paginator[extractedFunctionCode] {
+	return &database.Paginator[extractedFunctionCode]{
+		Conn:          conn,
+		AfterID:       0,
+		PageSize:      pageSize,
+		BaseQuery:     "SELECT id, clean_code FROM extracted_functions",
+		BaseCondition: "NOT EXISTS (SELECT 1 FROM extracted_function_signatures efs WHERE efs.extracted_function_id = extracted_functions.id)",
+		IDColumn:      "id",
+		ScanRow: func(rows pgx.Rows) (*extractedFunctionCode, error) {
+			efc := &extractedFunctionCode{}
+			err := rows.Scan(&efc.ID, &efc.CleanCode)
+			if err != nil {
+				return nil, err
+			}
+			return efc, nil
+		},
+		GetRowID: func(row *extractedFunctionCode) int { return row.ID },
+	}
+}
+
+const insertExtractedFunctionSignaturesQuery = `
+INSERT INTO extracted_function_signatures (extracted_function_id, signature)
+SELECT * FROM unnest($1::integer[], $2::bytea[])
+ON CONFLICT DO NOTHING
+`
+
+const insertExtractedFunctionBandHashesQuery = `
+INSERT INTO extracted_function_band_hashes (extracted_function_id, band, hash)
+SELECT * FROM unnest($1::integer[], $2::integer[], $3::bigint[])
+ON CONFLICT DO NOTHING
+`
+
+func insertExtractedFunctionSignatures(ctx context.Context, conn *pgx.Conn, extractedFunctionCodes []*extractedFunctionCode) error {
+	ids := make([]int, 0, len(extractedFunctionCodes))
+	signatures := make([][]byte, 0, len(extractedFunctionCodes))
+	bandIDs := make([]int, 0, len(extractedFunctionCodes)*BANDS)
+	bands := make([]int, 0, len(extractedFunctionCodes)*BANDS)
+	bandHashes := make([]int64, 0, len(extractedFunctionCodes)*BANDS)
+	for _, efc := range extractedFunctionCodes {
+		signature := NewSignature(efc.CleanCode)
+		ids = append(ids, efc.ID)
+		signatures = append(signatures, signature.Bytes())
+		for band := 0; band < BANDS; band++ {
+			bandIDs = append(bandIDs, efc.ID)
+			bands = append(bands, band)
+			bandHashes = append(bandHashes, int64(signature.bandHash(band)))
+		}
+	}
+
+	_, err := conn.Exec(ctx, insertExtractedFunctionSignaturesQuery, ids, signatures)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, insertExtractedFunctionBandHashesQuery, bandIDs, bands, bandHashes)
+	return err
+}
+
+func signExtractedFunctions(ctx context.Context, conn *pgx.Conn) error {
+	nSigned := 0
+	paginator := newUnsignedExtractedFunctionCodesPaginator(conn, 10_000)
+	page := paginator.Next(ctx)
+	for len(page) > 0 {
+		err := insertExtractedFunctionSignatures(ctx, conn, page)
+		if err != nil {
+			return err
+		}
+		nSigned += len(page)
+		page = paginator.Next(ctx)
+	}
+	if paginator.Error() != nil {
+		return paginator.Error()
+	}
+	log.Infof("Computed the signatures of %d new extracted functions", nSigned)
+	return nil
+}
+
+// Buckets are the functions sharing a band hash. Functions without a bucket of their own band hashes are not
+// candidates of any other function.
+const extractedFunctionBucketsQuery = `
+SELECT bh.band, bh.hash, efs.extracted_function_id, efs.signature
+FROM extracted_function_band_hashes bh
+JOIN extracted_function_signatures efs ON efs.extracted_function_id = bh.extracted_function_id
+WHERE (bh.band, bh.hash) IN (SELECT band, hash FROM extracted_function_band_hashes GROUP BY band, hash HAVING COUNT(*) > 1)
+ORDER BY bh.band, bh.hash, bh.extracted_function_id
+`
+
+type bucketFunction struct {
+	id        int
+	signature *Signature
+}
+
+// clusterBuckets compares the functions of each bucket, streamed one bucket at a time, so only the clusters of the
+// candidate functions are kept in memory.
+func clusterBuckets(ctx context.Context, conn *pgx.Conn) (unionFind, error) {
+	rows, err := conn.Query(ctx, extractedFunctionBucketsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clusters := unionFind{}
+	bucket := []*bucketFunction{}
+	var bucketBand int
+	var bucketHash int64
+	for rows.Next() {
+		var band, id int
+		var hash int64
+		var signatureBytes []byte
+		err := rows.Scan(&band, &hash, &id, &signatureBytes)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := SignatureFromBytes(signatureBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if band != bucketBand || hash != bucketHash {
+			bucket, bucketBand, bucketHash = bucket[:0], band, hash
+		}
+
+		clusters.add(id)
+		for _, candidate := range bucket {
+			clusters.mergeCandidate(id, signature, candidate.id, candidate.signature)
+		}
+		bucket = append(bucket, &bucketFunction{id, signature})
+	}
+	return clusters, rows.Err()
+}
+
+const updateExtractedFunctionClustersQuery = `
+UPDATE extracted_functions ef SET near_duplicate_cluster_id = c.cluster_id
+FROM unnest($1::integer[], $2::integer[]) AS c (id, cluster_id)
+WHERE ef.id = c.id AND ef.near_duplicate_cluster_id IS DISTINCT FROM c.cluster_id
+`
+
+// Functions that do not share any band hash are in their own cluster.
+const updateUnclusteredExtractedFunctionsQuery = `
+UPDATE extracted_functions ef SET near_duplicate_cluster_id = ef.id
+WHERE ef.near_duplicate_cluster_id IS DISTINCT FROM ef.id
+AND NOT EXISTS (
+	SELECT 1 FROM extracted_function_band_hashes bh
+	JOIN extracted_function_band_hashes other ON other.band = bh.band AND other.hash = bh.hash AND other.extracted_function_id <> bh.extracted_function_id
+	WHERE bh.extracted_function_id = ef.id
+)
+`
+
+const updateCodeQueryPairClustersQuery = `
+UPDATE code_query_pairs cqp SET near_duplicate_cluster_id = ef.near_duplicate_cluster_id
+FROM extracted_functions ef
+WHERE ef.id = cqp.extracted_function_id AND cqp.near_duplicate_cluster_id IS DISTINCT FROM ef.near_duplicate_cluster_id
+`
+
+func updateExtractedFunctionClusters(ctx context.Context, conn *pgx.Conn, clusters map[int]int) error {
+	ids := make([]int, 0, updateClustersBatchSize)
+	clusterIDs := make([]int, 0, updateClustersBatchSize)
+	flush := func() error {
+		if len(ids) == 0 {
+			return nil
+		}
+		_, err := conn.Exec(ctx, updateExtractedFunctionClustersQuery, ids, clusterIDs)
+		ids, clusterIDs = ids[:0], clusterIDs[:0]
+		return err
+	}
+
+	for id, clusterID := range clusters {
+		ids = append(ids, id)
+		clusterIDs = append(clusterIDs, clusterID)
+		if len(ids) == updateClustersBatchSize {
+			err := flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// ClusterExtractedFunctions assigns near-duplicate cluster IDs to every extracted function, and to the code query
+// pairs of the functions. The signatures and band hashes of the functions are stored in the database, and the
+// functions sharing a band hash are compared bucket by bucket, so the signatures are never all loaded in memory.
+// Clusters are recomputed over the whole corpus, cluster IDs stay the same as long as the representative function
+// of a cluster is not deleted.
+func ClusterExtractedFunctions(ctx context.Context, conn *pgx.Conn) error {
+	err := signExtractedFunctions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	candidateClusters, err := clusterBuckets(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	clusters := candidateClusters.clusters()
+	nClusters := 0
+	for id, clusterID := range clusters {
+		if id == clusterID {
+			nClusters++
+		}
+	}
+	log.Infof("Found %d near-duplicate clusters in %d candidate extracted functions", nClusters, len(clusters))
+
+	err = updateExtractedFunctionClusters(ctx, conn, clusters)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(ctx, updateUnclusteredExtractedFunctionsQuery)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(ctx, updateCodeQueryPairClustersQuery)
+	return err
+}
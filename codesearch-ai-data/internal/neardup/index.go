@@ -0,0 +1,93 @@
+package neardup
+
+// unionFind tracks the clusters of functions. The lowest ID of a cluster is its root, so it does not depend on the
+// order in which functions are merged.
+type unionFind map[int]int
+
+func (uf unionFind) add(id int) {
+	if _, ok := uf[id]; !ok {
+		uf[id] = id
+	}
+}
+
+// find returns the root of the cluster, and compresses the path to it.
+func (uf unionFind) find(id int) int {
+	root := id
+	for uf[root] != root {
+		root = uf[root]
+	}
+	for id != root {
+		id, uf[id] = uf[id], root
+	}
+	return root
+}
+
+// union merges two clusters.
+func (uf unionFind) union(a int, b int) {
+	rootA, rootB := uf.find(a), uf.find(b)
+	if rootA == rootB {
+		return
+	}
+	if rootA < rootB {
+		uf[rootB] = rootA
+	} else {
+		uf[rootA] = rootB
+	}
+}
+
+// clusters returns the cluster ID, the root, of every function.
+func (uf unionFind) clusters() map[int]int {
+	clusters := make(map[int]int, len(uf))
+	for id := range uf {
+		clusters[id] = uf.find(id)
+	}
+	return clusters
+}
+
+// mergeCandidate merges the function with the candidate sharing one of its bands if their signatures are similar
+// enough.
+func (uf unionFind) mergeCandidate(id int, signature *Signature, candidateID int, candidateSignature *Signature) {
+	// Skip candidates that are already in the same cluster.
+	if uf.find(candidateID) == uf.find(id) {
+		return
+	}
+	if signature.Similarity(candidateSignature) >= SIMILARITY_THRESHOLD {
+		uf.union(id, candidateID)
+	}
+}
+
+// Index clusters near-duplicate functions in memory as they are added. Functions sharing a band of their signatures
+// are compared, and clusters are merged when their signatures are similar enough, so near-duplicates are transitive.
+type Index struct {
+	bands      [BANDS]map[uint64][]int
+	signatures map[int]*Signature
+	parents    unionFind
+}
+
+func NewIndex() *Index {
+	index := &Index{signatures: map[int]*Signature{}, parents: unionFind{}}
+	for i := range index.bands {
+		index.bands[i] = map[uint64][]int{}
+	}
+	return index
+}
+
+// Add adds the function to the index, and merges it with the clusters of its near-duplicates.
+func (idx *Index) Add(id int, signature *Signature) {
+	idx.signatures[id] = signature
+	idx.parents.add(id)
+
+	for band := range idx.bands {
+		bandHash := signature.bandHash(band)
+		for _, candidateID := range idx.bands[band][bandHash] {
+			idx.parents.mergeCandidate(id, signature, candidateID, idx.signatures[candidateID])
+		}
+		idx.bands[band][bandHash] = append(idx.bands[band][bandHash], id)
+	}
+}
+
+// Clusters returns the cluster ID of every function in the index. The cluster ID is the lowest function ID in the
+// cluster, which is its representative. Functions without near-duplicates are in their own cluster.
+func (idx *Index) Clusters() map[int]int {
+	return idx.parents.clusters()
+}
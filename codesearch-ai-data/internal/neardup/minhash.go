@@ -0,0 +1,139 @@
+package neardup
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// The signatures are split into BANDS bands of ROWS hashes for locality-sensitive hashing. Functions that share a
+// band are candidate duplicates, which happens with a probability of 1-(1-s^ROWS)^BANDS for a Jaccard similarity s.
+// With 16 bands of 8 rows, functions with a similarity of 0.8 share a band 94% of the time and functions with a
+// similarity of 0.5 only 6% of the time.
+const (
+	BANDS      = 16
+	ROWS       = 8
+	NUM_HASHES = BANDS * ROWS
+)
+
+// Number of consecutive tokens in a shingle. Every occurrence of a renamed variable changes SHINGLE_SIZE shingles, so
+// shingles are kept short for functions with renamed variables to stay near-duplicates.
+const SHINGLE_SIZE = 3
+
+// Minimum estimated Jaccard similarity of the shingles of two functions to be near-duplicates.
+const SIMILARITY_THRESHOLD = 0.8
+
+// Signature is the MinHash signature of the token shingles of a function.
+type Signature [NUM_HASHES]uint32
+
+var tokenRegexp = regexp.MustCompile(`[\p{L}_$][\p{L}\p{N}_$]*|\p{N}[\p{L}\p{N}_.]*|"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])*'|` + "`[^`]*`" + `|\S`)
+
+// Literals are replaced with a placeholder token, so functions that only differ by a literal are identical.
+const LITERAL_TOKEN = "<lit>"
+
+// Tokenize splits code into identifier, literal and punctuation tokens. It does not depend on the language of the
+// code, which is good enough to compare functions.
+func Tokenize(code string) []string {
+	tokens := tokenRegexp.FindAllString(code, -1)
+	for i, token := range tokens {
+		switch token[0] {
+		case '"', '\'', '`', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			tokens[i] = LITERAL_TOKEN
+		}
+	}
+	return tokens
+}
+
+func hashShingle(tokens []string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(strings.Join(tokens, "\x00")))
+	return hasher.Sum64()
+}
+
+// splitmix64 scrambles the shingle hash with the seed of each MinHash function.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+var hashSeeds = func() [NUM_HASHES]uint64 {
+	seeds := [NUM_HASHES]uint64{}
+	for i := range seeds {
+		seeds[i] = splitmix64(uint64(i + 1))
+	}
+	return seeds
+}()
+
+// NewSignature computes the MinHash signature of the code. Code shorter than a shingle is a single shingle.
+func NewSignature(code string) *Signature {
+	tokens := Tokenize(code)
+
+	signature := &Signature{}
+	for i := range signature {
+		signature[i] = ^uint32(0)
+	}
+
+	addShingle := func(shingle []string) {
+		shingleHash := hashShingle(shingle)
+		for i, seed := range hashSeeds {
+			if h := uint32(splitmix64(shingleHash ^ seed)); h < signature[i] {
+				signature[i] = h
+			}
+		}
+	}
+
+	if len(tokens) < SHINGLE_SIZE {
+		addShingle(tokens)
+		return signature
+	}
+	for i := 0; i+SHINGLE_SIZE <= len(tokens); i++ {
+		addShingle(tokens[i : i+SHINGLE_SIZE])
+	}
+	return signature
+}
+
+// Similarity estimates the Jaccard similarity of the shingles of two signatures.
+func (s *Signature) Similarity(other *Signature) float64 {
+	equal := 0
+	for i := range s {
+		if s[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / NUM_HASHES
+}
+
+func (s *Signature) bandHash(band int) uint64 {
+	hasher := fnv.New64a()
+	b := make([]byte, 4)
+	for _, h := range s[band*ROWS : (band+1)*ROWS] {
+		b[0], b[1], b[2], b[3] = byte(h), byte(h>>8), byte(h>>16), byte(h>>24)
+		hasher.Write(b)
+	}
+	return hasher.Sum64()
+}
+
+// Bytes encodes the signature, as stored in the database.
+func (s *Signature) Bytes() []byte {
+	b := make([]byte, 4*NUM_HASHES)
+	for i, h := range s {
+		binary.LittleEndian.PutUint32(b[4*i:], h)
+	}
+	return b
+}
+
+// SignatureFromBytes decodes a signature encoded with Bytes.
+func SignatureFromBytes(b []byte) (*Signature, error) {
+	if len(b) != 4*NUM_HASHES {
+		return nil, fmt.Errorf("invalid signature of %d bytes", len(b))
+	}
+	signature := &Signature{}
+	for i := range signature {
+		signature[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return signature, nil
+}
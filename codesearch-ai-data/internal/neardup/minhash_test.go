@@ -0,0 +1,108 @@
+package neardup
+
+import (
+	"reflect"
+	"testing"
+)
+
+const loadConfig = `func loadConfig(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening config %s: %w", path, err)
+	}
+	defer file.Close()
+
+	config := &Config{Retries: 3, Timeout: 30}
+	err = json.NewDecoder(file).Decode(config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}`
+
+// Renamed variable and different literals.
+const loadConfigRenamed = `func loadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	config := &Config{Retries: 5, Timeout: 60}
+	err = json.NewDecoder(f).Decode(config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}`
+
+const sumPositive = `func sumPositive(values []int) int {
+	total := 0
+	for _, value := range values {
+		if value > 0 {
+			total += value
+		}
+	}
+	return total
+}`
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize(`if (count > 10) { log("too many", count) }`)
+	want := []string{"if", "(", "count", ">", LITERAL_TOKEN, ")", "{", "log", "(", LITERAL_TOKEN, ",", "count", ")", "}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSignatureSimilarity(t *testing.T) {
+	tests := []struct {
+		name          string
+		a             string
+		b             string
+		wantDuplicate bool
+	}{
+		{name: "identical", a: loadConfig, b: loadConfig, wantDuplicate: true},
+		{name: "literals only", a: `x := compute(1, "a")`, b: `x := compute(2, "b")`, wantDuplicate: true},
+		{name: "renamed variable", a: loadConfig, b: loadConfigRenamed, wantDuplicate: true},
+		{name: "different functions", a: loadConfig, b: sumPositive, wantDuplicate: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			similarity := NewSignature(tt.a).Similarity(NewSignature(tt.b))
+			if (similarity >= SIMILARITY_THRESHOLD) != tt.wantDuplicate {
+				t.Fatalf("unexpected similarity %f", similarity)
+			}
+		})
+	}
+}
+
+func TestIndexClusters(t *testing.T) {
+	index := NewIndex()
+	index.Add(7, NewSignature(loadConfigRenamed))
+	index.Add(2, NewSignature(sumPositive))
+	index.Add(4, NewSignature(loadConfig))
+	index.Add(9, NewSignature(loadConfig))
+
+	got := index.Clusters()
+	want := map[int]int{2: 2, 4: 4, 7: 4, 9: 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSignatureBytes(t *testing.T) {
+	signature := NewSignature(loadConfig)
+	got, err := SignatureFromBytes(signature.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *signature {
+		t.Fatal("expected the decoded signature to equal the encoded signature")
+	}
+
+	_, err = SignatureFromBytes([]byte{1, 2, 3})
+	if err == nil {
+		t.Fatal("expected an error for a truncated signature")
+	}
+}
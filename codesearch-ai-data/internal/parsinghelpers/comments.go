@@ -6,7 +6,7 @@ import (
 	sitter "github.com/smacker/go-tree-sitter"
 )
 
-var commentPrefixes = []string{"/**", "/*", "*", "#", "//", "\"\"\"", "\""}
+var commentPrefixes = []string{"/**", "/*!", "/*", "*", "#", "///", "//!", "//", "\"\"\"", "\""}
 var commentSuffixes = []string{"*/", "\"\"\"", "\""}
 
 func isCommentNode(node *sitter.Node) bool {
@@ -14,7 +14,8 @@ func isCommentNode(node *sitter.Node) bool {
 	return nodeType == "comment" || nodeType == "line_comment" || nodeType == "block_comment"
 }
 
-func StripCommentDelimiters(comment string) string {
+// stripCommentLines strips the comment delimiters and the surrounding whitespace of each line of the comment.
+func stripCommentLines(comment string) []string {
 	commentLines := strings.Split(comment, "\n")
 	strippedCommentLines := make([]string, 0, len(commentLines))
 	for _, commentLine := range commentLines {
@@ -32,14 +33,26 @@ func StripCommentDelimiters(comment string) string {
 			}
 		}
 
-		trimmedLine = strings.TrimSpace(trimmedLine)
+		strippedCommentLines = append(strippedCommentLines, strings.TrimSpace(trimmedLine))
+	}
+	return strippedCommentLines
+}
 
-		if len(trimmedLine) > 0 {
-			strippedCommentLines = append(strippedCommentLines, trimmedLine)
+func StripCommentDelimiters(comment string) string {
+	commentLines := stripCommentLines(comment)
+	nonEmptyCommentLines := make([]string, 0, len(commentLines))
+	for _, commentLine := range commentLines {
+		if len(commentLine) > 0 {
+			nonEmptyCommentLines = append(nonEmptyCommentLines, commentLine)
 		}
 	}
+	return strings.Join(nonEmptyCommentLines, " ")
+}
 
-	return strings.Join(strippedCommentLines, " ")
+// StripDocCommentDelimiters strips the comment delimiters like StripCommentDelimiters, but keeps the lines of the
+// comment. Empty lines separate the paragraphs of docstrings.
+func StripDocCommentDelimiters(comment string) string {
+	return strings.Join(stripCommentLines(comment), "\n")
 }
 
 func StripCommentNodesDelimiters(commentNodes []*sitter.Node, code []byte) []string {
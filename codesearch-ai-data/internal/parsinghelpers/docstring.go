@@ -2,7 +2,9 @@ package parsinghelpers
 
 import (
 	"errors"
+	"html"
 	"io"
+	"regexp"
 	"strings"
 
 	sitter "github.com/smacker/go-tree-sitter"
@@ -15,6 +17,7 @@ func areNodeLinesConsecutive(prevNode *sitter.Node, currentNode *sitter.Node) bo
 	return currentNodeStartRow-1 == prevNodeEndRow
 }
 
+// GetPrecedingFunctionDocstring returns the consecutive comments preceding the function, one line per comment line.
 func GetPrecedingFunctionDocstring(functionNode *sitter.Node, sourceCode []byte) string {
 	if functionNode == nil {
 		return ""
@@ -31,7 +34,7 @@ func GetPrecedingFunctionDocstring(functionNode *sitter.Node, sourceCode []byte)
 	commentType := prevNode.Type()
 	comments := []string{}
 	for prevNode != nil && prevNode.Type() == commentType {
-		comment := StripCommentDelimiters(prevNode.Content(sourceCode))
+		comment := StripDocCommentDelimiters(prevNode.Content(sourceCode))
 		// Prepend comment to existing comments since we are traversing in the reverse order (bottom up).
 		comments = append([]string{comment}, comments...)
 
@@ -43,7 +46,74 @@ func GetPrecedingFunctionDocstring(functionNode *sitter.Node, sourceCode []byte)
 		}
 		prevNode = prevPrevNode
 	}
-	return strings.Join(comments, " ")
+	return strings.Join(comments, "\n")
+}
+
+func isRustDocComment(comment string) bool {
+	return strings.HasPrefix(comment, "///") ||
+		strings.HasPrefix(comment, "//!") ||
+		strings.HasPrefix(comment, "/**") ||
+		strings.HasPrefix(comment, "/*!")
+}
+
+// GetPrecedingRustDocstring only considers doc comments (`///`, `//!`, `/** */` and `/*! */`) and skips
+// attributes placed between the doc comments and the function.
+func GetPrecedingRustDocstring(functionNode *sitter.Node, sourceCode []byte) string {
+	if functionNode == nil {
+		return ""
+	}
+
+	currentNode := functionNode
+	prevNode := currentNode.PrevNamedSibling()
+	for prevNode != nil && prevNode.Type() == "attribute_item" && areNodeLinesConsecutive(prevNode, currentNode) {
+		currentNode, prevNode = prevNode, prevNode.PrevNamedSibling()
+	}
+
+	comments := []string{}
+	for prevNode != nil && isCommentNode(prevNode) && areNodeLinesConsecutive(prevNode, currentNode) {
+		comment := prevNode.Content(sourceCode)
+		if !isRustDocComment(comment) {
+			break
+		}
+		// Prepend comment to existing comments since we are traversing in the reverse order (bottom up).
+		comments = append([]string{StripDocCommentDelimiters(comment)}, comments...)
+		currentNode, prevNode = prevNode, prevNode.PrevNamedSibling()
+	}
+	return strings.Join(comments, "\n")
+}
+
+// GetPrecedingKDocDocstring only considers the KDoc comment (`/** */`) directly preceding the function. Annotations
+// are part of the function node in Kotlin.
+func GetPrecedingKDocDocstring(functionNode *sitter.Node, sourceCode []byte) string {
+	if functionNode == nil {
+		return ""
+	}
+
+	prevNode := functionNode.PrevNamedSibling()
+	if prevNode == nil || !isCommentNode(prevNode) || !areNodeLinesConsecutive(prevNode, functionNode) {
+		return ""
+	}
+
+	comment := prevNode.Content(sourceCode)
+	if !strings.HasPrefix(comment, "/**") {
+		return ""
+	}
+	return StripDocCommentDelimiters(comment)
+}
+
+var xmlDocSummaryRegexp = regexp.MustCompile(`(?s)<summary>(.*?)</summary>`)
+var xmlDocReferenceRegexp = regexp.MustCompile(`<(?:see|seealso|paramref|typeparamref)\s+(?:cref|name|langword)="(?:[A-Z]:)?([^"]*)"\s*/>`)
+var xmlDocTagRegexp = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+
+// GetXMLDocCommentText converts a C# XML doc comment (with the comment delimiters already stripped) into plain text.
+// Only the summary is kept if present, and references such as <see cref="Foo"/> are replaced by their target.
+func GetXMLDocCommentText(docComment string) string {
+	if summaryMatch := xmlDocSummaryRegexp.FindStringSubmatch(docComment); summaryMatch != nil {
+		docComment = summaryMatch[1]
+	}
+	docComment = xmlDocReferenceRegexp.ReplaceAllString(docComment, "$1")
+	docComment = xmlDocTagRegexp.ReplaceAllString(docComment, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(docComment)), " ")
 }
 
 func SkipPythonDocstringNodesFn(docstringNodes []*sitter.Node) SkipNodeFn {
@@ -113,5 +183,5 @@ func GetPythonDocstring(docstringNode *sitter.Node, code []byte) string {
 	if docstringNode == nil {
 		return ""
 	}
-	return StripCommentDelimiters(docstringNode.Content(code))
+	return StripDocCommentDelimiters(docstringNode.Content(code))
 }
@@ -0,0 +1,33 @@
+package parsinghelpers
+
+import sitter "github.com/smacker/go-tree-sitter"
+
+// ParseErrors counts the syntax errors of a tree. Each ERROR node is a region the parser could not make sense of,
+// and each MISSING node a token the parser inserted to recover.
+type ParseErrors struct {
+	ErrorNodes   int `json:"errorNodes"`
+	MissingNodes int `json:"missingNodes"`
+}
+
+// CountParseErrors counts the syntax errors under the node. Subtrees without errors are not visited.
+func CountParseErrors(node *sitter.Node) ParseErrors {
+	parseErrors := ParseErrors{}
+	countParseErrors(node, &parseErrors)
+	return parseErrors
+}
+
+func countParseErrors(node *sitter.Node, parseErrors *ParseErrors) {
+	if node.IsMissing() {
+		parseErrors.MissingNodes++
+		return
+	}
+	if node.IsError() {
+		parseErrors.ErrorNodes++
+	}
+	if !node.HasError() {
+		return
+	}
+	for i := 0; i < int(node.ChildCount()); i++ {
+		countParseErrors(node.Child(i), parseErrors)
+	}
+}
@@ -8,6 +8,17 @@ import (
 
 type SkipNodeFn func(node *sitter.Node) bool
 
+// hasHiddenTokens returns true if the children of the node do not cover the start or the end of the node, e.g. the
+// `?` of Kotlin nullable types, which the grammar does not expose as a node. Only single line nodes are checked, nodes
+// spanning lines (e.g. Python and Ruby programs) can end with whitespace.
+func hasHiddenTokens(node *sitter.Node) bool {
+	childCount := int(node.ChildCount())
+	if childCount == 0 || node.StartPoint().Row != node.EndPoint().Row {
+		return false
+	}
+	return node.Child(0).StartByte() > node.StartByte() || node.Child(childCount-1).EndByte() < node.EndByte()
+}
+
 func StripComments(rootNode *sitter.Node, skipNodeFn SkipNodeFn) ([]*sitter.Node, []*sitter.Node) {
 	leafNodes := []*sitter.Node{}
 	nodesToVisit := []*sitter.Node{rootNode}
@@ -18,8 +29,8 @@ func StripComments(rootNode *sitter.Node, skipNodeFn SkipNodeFn) ([]*sitter.Node
 
 		// We consider string types as leaf nodes since they can contain further children (e.g. interpolation)
 		// but tree-sitter does not provide a way to iterate over string "parts". So we have to do a separate check
-		// for string types.
-		if strings.Contains(currentNode.Type(), "string") || currentNode.ChildCount() == 0 {
+		// for string types. Nodes with hidden tokens are leaves too, since their children do not cover their text.
+		if strings.Contains(currentNode.Type(), "string") || currentNode.ChildCount() == 0 || hasHiddenTokens(currentNode) {
 			leafNodes = append(leafNodes, currentNode)
 			continue
 		}
@@ -1,50 +1,63 @@
-package parsinghelpers
+package parsinghelpers_test
 
 import (
-	sp "codesearch-ai-data/internal/sitterparsers"
+	"codesearch-ai-data/internal/languages"
+	ph "codesearch-ai-data/internal/parsinghelpers"
 	"io/ioutil"
 	"strings"
 	"testing"
 
 	"github.com/hexops/autogold"
-	sitter "github.com/smacker/go-tree-sitter"
 )
 
 func TestStripComments(t *testing.T) {
 	tests := []struct {
-		name   string
-		path   string
-		parser *sitter.Parser
+		name string
+		path string
 	}{
 		{
-			name:   "RubyStripComments",
-			path:   "../testdata/test.rb",
-			parser: sp.GetRubyParser(),
+			name: "RubyStripComments",
+			path: "../testdata/test.rb",
 		},
 		{
-			name:   "GoStripComments",
-			path:   "../testdata/test.go",
-			parser: sp.GetGoParser(),
+			name: "GoStripComments",
+			path: "../testdata/test.go",
 		},
 		{
-			name:   "PythonStripComments",
-			path:   "../testdata/test.py",
-			parser: sp.GetPythonParser(),
+			name: "PythonStripComments",
+			path: "../testdata/test.py",
 		},
 		{
-			name:   "JavascriptStripComments",
-			path:   "../testdata/test.js",
-			parser: sp.GetJavascriptParser(),
+			name: "JavascriptStripComments",
+			path: "../testdata/test.js",
 		},
 		{
-			name:   "JavaStripComments",
-			path:   "../testdata/test.java",
-			parser: sp.GetJavaParser(),
+			name: "JavaStripComments",
+			path: "../testdata/test.java",
 		},
 		{
-			name:   "PhpStripComments",
-			path:   "../testdata/test.php",
-			parser: sp.GetPhpParser(),
+			name: "PhpStripComments",
+			path: "../testdata/test.php",
+		},
+		{
+			name: "TypescriptStripComments",
+			path: "../testdata/test.ts",
+		},
+		{
+			name: "RustStripComments",
+			path: "../testdata/test.rs",
+		},
+		{
+			name: "CppStripComments",
+			path: "../testdata/test.cpp",
+		},
+		{
+			name: "CsharpStripComments",
+			path: "../testdata/test.cs",
+		},
+		{
+			name: "KotlinStripComments",
+			path: "../testdata/test.kt",
 		},
 	}
 
@@ -55,25 +68,21 @@ func TestStripComments(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			tree := tt.parser.Parse(nil, testCode)
+			language := languages.ForFile(tt.path)
+			tree := language.NewParserForFile(tt.path).Parse(nil, testCode)
 			rootNode := tree.RootNode()
 			if rootNode.HasError() {
 				t.Fatal("Error encountered while parsing")
 			}
 
-			// TODO: Refactor
-			var skipNodeFn SkipNodeFn = nil
-			if tt.name == "PythonStripComments" {
-				docstringNodes, err := GetPythonDocstringNodes(rootNode)
-				if err != nil {
-					t.Fatal(err)
-				}
-				skipNodeFn = SkipPythonDocstringNodesFn(docstringNodes)
+			skipNodeFn, err := language.GetSkipNodeFn(rootNode)
+			if err != nil {
+				t.Fatal(err)
 			}
 
-			filteredNodes, commentNodes := StripComments(rootNode, skipNodeFn)
-			prettyFormattedCode := PrettyFormatNodes(filteredNodes, testCode)
-			comments := StripCommentNodesDelimiters(commentNodes, testCode)
+			filteredNodes, commentNodes := ph.StripComments(rootNode, skipNodeFn)
+			prettyFormattedCode := ph.PrettyFormatNodes(filteredNodes, testCode)
+			comments := ph.StripCommentNodesDelimiters(commentNodes, testCode)
 
 			autogold.Equal(t, prettyFormattedCode+"\n\n"+strings.Join(comments, "\n"))
 		})
@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/xml"
 	"errors"
-	"fmt"
 	"html"
 
 	"os"
@@ -13,16 +12,14 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
-	"codesearch-ai-data/internal/database"
-
-	"github.com/jackc/pgx/v4"
+	"codesearch-ai-data/internal/storage"
 )
 
 const TIMESTAMP_LAYOUT = "2006-01-02T15:04:05.000"
 const MAX_LINE_LENGTH = 1024 * 1024
 const BATCH_SIZE = 1024
 
-func Import(ctx context.Context, conn *pgx.Conn, postsXmlPath string) error {
+func Import(ctx context.Context, store storage.Store, postsXmlPath string) error {
 	if _, err := os.Stat(postsXmlPath); errors.Is(err, os.ErrNotExist) {
 		return err
 	}
@@ -37,8 +34,8 @@ func Import(ctx context.Context, conn *pgx.Conn, postsXmlPath string) error {
 	buf := make([]byte, MAX_LINE_LENGTH)
 	scanner.Buffer(buf, MAX_LINE_LENGTH)
 
-	questionsBuffer := make([]*SOQuestion, 0, BATCH_SIZE)
-	answersBuffer := make([]*SOAnswer, 0, BATCH_SIZE)
+	questionsBuffer := make([]*storage.SOQuestion, 0, BATCH_SIZE)
+	answersBuffer := make([]*storage.SOAnswer, 0, BATCH_SIZE)
 
 	rowNumber := 0
 	for scanner.Scan() {
@@ -77,14 +74,14 @@ func Import(ctx context.Context, conn *pgx.Conn, postsXmlPath string) error {
 			}
 
 			if len(questionsBuffer) == BATCH_SIZE {
-				err = importQuestions(ctx, conn, questionsBuffer)
+				err = store.InsertSOQuestions(ctx, questionsBuffer)
 				if err != nil {
 					return err
 				}
 				questionsBuffer = questionsBuffer[:0]
 			}
 
-			questionsBuffer = append(questionsBuffer, &SOQuestion{
+			questionsBuffer = append(questionsBuffer, &storage.SOQuestion{
 				ID:               row.ID,
 				Title:            stringOrEmpty(row.Title),
 				Tags:             stringOrEmpty(row.Tags),
@@ -100,14 +97,14 @@ func Import(ctx context.Context, conn *pgx.Conn, postsXmlPath string) error {
 			}
 
 			if len(answersBuffer) == BATCH_SIZE {
-				err = importAnswers(ctx, conn, answersBuffer)
+				err = store.InsertSOAnswers(ctx, answersBuffer)
 				if err != nil {
 					return err
 				}
 				answersBuffer = answersBuffer[:0]
 			}
 
-			answersBuffer = append(answersBuffer, &SOAnswer{
+			answersBuffer = append(answersBuffer, &storage.SOAnswer{
 				ID:           row.ID,
 				Body:         row.Body,
 				Score:        row.Score,
@@ -118,12 +115,12 @@ func Import(ctx context.Context, conn *pgx.Conn, postsXmlPath string) error {
 		}
 	}
 
-	err = importQuestions(ctx, conn, questionsBuffer)
+	err = store.InsertSOQuestions(ctx, questionsBuffer)
 	if err != nil {
 		return err
 	}
 
-	err = importAnswers(ctx, conn, answersBuffer)
+	err = store.InsertSOAnswers(ctx, answersBuffer)
 	if err != nil {
 		return err
 	}
@@ -135,40 +132,6 @@ func Import(ctx context.Context, conn *pgx.Conn, postsXmlPath string) error {
 	return nil
 }
 
-func importQuestions(ctx context.Context, conn *pgx.Conn, questions []*SOQuestion) error {
-	if len(questions) == 0 {
-		return nil
-	}
-
-	insertValuesParameters, valuesArgs := database.PrepareValuesForBulkInsert(questions, 7, func(valueArgs []any, question *SOQuestion) []any {
-		return append(valueArgs, question.ID, question.Title, question.Tags, question.Score, question.AcceptedAnswerID, question.CreationDate, question.LastEditDate)
-	})
-
-	_, err := conn.Exec(
-		ctx,
-		fmt.Sprintf("INSERT INTO so_questions (id, title, tags, score, accepted_answer_id, creation_date, last_edit_date) VALUES %s", insertValuesParameters),
-		valuesArgs...,
-	)
-	return err
-}
-
-func importAnswers(ctx context.Context, conn *pgx.Conn, answers []*SOAnswer) error {
-	if len(answers) == 0 {
-		return nil
-	}
-
-	insertValuesParameters, valuesArgs := database.PrepareValuesForBulkInsert(answers, 6, func(valueArgs []any, answer *SOAnswer) []any {
-		return append(valueArgs, answer.ID, answer.Body, answer.Score, answer.ParentID, answer.CreationDate, answer.LastEditDate)
-	})
-
-	_, err := conn.Exec(
-		ctx,
-		fmt.Sprintf("INSERT INTO so_answers (id, body, score, parent_id, creation_date, last_edit_date) VALUES %s", insertValuesParameters),
-		valuesArgs...,
-	)
-	return err
-}
-
 func intOrZero(i *int) int {
 	if i == nil {
 		return 0
@@ -13,22 +13,3 @@ type SOPostRow struct {
 	CreationDate     string  `xml:"CreationDate,attr"`
 	LastEditDate     string  `xml:"LastEditDate,attr"`
 }
-
-type SOQuestion struct {
-	ID               int
-	Title            string
-	Tags             string
-	Score            int
-	AcceptedAnswerID *int
-	CreationDate     string
-	LastEditDate     string
-}
-
-type SOAnswer struct {
-	ID           int
-	Body         string
-	Score        int
-	ParentID     int
-	CreationDate string
-	LastEditDate string
-}
@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"codesearch-ai-data/internal/database"
+	"codesearch-ai-data/internal/web"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// PostgresStore is the store of a Postgres database.
+type PostgresStore struct {
+	conn *pgx.Conn
+}
+
+func NewPostgresStore(conn *pgx.Conn) *PostgresStore {
+	return &PostgresStore{conn: conn}
+}
+
+func (s *PostgresStore) InitializeSchema(ctx context.Context) error {
+	return database.InitializeDatabaseSchema(ctx, s.conn)
+}
+
+func (s *PostgresStore) ResetSchema(ctx context.Context) error {
+	return database.ResetDatabaseSchema(ctx, s.conn)
+}
+
+func (s *PostgresStore) Close(ctx context.Context) error {
+	return s.conn.Close(ctx)
+}
+
+// The location links to the tracked snapshot if it contains the function, and to the latest snapshot otherwise.
+// The license is the one of the linked location. Revisions are the refs of the snapshots containing the function, or the commit for the tracked snapshot.
+// Used by lists up to five callers of the function resolved in its repo.
+const extractedFunctionsWithRepoQuery = `SELECT extracted_functions.id, r.name, COALESCE(latest.commit_id, r.commit_id), COALESCE(latest.path, extracted_functions.path), COALESCE(latest.start_line, extracted_functions.start_line), COALESCE(latest.end_line, extracted_functions.end_line), extracted_functions.signature, extracted_functions.qualified_identifier, COALESCE(latest.license, ''), extracted_functions.role,
+ARRAY(
+	SELECT CASE WHEN s.ref = '' THEN s.commit_id ELSE s.ref END
+	FROM extracted_function_snapshots efs
+	JOIN repo_snapshots s ON s.id = efs.snapshot_id
+	WHERE efs.extracted_function_id = extracted_functions.id
+	GROUP BY s.id
+	ORDER BY s.id
+),
+ARRAY(
+	SELECT COALESCE(NULLIF(caller.qualified_identifier, ''), caller.identifier)
+	FROM extracted_function_calls efc
+	JOIN extracted_functions caller ON caller.id = efc.caller_id
+	WHERE efc.callee_id = extracted_functions.id AND efc.caller_id <> extracted_functions.id
+	GROUP BY caller.id
+	ORDER BY caller.id
+	LIMIT 5
+)
+FROM extracted_functions
+LEFT JOIN repos r ON r.id = extracted_functions.repo_id
+LEFT JOIN LATERAL (
+	SELECT s.commit_id, efs.path, efs.start_line, efs.end_line, COALESCE(NULLIF(efs.license, ''), s.license) AS license
+	FROM extracted_function_snapshots efs
+	JOIN repo_snapshots s ON s.id = efs.snapshot_id
+	WHERE efs.extracted_function_id = extracted_functions.id
+	ORDER BY s.ref = '' DESC, s.id DESC
+	LIMIT 1
+) latest ON true
+WHERE extracted_functions.id = ANY ($1)`
+
+func (s *PostgresStore) GetExtractedFunctionsByID(ctx context.Context, ids []int) ([]*web.HighlightedExtractedFunction, error) {
+	rows, err := s.conn.Query(ctx, extractedFunctionsWithRepoQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	fns, err := database.ScanRows(ctx, rows, func(rows pgx.Rows) (*web.HighlightedExtractedFunction, error) {
+		hef := &web.HighlightedExtractedFunction{}
+		err := rows.Scan(
+			&hef.ID,
+			&hef.RepositoryName,
+			&hef.CommitID,
+			&hef.FilePath,
+			&hef.StartLine,
+			&hef.EndLine,
+			&hef.Signature,
+			&hef.QualifiedIdentifier,
+			&hef.License,
+			&hef.Role,
+			&hef.Revisions,
+			&hef.UsedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		hef.URL = sourcegraphURL(hef.RepositoryName, hef.CommitID, hef.FilePath, hef.StartLine, hef.EndLine)
+		return hef, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderByIDs(fns, ids, func(hef *web.HighlightedExtractedFunction) int { return hef.ID }), nil
+}
+
+const extractedTypesWithRepoQuery = `SELECT extracted_types.id, r.name, COALESCE(latest.commit_id, r.commit_id), COALESCE(latest.path, extracted_types.path), COALESCE(latest.start_line, extracted_types.start_line), COALESCE(latest.end_line, extracted_types.end_line), extracted_types.kind, extracted_types.identifier, extracted_types.members, COALESCE(latest.license, ''),
+ARRAY(
+	SELECT CASE WHEN s.ref = '' THEN s.commit_id ELSE s.ref END
+	FROM extracted_type_snapshots ets
+	JOIN repo_snapshots s ON s.id = ets.snapshot_id
+	WHERE ets.extracted_type_id = extracted_types.id
+	GROUP BY s.id
+	ORDER BY s.id
+)
+FROM extracted_types
+LEFT JOIN repos r ON r.id = extracted_types.repo_id
+LEFT JOIN LATERAL (
+	SELECT s.commit_id, ets.path, ets.start_line, ets.end_line, COALESCE(NULLIF(ets.license, ''), s.license) AS license
+	FROM extracted_type_snapshots ets
+	JOIN repo_snapshots s ON s.id = ets.snapshot_id
+	WHERE ets.extracted_type_id = extracted_types.id
+	ORDER BY s.ref = '' DESC, s.id DESC
+	LIMIT 1
+) latest ON true
+WHERE extracted_types.id = ANY ($1)`
+
+func (s *PostgresStore) GetExtractedTypesByID(ctx context.Context, ids []int) ([]*web.HighlightedExtractedType, error) {
+	rows, err := s.conn.Query(ctx, extractedTypesWithRepoQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	types, err := database.ScanRows(ctx, rows, func(rows pgx.Rows) (*web.HighlightedExtractedType, error) {
+		het := &web.HighlightedExtractedType{}
+		var members string
+		err := rows.Scan(
+			&het.ID,
+			&het.RepositoryName,
+			&het.CommitID,
+			&het.FilePath,
+			&het.StartLine,
+			&het.EndLine,
+			&het.Kind,
+			&het.Identifier,
+			&members,
+			&het.License,
+			&het.Revisions,
+		)
+		if err != nil {
+			return nil, err
+		}
+		het.Members = strings.Fields(members)
+		het.URL = sourcegraphURL(het.RepositoryName, het.CommitID, het.FilePath, het.StartLine, het.EndLine)
+		return het, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderByIDs(types, ids, func(het *web.HighlightedExtractedType) int { return het.ID }), nil
+}
+
+func (s *PostgresStore) InsertSOQuestions(ctx context.Context, questions []*SOQuestion) error {
+	if len(questions) == 0 {
+		return nil
+	}
+
+	insertValuesParameters, valuesArgs := database.PrepareValuesForBulkInsert(questions, 7, func(valueArgs []any, question *SOQuestion) []any {
+		return append(valueArgs, question.ID, question.Title, question.Tags, question.Score, question.AcceptedAnswerID, question.CreationDate, question.LastEditDate)
+	})
+
+	_, err := s.conn.Exec(
+		ctx,
+		fmt.Sprintf("INSERT INTO so_questions (id, title, tags, score, accepted_answer_id, creation_date, last_edit_date) VALUES %s", insertValuesParameters),
+		valuesArgs...,
+	)
+	return err
+}
+
+func (s *PostgresStore) InsertSOAnswers(ctx context.Context, answers []*SOAnswer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+
+	insertValuesParameters, valuesArgs := database.PrepareValuesForBulkInsert(answers, 6, func(valueArgs []any, answer *SOAnswer) []any {
+		return append(valueArgs, answer.ID, answer.Body, answer.Score, answer.ParentID, answer.CreationDate, answer.LastEditDate)
+	})
+
+	_, err := s.conn.Exec(
+		ctx,
+		fmt.Sprintf("INSERT INTO so_answers (id, body, score, parent_id, creation_date, last_edit_date) VALUES %s", insertValuesParameters),
+		valuesArgs...,
+	)
+	return err
+}
+
+const soQuestionsWithAnswersQuery = `SELECT so_questions.id, so_questions.title, so_questions.tags, so_questions.score, so_questions.creation_date, json_agg(sa order by sa.score desc)
+FROM so_questions
+LEFT JOIN so_answers sa on so_questions.id = sa.parent_id
+WHERE so_questions.id = ANY($1)
+GROUP BY so_questions.id`
+
+func (s *PostgresStore) GetSOQuestionsWithAnswersByID(ctx context.Context, ids []int) ([]*web.SOQuestionWithAnswers, error) {
+	rows, err := s.conn.Query(ctx, soQuestionsWithAnswersQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	qs, err := database.ScanRows(ctx, rows, func(rows pgx.Rows) (*web.SOQuestionWithAnswers, error) {
+		sq := &web.SOQuestionWithAnswers{}
+		err := rows.Scan(
+			&sq.ID,
+			&sq.Title,
+			&sq.Tags,
+			&sq.Score,
+			&sq.CreationDate,
+			&sq.Answers,
+		)
+		if err != nil {
+			return nil, err
+		}
+		formatSOQuestion(sq)
+		return sq, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderByIDs(qs, ids, func(sq *web.SOQuestionWithAnswers) int { return sq.ID }), nil
+}
+
+func (s *PostgresStore) InsertCodeQueryPairs(ctx context.Context, pairs []*CodeQueryPair) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	insertValuesParameters, valuesArgs := database.PrepareValuesForBulkInsert(deduplicateCodeQueryPairs(pairs), 8, func(valueArgs []any, cqp *CodeQueryPair) []any {
+		return append(valueArgs, cqp.Code, cqp.CodeHash, cqp.Query, cqp.IsTrain, cqp.SOQuestionID, cqp.ExtractedFunctionID, cqp.ExtractedTypeID, cqp.NearDuplicateClusterID)
+	})
+
+	_, err := s.conn.Exec(
+		ctx,
+		fmt.Sprintf("INSERT INTO code_query_pairs (code, code_hash, query, is_train, so_question_id, extracted_function_id, extracted_type_id, near_duplicate_cluster_id) VALUES %s ON CONFLICT (code_hash) DO NOTHING", insertValuesParameters),
+		valuesArgs...,
+	)
+	return err
+}
+
+func (s *PostgresStore) GetCodeQueryPairsPage(ctx context.Context, afterID int, pageSize int) ([]*CodeQueryPair, error) {
+	return database.GetRowsPage(ctx, s.conn, codeQueryPairsQuery, "", "", "id", afterID, pageSize, func(rows pgx.Rows) (*CodeQueryPair, error) {
+		cqp := &CodeQueryPair{}
+		err := rows.Scan(&cqp.ID, &cqp.Code, &cqp.CodeHash, &cqp.Query, &cqp.IsTrain, &cqp.SOQuestionID, &cqp.ExtractedFunctionID, &cqp.ExtractedTypeID, &cqp.NearDuplicateClusterID)
+		if err != nil {
+			return nil, err
+		}
+		return cqp, nil
+	})
+}
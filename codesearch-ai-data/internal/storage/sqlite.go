@@ -0,0 +1,631 @@
+package storage
+
+import (
+	"codesearch-ai-data/internal/web"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLITE_SCHEMA_UP is the Postgres schema in the SQLite dialect.
+const SQLITE_SCHEMA_UP = `
+CREATE TABLE so_questions (
+    id integer NOT NULL PRIMARY KEY,
+    title text NOT NULL,
+    tags text NOT NULL,
+    score integer NOT NULL,
+    accepted_answer_id integer,
+    creation_date text NOT NULL,
+    last_edit_date text NOT NULL
+);
+
+CREATE TABLE so_answers (
+    id integer NOT NULL PRIMARY KEY,
+    body text NOT NULL,
+    score integer NOT NULL,
+    parent_id integer NOT NULL,
+    creation_date text NOT NULL,
+    last_edit_date text NOT NULL
+);
+
+CREATE INDEX so_answers_parent_id_idx ON so_answers (parent_id);
+
+CREATE TABLE repos (
+    id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+    commit_id text NOT NULL,
+    name text NOT NULL UNIQUE,
+    is_train boolean NOT NULL DEFAULT false
+);
+
+CREATE TABLE repo_jobs (
+    repo_name text NOT NULL PRIMARY KEY,
+    status text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    error text NOT NULL DEFAULT '',
+    repo_id integer,
+    started_at timestamp,
+    duration_ms bigint NOT NULL DEFAULT 0,
+    extracted_functions integer NOT NULL DEFAULT 0,
+
+    CONSTRAINT repo_jobs_repo_fk FOREIGN KEY (repo_id) REFERENCES repos (id) ON DELETE SET NULL
+);
+
+CREATE INDEX repo_jobs_status_idx ON repo_jobs (status);
+
+CREATE TABLE extraction_policies (
+    hash text NOT NULL PRIMARY KEY,
+    policy text NOT NULL
+);
+
+CREATE TABLE repo_snapshots (
+    id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+    repo_id integer NOT NULL,
+    ref text NOT NULL,
+    commit_id text NOT NULL,
+    license text NOT NULL DEFAULT '',
+    policy_hash text NOT NULL DEFAULT '',
+
+    CONSTRAINT repo_snapshots_repo_fk FOREIGN KEY (repo_id) REFERENCES repos (id) ON DELETE CASCADE,
+
+    CONSTRAINT repo_snapshots_repo_ref_unique UNIQUE (repo_id, ref)
+);
+
+CREATE TABLE excluded_files (
+    snapshot_id integer NOT NULL,
+    path text NOT NULL,
+    reason text NOT NULL,
+    detail text NOT NULL,
+
+    PRIMARY KEY (snapshot_id, path),
+
+    CONSTRAINT excluded_files_snapshot_fk FOREIGN KEY (snapshot_id) REFERENCES repo_snapshots (id) ON DELETE CASCADE
+);
+
+CREATE INDEX excluded_files_reason_idx ON excluded_files (reason);
+
+CREATE TABLE parsed_files (
+    snapshot_id integer NOT NULL,
+    path text NOT NULL,
+    language text NOT NULL,
+    error_nodes integer NOT NULL,
+    missing_nodes integer NOT NULL,
+
+    PRIMARY KEY (snapshot_id, path),
+
+    CONSTRAINT parsed_files_snapshot_fk FOREIGN KEY (snapshot_id) REFERENCES repo_snapshots (id) ON DELETE CASCADE
+);
+
+CREATE INDEX parsed_files_language_idx ON parsed_files (language);
+
+CREATE TABLE extracted_functions (
+    id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+    path text NOT NULL,
+    docstring text NOT NULL,
+    docstring_summary text NOT NULL DEFAULT '',
+    docstring_sections text NOT NULL DEFAULT '{}',
+    inline_comments text NOT NULL,
+    clean_code text NOT NULL,
+    clean_code_hash text NOT NULL UNIQUE,
+    identifier text NOT NULL,
+    qualified_identifier text NOT NULL DEFAULT '',
+    start_line integer NOT NULL,
+    end_line integer NOT NULL,
+    signature text NOT NULL DEFAULT '',
+    receiver text NOT NULL DEFAULT '',
+    parameters text NOT NULL DEFAULT '[]',
+    return_type text NOT NULL DEFAULT '',
+    visibility text NOT NULL DEFAULT '',
+    is_static boolean NOT NULL DEFAULT false,
+    is_async boolean NOT NULL DEFAULT false,
+    role text NOT NULL DEFAULT 'production',
+    token_count integer NOT NULL DEFAULT 0,
+    cyclomatic_complexity integer NOT NULL DEFAULT 0,
+    max_nesting_depth integer NOT NULL DEFAULT 0,
+    parameter_count integer NOT NULL DEFAULT 0,
+    comment_ratio real NOT NULL DEFAULT 0,
+    near_duplicate_cluster_id integer,
+    repo_id integer NOT NULL,
+
+    CONSTRAINT extracted_functions_repo_fk FOREIGN KEY (repo_id) REFERENCES repos (id) ON DELETE CASCADE
+);
+
+CREATE INDEX extracted_functions_repo_id_idx ON extracted_functions (repo_id);
+
+CREATE INDEX extracted_functions_visibility_idx ON extracted_functions (visibility);
+
+CREATE INDEX extracted_functions_role_idx ON extracted_functions (role);
+
+CREATE INDEX extracted_functions_near_duplicate_cluster_id_idx ON extracted_functions (near_duplicate_cluster_id);
+
+CREATE TABLE extracted_types (
+    id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+    path text NOT NULL,
+    kind text NOT NULL,
+    identifier text NOT NULL,
+    docstring text NOT NULL,
+    docstring_summary text NOT NULL DEFAULT '',
+    members text NOT NULL,
+    clean_code text NOT NULL,
+    clean_code_hash text NOT NULL UNIQUE,
+    start_line integer NOT NULL,
+    end_line integer NOT NULL,
+    repo_id integer NOT NULL,
+
+    CONSTRAINT extracted_types_repo_fk FOREIGN KEY (repo_id) REFERENCES repos (id) ON DELETE CASCADE
+);
+
+CREATE INDEX extracted_types_repo_id_idx ON extracted_types (repo_id);
+
+CREATE TABLE extracted_function_snapshots (
+    snapshot_id integer NOT NULL,
+    extracted_function_id integer NOT NULL,
+    path text NOT NULL,
+    license text NOT NULL DEFAULT '',
+    docstring text NOT NULL,
+    start_line integer NOT NULL,
+    end_line integer NOT NULL,
+
+    PRIMARY KEY (snapshot_id, extracted_function_id, path),
+
+    CONSTRAINT extracted_function_snapshots_snapshot_fk FOREIGN KEY (snapshot_id) REFERENCES repo_snapshots (id) ON DELETE CASCADE,
+
+    CONSTRAINT extracted_function_snapshots_extracted_function_fk FOREIGN KEY (extracted_function_id) REFERENCES extracted_functions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX extracted_function_snapshots_extracted_function_id_idx ON extracted_function_snapshots (extracted_function_id);
+
+CREATE TABLE extracted_type_snapshots (
+    snapshot_id integer NOT NULL,
+    extracted_type_id integer NOT NULL,
+    path text NOT NULL,
+    license text NOT NULL DEFAULT '',
+    docstring text NOT NULL,
+    start_line integer NOT NULL,
+    end_line integer NOT NULL,
+
+    PRIMARY KEY (snapshot_id, extracted_type_id, path),
+
+    CONSTRAINT extracted_type_snapshots_snapshot_fk FOREIGN KEY (snapshot_id) REFERENCES repo_snapshots (id) ON DELETE CASCADE,
+
+    CONSTRAINT extracted_type_snapshots_extracted_type_fk FOREIGN KEY (extracted_type_id) REFERENCES extracted_types (id) ON DELETE CASCADE
+);
+
+CREATE INDEX extracted_type_snapshots_extracted_type_id_idx ON extracted_type_snapshots (extracted_type_id);
+
+CREATE TABLE extracted_function_calls (
+    caller_id integer NOT NULL,
+    callee_name text NOT NULL,
+    receiver text NOT NULL DEFAULT '',
+    callee_id integer,
+
+    PRIMARY KEY (caller_id, callee_name, receiver),
+
+    CONSTRAINT extracted_function_calls_caller_fk FOREIGN KEY (caller_id) REFERENCES extracted_functions (id) ON DELETE CASCADE,
+
+    CONSTRAINT extracted_function_calls_callee_fk FOREIGN KEY (callee_id) REFERENCES extracted_functions (id) ON DELETE SET NULL
+);
+
+CREATE INDEX extracted_function_calls_callee_id_idx ON extracted_function_calls (callee_id);
+
+CREATE TABLE code_query_pairs (
+    id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+    code text NOT NULL,
+    code_hash text NOT NULL UNIQUE,
+    query text NOT NULL,
+    is_train boolean NOT NULL DEFAULT false,
+    so_question_id integer,
+    extracted_function_id integer,
+    extracted_type_id integer,
+    near_duplicate_cluster_id integer,
+
+    CONSTRAINT code_query_pairs_so_question_id_fk FOREIGN KEY (so_question_id) REFERENCES so_questions (id) ON DELETE SET NULL,
+
+    CONSTRAINT code_query_pairs_extracted_function_id_fk FOREIGN KEY (extracted_function_id) REFERENCES extracted_functions (id) ON DELETE SET NULL,
+
+    CONSTRAINT code_query_pairs_extracted_type_id_fk FOREIGN KEY (extracted_type_id) REFERENCES extracted_types (id) ON DELETE SET NULL
+);
+
+CREATE INDEX code_query_pairs_so_question_id_idx ON code_query_pairs (so_question_id);
+
+CREATE INDEX code_query_pairs_extracted_function_id_idx ON code_query_pairs (extracted_function_id);
+
+CREATE INDEX code_query_pairs_extracted_type_id_idx ON code_query_pairs (extracted_type_id);
+
+CREATE INDEX code_query_pairs_near_duplicate_cluster_id_idx ON code_query_pairs (near_duplicate_cluster_id);
+`
+
+const SQLITE_SCHEMA_DOWN = `
+DROP TABLE code_query_pairs;
+DROP TABLE so_questions;
+DROP TABLE so_answers;
+DROP TABLE extracted_function_calls;
+DROP TABLE extracted_function_snapshots;
+DROP TABLE extracted_type_snapshots;
+DROP TABLE extracted_functions;
+DROP TABLE extracted_types;
+DROP TABLE excluded_files;
+DROP TABLE parsed_files;
+DROP TABLE repo_snapshots;
+DROP TABLE extraction_policies;
+DROP TABLE repo_jobs;
+DROP TABLE repos;
+`
+
+// SQLiteStore is the store of a SQLite file. The schema and the queries mirror the Postgres ones, without arrays and
+// lateral joins.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStore opens the SQLite file at the path, and creates it if it does not exist. The `:memory:` path opens
+// an in-memory database.
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", url.PathEscape(path)))
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer, and every connection to an in-memory database opens a new database.
+	db.SetMaxOpenConns(1)
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) InitializeSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, SQLITE_SCHEMA_UP)
+	return err
+}
+
+func (s *SQLiteStore) ResetSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, SQLITE_SCHEMA_DOWN)
+	return err
+}
+
+func (s *SQLiteStore) Close(ctx context.Context) error {
+	return s.db.Close()
+}
+
+// insertRows executes the insert statement with the arguments of each row, in a single transaction.
+func insertRows[T any](ctx context.Context, db *sql.DB, insertStatement string, rows []*T, getRowArgs func(row *T) []any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, insertStatement)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		_, err := stmt.ExecContext(ctx, getRowArgs(row)...)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// idsCondition returns the `IN` condition on the column for the IDs, along with its arguments.
+func idsCondition(column string, ids []int) (string, []any) {
+	if len(ids) == 0 {
+		return "false", nil
+	}
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return fmt.Sprintf("%s IN (%s)", column, strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")), args
+}
+
+func querySQLiteRows[T any](ctx context.Context, db *sql.DB, query string, args []any, scanRow func(rows *sql.Rows) (*T, error)) ([]*T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scannedRows := []*T{}
+	for rows.Next() {
+		row, err := scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		scannedRows = append(scannedRows, row)
+	}
+	return scannedRows, rows.Err()
+}
+
+// The tracked snapshot of a repo has an empty ref.
+const trackedSnapshotRef = ""
+
+// snapshotLocation is where a function or a type is in a snapshot.
+type snapshotLocation struct {
+	id         int
+	snapshotID int
+	ref        string
+	commitID   string
+	path       string
+	startLine  int
+	endLine    int
+	license    string
+}
+
+// getSnapshotLocations returns the locations of the functions or the types of a snapshots table by ID, ordered by
+// snapshot.
+func (s *SQLiteStore) getSnapshotLocations(ctx context.Context, snapshotsTable string, idColumn string, ids []int) (map[int][]*snapshotLocation, error) {
+	condition, args := idsCondition(idColumn, ids)
+	query := fmt.Sprintf(`SELECT %[2]s, s.id, s.ref, s.commit_id, path, start_line, end_line, COALESCE(NULLIF(%[1]s.license, ''), s.license)
+FROM %[1]s
+JOIN repo_snapshots s ON s.id = %[1]s.snapshot_id
+WHERE %[3]s
+ORDER BY s.id`, snapshotsTable, idColumn, condition)
+
+	locations, err := querySQLiteRows(ctx, s.db, query, args, func(rows *sql.Rows) (*snapshotLocation, error) {
+		l := &snapshotLocation{}
+		err := rows.Scan(&l.id, &l.snapshotID, &l.ref, &l.commitID, &l.path, &l.startLine, &l.endLine, &l.license)
+		if err != nil {
+			return nil, err
+		}
+		return l, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idToLocations := map[int][]*snapshotLocation{}
+	for _, location := range locations {
+		idToLocations[location.id] = append(idToLocations[location.id], location)
+	}
+	return idToLocations, nil
+}
+
+// getLatestLocationAndRevisions returns the location in the tracked snapshot if there is one, and in the latest
+// snapshot otherwise, along with the refs of the snapshots, or the commit for the tracked snapshot.
+func getLatestLocationAndRevisions(locations []*snapshotLocation) (*snapshotLocation, []string) {
+	var latest *snapshotLocation
+	revisions := []string{}
+	for idx, location := range locations {
+		if latest == nil || latest.ref != trackedSnapshotRef {
+			latest = location
+		}
+		if idx > 0 && locations[idx-1].snapshotID == location.snapshotID {
+			continue
+		}
+		if location.ref == trackedSnapshotRef {
+			revisions = append(revisions, location.commitID)
+		} else {
+			revisions = append(revisions, location.ref)
+		}
+	}
+	return latest, revisions
+}
+
+const sqliteExtractedFunctionsWithRepoQuery = `SELECT extracted_functions.id, r.name, r.commit_id, extracted_functions.path, extracted_functions.start_line, extracted_functions.end_line, extracted_functions.signature, extracted_functions.qualified_identifier, extracted_functions.role
+FROM extracted_functions
+LEFT JOIN repos r ON r.id = extracted_functions.repo_id
+WHERE %s`
+
+const sqliteCallersQuery = `SELECT efc.callee_id, COALESCE(NULLIF(caller.qualified_identifier, ''), caller.identifier)
+FROM extracted_function_calls efc
+JOIN extracted_functions caller ON caller.id = efc.caller_id
+WHERE %s AND efc.caller_id <> efc.callee_id
+GROUP BY efc.callee_id, caller.id
+ORDER BY caller.id`
+
+type sqliteCaller struct {
+	calleeID   int
+	identifier string
+}
+
+func (s *SQLiteStore) GetExtractedFunctionsByID(ctx context.Context, ids []int) ([]*web.HighlightedExtractedFunction, error) {
+	condition, args := idsCondition("extracted_functions.id", ids)
+	fns, err := querySQLiteRows(ctx, s.db, fmt.Sprintf(sqliteExtractedFunctionsWithRepoQuery, condition), args, func(rows *sql.Rows) (*web.HighlightedExtractedFunction, error) {
+		hef := &web.HighlightedExtractedFunction{}
+		err := rows.Scan(
+			&hef.ID,
+			&hef.RepositoryName,
+			&hef.CommitID,
+			&hef.FilePath,
+			&hef.StartLine,
+			&hef.EndLine,
+			&hef.Signature,
+			&hef.QualifiedIdentifier,
+			&hef.Role,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return hef, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idToLocations, err := s.getSnapshotLocations(ctx, "extracted_function_snapshots", "extracted_function_id", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	condition, args = idsCondition("efc.callee_id", ids)
+	callers, err := querySQLiteRows(ctx, s.db, fmt.Sprintf(sqliteCallersQuery, condition), args, func(rows *sql.Rows) (*sqliteCaller, error) {
+		c := &sqliteCaller{}
+		err := rows.Scan(&c.calleeID, &c.identifier)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	idToCallers := map[int][]string{}
+	for _, caller := range callers {
+		if len(idToCallers[caller.calleeID]) < 5 {
+			idToCallers[caller.calleeID] = append(idToCallers[caller.calleeID], caller.identifier)
+		}
+	}
+
+	for _, hef := range fns {
+		latest, revisions := getLatestLocationAndRevisions(idToLocations[hef.ID])
+		if latest != nil {
+			hef.CommitID, hef.FilePath, hef.StartLine, hef.EndLine, hef.License = latest.commitID, latest.path, latest.startLine, latest.endLine, latest.license
+		}
+		hef.Revisions = revisions
+		hef.UsedBy = idToCallers[hef.ID]
+		if hef.UsedBy == nil {
+			hef.UsedBy = []string{}
+		}
+		hef.URL = sourcegraphURL(hef.RepositoryName, hef.CommitID, hef.FilePath, hef.StartLine, hef.EndLine)
+	}
+	return orderByIDs(fns, ids, func(hef *web.HighlightedExtractedFunction) int { return hef.ID }), nil
+}
+
+const sqliteExtractedTypesWithRepoQuery = `SELECT extracted_types.id, r.name, r.commit_id, extracted_types.path, extracted_types.start_line, extracted_types.end_line, extracted_types.kind, extracted_types.identifier, extracted_types.members
+FROM extracted_types
+LEFT JOIN repos r ON r.id = extracted_types.repo_id
+WHERE %s`
+
+func (s *SQLiteStore) GetExtractedTypesByID(ctx context.Context, ids []int) ([]*web.HighlightedExtractedType, error) {
+	condition, args := idsCondition("extracted_types.id", ids)
+	types, err := querySQLiteRows(ctx, s.db, fmt.Sprintf(sqliteExtractedTypesWithRepoQuery, condition), args, func(rows *sql.Rows) (*web.HighlightedExtractedType, error) {
+		het := &web.HighlightedExtractedType{}
+		var members string
+		err := rows.Scan(
+			&het.ID,
+			&het.RepositoryName,
+			&het.CommitID,
+			&het.FilePath,
+			&het.StartLine,
+			&het.EndLine,
+			&het.Kind,
+			&het.Identifier,
+			&members,
+		)
+		if err != nil {
+			return nil, err
+		}
+		het.Members = strings.Fields(members)
+		return het, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idToLocations, err := s.getSnapshotLocations(ctx, "extracted_type_snapshots", "extracted_type_id", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, het := range types {
+		latest, revisions := getLatestLocationAndRevisions(idToLocations[het.ID])
+		if latest != nil {
+			het.CommitID, het.FilePath, het.StartLine, het.EndLine, het.License = latest.commitID, latest.path, latest.startLine, latest.endLine, latest.license
+		}
+		het.Revisions = revisions
+		het.URL = sourcegraphURL(het.RepositoryName, het.CommitID, het.FilePath, het.StartLine, het.EndLine)
+	}
+	return orderByIDs(types, ids, func(het *web.HighlightedExtractedType) int { return het.ID }), nil
+}
+
+func (s *SQLiteStore) InsertSOQuestions(ctx context.Context, questions []*SOQuestion) error {
+	return insertRows(
+		ctx,
+		s.db,
+		"INSERT INTO so_questions (id, title, tags, score, accepted_answer_id, creation_date, last_edit_date) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		questions,
+		func(question *SOQuestion) []any {
+			return []any{question.ID, question.Title, question.Tags, question.Score, question.AcceptedAnswerID, question.CreationDate, question.LastEditDate}
+		},
+	)
+}
+
+func (s *SQLiteStore) InsertSOAnswers(ctx context.Context, answers []*SOAnswer) error {
+	return insertRows(
+		ctx,
+		s.db,
+		"INSERT INTO so_answers (id, body, score, parent_id, creation_date, last_edit_date) VALUES (?, ?, ?, ?, ?, ?)",
+		answers,
+		func(answer *SOAnswer) []any {
+			return []any{answer.ID, answer.Body, answer.Score, answer.ParentID, answer.CreationDate, answer.LastEditDate}
+		},
+	)
+}
+
+type sqliteSOAnswer struct {
+	parentID int
+	answer   *web.SOAnswer
+}
+
+func (s *SQLiteStore) GetSOQuestionsWithAnswersByID(ctx context.Context, ids []int) ([]*web.SOQuestionWithAnswers, error) {
+	condition, args := idsCondition("id", ids)
+	qs, err := querySQLiteRows(ctx, s.db, "SELECT id, title, tags, score, creation_date FROM so_questions WHERE "+condition, args, func(rows *sql.Rows) (*web.SOQuestionWithAnswers, error) {
+		sq := &web.SOQuestionWithAnswers{Answers: []*web.SOAnswer{}}
+		err := rows.Scan(&sq.ID, &sq.Title, &sq.Tags, &sq.Score, &sq.CreationDate)
+		if err != nil {
+			return nil, err
+		}
+		return sq, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	condition, args = idsCondition("parent_id", ids)
+	answers, err := querySQLiteRows(ctx, s.db, "SELECT parent_id, id, body, score, creation_date FROM so_answers WHERE "+condition+" ORDER BY score DESC", args, func(rows *sql.Rows) (*sqliteSOAnswer, error) {
+		sa := &sqliteSOAnswer{answer: &web.SOAnswer{}}
+		err := rows.Scan(&sa.parentID, &sa.answer.ID, &sa.answer.Body, &sa.answer.Score, &sa.answer.CreationDate)
+		if err != nil {
+			return nil, err
+		}
+		return sa, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idToQuestion := map[int]*web.SOQuestionWithAnswers{}
+	for _, sq := range qs {
+		idToQuestion[sq.ID] = sq
+	}
+	for _, sa := range answers {
+		if sq, ok := idToQuestion[sa.parentID]; ok {
+			sq.Answers = append(sq.Answers, sa.answer)
+		}
+	}
+	for _, sq := range qs {
+		formatSOQuestion(sq)
+	}
+	return orderByIDs(qs, ids, func(sq *web.SOQuestionWithAnswers) int { return sq.ID }), nil
+}
+
+func (s *SQLiteStore) InsertCodeQueryPairs(ctx context.Context, pairs []*CodeQueryPair) error {
+	return insertRows(
+		ctx,
+		s.db,
+		"INSERT INTO code_query_pairs (code, code_hash, query, is_train, so_question_id, extracted_function_id, extracted_type_id, near_duplicate_cluster_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (code_hash) DO NOTHING",
+		deduplicateCodeQueryPairs(pairs),
+		func(cqp *CodeQueryPair) []any {
+			return []any{cqp.Code, cqp.CodeHash, cqp.Query, cqp.IsTrain, cqp.SOQuestionID, cqp.ExtractedFunctionID, cqp.ExtractedTypeID, cqp.NearDuplicateClusterID}
+		},
+	)
+}
+
+func (s *SQLiteStore) GetCodeQueryPairsPage(ctx context.Context, afterID int, pageSize int) ([]*CodeQueryPair, error) {
+	return querySQLiteRows(ctx, s.db, codeQueryPairsQuery+" WHERE id > ? ORDER BY id ASC LIMIT ?", []any{afterID, pageSize}, func(rows *sql.Rows) (*CodeQueryPair, error) {
+		cqp := &CodeQueryPair{}
+		err := rows.Scan(&cqp.ID, &cqp.Code, &cqp.CodeHash, &cqp.Query, &cqp.IsTrain, &cqp.SOQuestionID, &cqp.ExtractedFunctionID, &cqp.ExtractedTypeID, &cqp.NearDuplicateClusterID)
+		if err != nil {
+			return nil, err
+		}
+		return cqp, nil
+	})
+}
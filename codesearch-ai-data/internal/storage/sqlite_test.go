@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	ctx := context.Background()
+	store, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "codesearch.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close(ctx) })
+
+	err = store.InitializeSchema(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSQLiteStoreExtractedFunctions(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	// Repos and functions are only written by the extraction pipeline, to Postgres.
+	_, err := store.db.ExecContext(ctx, `
+INSERT INTO repos (name, commit_id) VALUES ('github.com/org/repo', 'commit');
+INSERT INTO extracted_functions (path, identifier, qualified_identifier, docstring, docstring_summary, inline_comments, clean_code, clean_code_hash, start_line, end_line, signature, role, repo_id) VALUES
+	('a.go', 'parse', 'pkg.parse', '', '', '', 'func parse() {}', 'a', 2, 4, 'func parse()', 'production', 1),
+	('a_test.go', 'TestParse', '', '', '', '', 'func TestParse() {}', 'b', 0, 3, '', 'test', 1);
+INSERT INTO repo_snapshots (repo_id, ref, commit_id, license) VALUES (1, 'v1.0', 'old-commit', 'MIT'), (1, '', 'commit', 'Apache-2.0');
+INSERT INTO extracted_function_snapshots (snapshot_id, extracted_function_id, path, license, docstring, start_line, end_line) VALUES (1, 1, 'old.go', '', '', 0, 2), (2, 1, 'a.go', '', '', 2, 4), (1, 2, 'a_test.go', 'MIT-0', '', 1, 4);
+INSERT INTO extracted_function_calls (caller_id, callee_name, callee_id) VALUES (2, 'parse', 1), (1, 'parse', 1);
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fns, err := store.GetExtractedFunctionsByID(ctx, []int{2, 3, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fns[1] != nil {
+		t.Fatalf("expected no function with ID 3, got %+v", fns[1])
+	}
+	autogold.Equal(t, [][]any{
+		{fns[0].ID, fns[0].CommitID, fns[0].FilePath, fns[0].StartLine, fns[0].License, fns[0].Role, fns[0].Revisions, fns[0].UsedBy, fns[0].URL},
+		{fns[2].ID, fns[2].CommitID, fns[2].FilePath, fns[2].StartLine, fns[2].License, fns[2].Role, fns[2].Revisions, fns[2].UsedBy, fns[2].URL},
+	})
+}
+
+func TestSQLiteStoreSOQuestions(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	err := store.InsertSOQuestions(ctx, []*SOQuestion{
+		{ID: 1, Title: "How to parse JSON?", Tags: "<go><json>", Score: 3, CreationDate: "2020-01-02T03:04:05.000"},
+		{ID: 2, Title: "Unanswered", Tags: "<go>", CreationDate: "2020-01-02T03:04:05.000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.InsertSOAnswers(ctx, []*SOAnswer{
+		{ID: 3, Body: "<p>Use a decoder.</p>", Score: 1, ParentID: 1, CreationDate: "2020-01-03T03:04:05.000"},
+		{ID: 4, Body: "<pre><code>json.Unmarshal(b, &v)</code></pre>", Score: 5, ParentID: 1, CreationDate: "not a timestamp"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	questions, err := store.GetSOQuestionsWithAnswersByID(ctx, []int{2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	autogold.Equal(t, questions)
+}
+
+func TestSQLiteStoreCodeQueryPairs(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	err := store.InsertCodeQueryPairs(ctx, []*CodeQueryPair{
+		{Code: "a", CodeHash: "a", Query: "first"},
+		{Code: "a", CodeHash: "a", Query: "duplicate in batch"},
+		{Code: "b", CodeHash: "b", Query: "second", IsTrain: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.InsertCodeQueryPairs(ctx, []*CodeQueryPair{
+		{Code: "b", CodeHash: "b", Query: "duplicate"},
+		{Code: "c", CodeHash: "c", Query: "third"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstPage, err := store.GetCodeQueryPairsPage(ctx, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondPage, err := store.GetCodeQueryPairsPage(ctx, firstPage[len(firstPage)-1].ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries := []string{}
+	for _, cqp := range append(firstPage, secondPage...) {
+		queries = append(queries, cqp.Query)
+	}
+	autogold.Equal(t, queries)
+}
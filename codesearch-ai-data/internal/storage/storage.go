@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"codesearch-ai-data/internal/database"
+	"codesearch-ai-data/internal/socode"
+	"codesearch-ai-data/internal/web"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Store serves the extracted functions and types, the StackOverflow posts and the code query pairs to cmd/web, and
+// stores the StackOverflow posts and the code query pairs of the StackOverflow importer. Stores are backed by Postgres,
+// or by a single SQLite file to run the web app and the StackOverflow importer locally. The function extraction, the
+// near-duplicate clustering, the code query pairs import and the training data export are not part of the store, they
+// connect to Postgres directly and reject SQLite database URLs.
+type Store interface {
+	InitializeSchema(ctx context.Context) error
+	ResetSchema(ctx context.Context) error
+	Close(ctx context.Context) error
+
+	// GetExtractedFunctionsByID returns the functions in the order of the IDs, with nil for unknown IDs.
+	GetExtractedFunctionsByID(ctx context.Context, ids []int) ([]*web.HighlightedExtractedFunction, error)
+	// GetExtractedTypesByID returns the types in the order of the IDs, with nil for unknown IDs.
+	GetExtractedTypesByID(ctx context.Context, ids []int) ([]*web.HighlightedExtractedType, error)
+
+	InsertSOQuestions(ctx context.Context, questions []*SOQuestion) error
+	InsertSOAnswers(ctx context.Context, answers []*SOAnswer) error
+	// GetSOQuestionsWithAnswersByID returns the questions in the order of the IDs, with nil for unknown IDs. Answers
+	// are ordered by descending score.
+	GetSOQuestionsWithAnswersByID(ctx context.Context, ids []int) ([]*web.SOQuestionWithAnswers, error)
+
+	// InsertCodeQueryPairs inserts the pairs. Pairs with the code of a stored pair are skipped.
+	InsertCodeQueryPairs(ctx context.Context, pairs []*CodeQueryPair) error
+	// GetCodeQueryPairsPage returns up to pageSize pairs with an ID greater than afterID, ordered by ID.
+	GetCodeQueryPairsPage(ctx context.Context, afterID int, pageSize int) ([]*CodeQueryPair, error)
+}
+
+type SOQuestion struct {
+	ID               int
+	Title            string
+	Tags             string
+	Score            int
+	AcceptedAnswerID *int
+	CreationDate     string
+	LastEditDate     string
+}
+
+type SOAnswer struct {
+	ID           int
+	Body         string
+	Score        int
+	ParentID     int
+	CreationDate string
+	LastEditDate string
+}
+
+type CodeQueryPair struct {
+	ID                  int    `json:"id"`
+	Code                string `json:"code"`
+	CodeHash            string `json:"-"`
+	Query               string `json:"query"`
+	IsTrain             bool   `json:"-"`
+	SOQuestionID        *int   `json:"soQuestionId"`
+	ExtractedFunctionID *int   `json:"extractedFunctionId"`
+	ExtractedTypeID     *int   `json:"extractedTypeId"`
+	// NearDuplicateClusterID is the near-duplicate cluster of the extracted function of the pair.
+	NearDuplicateClusterID *int `json:"nearDuplicateClusterId"`
+}
+
+// Open opens the store of the database URL. URLs starting with `sqlite:` open the SQLite file at the rest of the URL
+// (e.g. `sqlite:codesearch.db`), other URLs connect to Postgres.
+func Open(ctx context.Context, databaseURL string) (Store, error) {
+	if strings.HasPrefix(databaseURL, database.SQLITE_URL_PREFIX) {
+		return OpenSQLiteStore(strings.TrimPrefix(strings.TrimPrefix(databaseURL, database.SQLITE_URL_PREFIX), "//"))
+	}
+
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return NewPostgresStore(conn), nil
+}
+
+// OpenFromEnv opens the store of the CODESEARCH_AI_DATA_DATABASE_URL environment variable.
+func OpenFromEnv(ctx context.Context) (Store, error) {
+	return Open(ctx, os.Getenv("CODESEARCH_AI_DATA_DATABASE_URL"))
+}
+
+const codeQueryPairsQuery = "SELECT id, code, code_hash, query, is_train, so_question_id, extracted_function_id, extracted_type_id, near_duplicate_cluster_id FROM code_query_pairs"
+
+// deduplicateCodeQueryPairs keeps the first pair of each code.
+func deduplicateCodeQueryPairs(pairs []*CodeQueryPair) []*CodeQueryPair {
+	codes := map[string]bool{}
+	deduplicatedPairs := []*CodeQueryPair{}
+	for _, pair := range pairs {
+		if codes[pair.CodeHash] {
+			continue
+		}
+		deduplicatedPairs = append(deduplicatedPairs, pair)
+		codes[pair.CodeHash] = true
+	}
+	return deduplicatedPairs
+}
+
+func sourcegraphURL(repoName string, commitID string, filePath string, startLine int, endLine int) string {
+	return fmt.Sprintf("https://sourcegraph.com/%s@%s/-/blob/%s?L%d-%d", repoName, commitID, filePath, startLine+1, endLine+1)
+}
+
+// formatSOTimestamp formats StackOverflow timestamps for display, timestamps that cannot be parsed are kept as-is.
+func formatSOTimestamp(timestamp string) string {
+	parsedTimestamp, err := time.Parse(web.TIMESTAMP_LAYOUT, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return parsedTimestamp.Format("Jan 02, 2006")
+}
+
+// formatSOQuestion sets the URL of the question, formats the timestamps and escapes the code snippets of the answers.
+func formatSOQuestion(sq *web.SOQuestionWithAnswers) {
+	sq.URL = fmt.Sprintf("https://stackoverflow.com/questions/%d", sq.ID)
+	sq.CreationDate = formatSOTimestamp(sq.CreationDate)
+
+	answers := sq.Answers[:0]
+	for _, answer := range sq.Answers {
+		if answer == nil {
+			continue
+		}
+		answer.CreationDate = formatSOTimestamp(answer.CreationDate)
+		answer.Body = socode.EscapeCodeSnippetsInHTML(answer.Body)
+		answers = append(answers, answer)
+	}
+	sq.Answers = answers
+}
+
+// orderByIDs orders the rows like the IDs, with nil for IDs without a row.
+func orderByIDs[T any](rows []*T, ids []int, getRowID func(row *T) int) []*T {
+	idToRow := map[int]*T{}
+	for _, row := range rows {
+		idToRow[getRowID(row)] = row
+	}
+
+	orderedRows := make([]*T, 0, len(ids))
+	for _, id := range ids {
+		orderedRows = append(orderedRows, idToRow[id])
+	}
+	return orderedRows
+}
@@ -0,0 +1,19 @@
+#include <stdio.h>
+
+/**
+ * Prints a greeting.
+ */
+void greet(const char *name) {
+    /* Print it */
+    printf("Hello %s\n", name);
+}
+
+/// Declared in a header.
+int add(int a, int b);
+
+// Allocates a buffer
+static char *
+allocate(size_t size)
+{
+    return malloc(size);
+}
@@ -0,0 +1,49 @@
+#include <vector>
+
+namespace geometry {
+
+/**
+ * Computes the area of a rectangle.
+ */
+int area(int width, int height) {
+    // Multiply
+    return width * height;
+}
+
+/// Declared only, no body.
+int perimeter(int width, int height);
+
+class Shape {
+public:
+    Shape() = default;
+    virtual ~Shape();
+
+    /// Inline method
+    double scale(double factor) const {
+        return factor * 2;
+    }
+};
+
+/// Destroys the shape.
+Shape::~Shape() {
+    cleanup();
+}
+
+// Returns a pointer
+int *Shape::buffer(size_t size) {
+    return new int[size];
+}
+
+/** Sums a vector. */
+template <typename T>
+T sum(const std::vector<T> &values) {
+    T total{};
+    for (const auto &v : values) total += v;
+    return total;
+}
+
+bool Shape::operator==(const Shape &other) const {
+    return this == &other;
+}
+
+}  // namespace geometry
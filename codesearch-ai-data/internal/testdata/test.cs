@@ -0,0 +1,54 @@
+using System;
+
+namespace Geometry
+{
+    /// <summary>
+    /// A circle.
+    /// </summary>
+    public class Circle
+    {
+        private readonly double radius;
+
+        /// <summary>
+        /// Creates a circle with the given <paramref name="radius"/>.
+        /// </summary>
+        /// <param name="radius">The radius.</param>
+        public Circle(double radius)
+        {
+            this.radius = radius;
+        }
+
+        /// <summary>Computes the area, see <see cref="Math.PI"/>.</summary>
+        /// <returns>The area.</returns>
+        [Pure]
+        public double Area()
+        {
+            // Square the radius
+            return Math.PI * radius * radius;
+        }
+
+        /// <summary>Scales a value.</summary>
+        public Func<double, double> Scale { get; } = factor =>
+        {
+            return factor * 2;
+        };
+
+        // Not an XML doc comment
+        public static int Count(int[] values)
+        {
+            int Sum(int a, int b)
+            {
+                return a + b;
+            }
+
+            return values.Length;
+        }
+
+        public override string ToString()
+        {
+            return "Circle";
+        }
+
+        public abstract void Draw();
+    }
+}
@@ -0,0 +1,74 @@
+package geometry
+
+import kotlin.math.PI
+
+/**
+ * A circle with the given [radius].
+ *
+ * @property radius The radius.
+ */
+class Circle(private val radius: Double) : Shape {
+    /**
+     * Computes the area, see [PI].
+     *
+     * @return The area.
+     */
+    @Memoized
+    override fun area(): Double {
+        // Square the radius
+        return PI * radius * radius
+    }
+
+    /** Scales a value. */
+    val scale: (Double) -> Double = { factor: Double ->
+        factor * 2
+    }
+
+    constructor(diameter: Int) : this(diameter / 2.0) {
+        println("Created from a diameter")
+    }
+
+    fun describe(): String = "circle $radius"
+
+    /**
+     * Loads circles by name.
+     *
+     * @param names The circle names.
+     * @param retries How many times to retry.
+     * @throws IllegalStateException If the retries are negative.
+     */
+    internal suspend fun load(vararg names: String, retries: Int = 3): List<String>? {
+        if (names.isEmpty() && retries > 0) {
+            return null
+        } else if (retries < 0) {
+            throw IllegalStateException()
+        }
+        val label = when (retries) {
+            1 -> "one"
+            else -> names.firstOrNull() ?: "none"
+        }
+        return names.map { it.trim() }.filter { it != label }
+    }
+
+    companion object {
+        // Not a KDoc comment
+        fun unit(): Circle {
+            return Circle(1.0)
+        }
+    }
+}
+
+interface Shape {
+    fun area(): Double
+}
+
+fun Circle.perimeter(): Double {
+    fun double(value: Double): Double {
+        return value * 2
+    }
+    return double(PI * radius)
+}
+
+val square = fun(value: Int): Int {
+    return value * value
+}
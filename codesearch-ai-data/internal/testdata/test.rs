@@ -0,0 +1,56 @@
+//! Geometry helpers.
+
+use std::fmt;
+
+/// Adds two numbers.
+/// Returns the sum.
+pub fn add(a: i32, b: i32) -> i32 {
+    // Sum
+    a + b
+}
+
+// Regular comment, not a doc comment
+fn helper() -> bool {
+    true
+}
+
+pub struct Point<T> {
+    x: T,
+    y: T,
+}
+
+impl<T: Copy> Point<T> {
+    /// Creates a new point.
+    #[inline]
+    pub fn new(x: T, y: T) -> Self {
+        Point { x, y }
+    }
+
+    /** Returns the x coordinate. */
+    pub fn x(&self) -> T {
+        self.x
+    }
+}
+
+pub trait Shape {
+    /// Area of the shape.
+    fn area(&self) -> f64;
+
+    /// Describes the shape.
+    fn describe(&self) -> String {
+        format!("area {}", self.area())
+    }
+}
+
+impl fmt::Display for Point<i32> {
+    fn fmt(&self, f: &mut fmt::Formatter) -> fmt::Result {
+        write!(f, "({}, {})", self.x, self.y)
+    }
+}
+
+mod inner {
+    //! Inner module docs.
+    pub fn nested() -> u8 {
+        1
+    }
+}
@@ -0,0 +1,56 @@
+import { Injectable, Input } from "./decorators";
+
+/**
+ * Formats a value.
+ * @param value The value to format
+ */
+export function format(value: string): string;
+export function format(value: number): string;
+export function format(value: string | number): string {
+    // Stringify numbers
+    return typeof value === "number" ? value.toFixed(2) : value;
+}
+
+type Handler<T> = (request: T) => Promise<void>;
+
+// Handles requests
+export const handle: Handler<Request> = async (request: Request): Promise<void> => {
+    await fetch(request);
+};
+
+// Not exported
+const identity = function <T>(value: T): T {
+    return value;
+};
+
+interface Shape {
+    // Area of the shape
+    area(): number;
+}
+
+abstract class Base {
+    abstract describe(): string;
+}
+
+@Injectable()
+class Circle extends Base implements Shape {
+    constructor(private radius: number) {
+        super();
+    }
+
+    /** Computes the area */
+    @Input()
+    @Memoize
+    area(): number {
+        return Math.PI * this.radius ** 2;
+    }
+
+    // Describes the circle
+    describe = (): string => {
+        return `circle ${this.radius}`;
+    };
+
+    toString(): string {
+        return "Circle";
+    }
+}
@@ -0,0 +1,24 @@
+import React from "react";
+
+interface ButtonProps {
+    label: string;
+    onClick: () => void;
+}
+
+// Renders a button
+export const Button: React.FunctionComponent<ButtonProps> = ({ label, onClick }) => {
+    return <button onClick={onClick}>{label}</button>;
+};
+
+/**
+ * Renders a list of items.
+ */
+export function List<T>({ items }: { items: T[] }): JSX.Element {
+    return (
+        <ul>
+            {items.map((item, index) => (
+                <li key={index}>{String(item)}</li>
+            ))}
+        </ul>
+    );
+}
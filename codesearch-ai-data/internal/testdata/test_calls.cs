@@ -0,0 +1,22 @@
+using System;
+
+class Greeter
+{
+    public string Greet(string name)
+    {
+        var message = Format(name);
+        Console.WriteLine(message);
+        return this.Decorate<string>(message);
+    }
+
+    private string Format(string name)
+    {
+        return String.Format("Hello {0}", name.Trim());
+    }
+
+    private T Decorate<T>(T value)
+    {
+        var builder = new StringBuilder();
+        return value;
+    }
+}
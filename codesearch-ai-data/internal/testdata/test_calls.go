@@ -0,0 +1,36 @@
+package calls
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Parser struct {
+	tokens []string
+}
+
+func NewParser(input string) *Parser {
+	return &Parser{tokens: tokenize(input)}
+}
+
+func tokenize(input string) []string {
+	return strings.Fields(input)
+}
+
+func (p *Parser) Parse() error {
+	for _, token := range p.tokens {
+		if err := p.parseToken(token); err != nil {
+			return fmt.Errorf("parsing %s: %w", token, err)
+		}
+	}
+	p.parseToken("")
+	return nil
+}
+
+func (p *Parser) parseToken(token string) error {
+	if token == "" {
+		return nil
+	}
+	fmt.Println(token)
+	return nil
+}
@@ -0,0 +1,22 @@
+import java.util.ArrayList;
+import java.util.List;
+
+class Inventory {
+    private List<String> items = new ArrayList<>();
+
+    public void add(String item) {
+        validate(item);
+        items.add(item);
+        this.log("added " + item);
+    }
+
+    private void validate(String item) {
+        if (item.isEmpty()) {
+            throw new IllegalArgumentException("empty item");
+        }
+    }
+
+    private void log(String message) {
+        System.out.println(message);
+    }
+}
@@ -0,0 +1,20 @@
+function fetchUser(id) {
+  return request(`/users/${id}`).then((response) => response.json());
+}
+
+function request(url) {
+  return fetch(url);
+}
+
+class UserCache {
+  get(id) {
+    if (!this.users.has(id)) {
+      this.users.set(id, fetchUser(id));
+    }
+    return this.users.get(id);
+  }
+
+  reset() {
+    this.users = new Map();
+  }
+}
@@ -0,0 +1,30 @@
+<?php
+
+function format_price($amount)
+{
+    return number_format(round_price($amount), 2);
+}
+
+function round_price($amount)
+{
+    return round($amount, 2);
+}
+
+class Cart
+{
+    public function total()
+    {
+        $total = $this->subtotal();
+        return format_price($total + self::shipping());
+    }
+
+    private function subtotal()
+    {
+        return Math::sum($this->prices);
+    }
+
+    private static function shipping()
+    {
+        return new Money(5);
+    }
+}
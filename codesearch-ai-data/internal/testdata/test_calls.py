@@ -0,0 +1,25 @@
+import os
+
+
+def read_config(path):
+    with open(path) as f:
+        return parse_config(f.read())
+
+
+def parse_config(text):
+    return dict(line.split("=") for line in text.splitlines())
+
+
+class Loader:
+    def __init__(self, root):
+        self.root = root
+
+    def load(self, name):
+        path = os.path.join(self.root, name)
+        config = read_config(path)
+        return self.validate(config)
+
+    def validate(self, config):
+        def check(key):
+            return key in config
+        return all(check(key) for key in ("name", "version"))
@@ -0,0 +1,18 @@
+class Report
+  def initialize(rows)
+    @rows = rows
+  end
+
+  def render
+    lines = @rows.map { |row| format_row(row) }
+    header + lines.join("\n")
+  end
+
+  def format_row(row)
+    row.values.join(", ")
+  end
+
+  def self.build(rows)
+    Report.new(rows).render
+  end
+end
@@ -0,0 +1,23 @@
+struct Stack {
+    items: Vec<i32>,
+}
+
+impl Stack {
+    fn new() -> Self {
+        Self { items: Vec::new() }
+    }
+
+    fn push(&mut self, item: i32) {
+        self.items.push(item);
+        log_size(self.len());
+    }
+
+    fn len(&self) -> usize {
+        self.items.len()
+    }
+}
+
+fn log_size(size: usize) {
+    println!("{}", size);
+    std::process::exit(size as i32);
+}
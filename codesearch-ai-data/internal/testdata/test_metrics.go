@@ -0,0 +1,27 @@
+package metrics
+
+func name(p *Person) string {
+	return p.name
+}
+
+// classify returns the category of the value.
+func classify(value int, strict bool) string {
+	if value < 0 && strict {
+		return "negative"
+	} else if value == 0 {
+		return "zero"
+	}
+
+	for i := 0; i < value; i++ {
+		switch {
+		case i%15 == 0:
+			// Multiples of both.
+			return "fizzbuzz"
+		case i%3 == 0 || i%5 == 0:
+			return "fizz"
+		default:
+			continue
+		}
+	}
+	return "positive"
+}
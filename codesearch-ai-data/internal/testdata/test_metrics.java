@@ -0,0 +1,27 @@
+class Classifier {
+    String getName() {
+        return name;
+    }
+
+    String classify(int value, boolean strict) {
+        if (value < 0 && strict) {
+            return "negative";
+        } else if (value == 0) {
+            return "zero";
+        }
+        for (int i = 0; i < value; i++) {
+            switch (i % 15) {
+                case 0:
+                    return "fizzbuzz";
+                default:
+                    items.forEach(item -> {
+                        if (item == null) {
+                            // Skip missing items.
+                            return;
+                        }
+                    });
+            }
+        }
+        return "positive";
+    }
+}
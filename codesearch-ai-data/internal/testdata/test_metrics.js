@@ -0,0 +1,15 @@
+function name(person) {
+  return person.name;
+}
+
+function classify(value, strict) {
+  if (value < 0 && strict) {
+    return 'negative';
+  } else if (value === 0) {
+    return 'zero';
+  }
+  return values.map((v) => {
+    // Parity.
+    return v % 2 === 0 ? 'even' : 'odd';
+  });
+}
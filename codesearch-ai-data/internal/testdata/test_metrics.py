@@ -0,0 +1,14 @@
+def name(person):
+    return person.name
+
+
+def classify(value, strict=False):
+    # Negative values are only rejected in strict mode.
+    if value < 0 and strict:
+        return "negative"
+    elif value == 0:
+        return "zero"
+    for i in range(value):
+        if i % 15 == 0:
+            return "fizzbuzz"
+    return "even" if value % 2 == 0 else "odd"
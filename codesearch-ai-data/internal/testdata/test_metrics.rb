@@ -0,0 +1,17 @@
+def name
+  @name
+end
+
+def classify(value, strict)
+  return 'negative' if value < 0 && strict
+  # Zero is neither.
+  if value == 0
+    'zero'
+  elsif value.even?
+    'even'
+  else
+    values.each do |v|
+      puts v unless v.nil?
+    end
+  end
+end
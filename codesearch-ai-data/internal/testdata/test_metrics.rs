@@ -0,0 +1,16 @@
+fn name(person: &Person) -> &str {
+    &person.name
+}
+
+fn classify(value: i32, strict: bool) -> Result<&'static str, Error> {
+    if value < 0 && strict {
+        return Ok("negative");
+    } else if value == 0 {
+        return Ok("zero");
+    }
+    let parsed = parse(value)?;
+    match parsed % 3 {
+        0 => Ok("fizz"),
+        _ => Ok("positive"),
+    }
+}
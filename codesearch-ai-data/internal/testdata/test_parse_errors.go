@@ -0,0 +1,24 @@
+package main
+
+import "fmt"
+
+func validBefore(a int, b int) int {
+	sum := a + b
+	fmt.Println(sum)
+	return sum
+}
+
+func broken(a int) int {
+	if a > 0 {
+		return a +
+	}
+	return 0
+}
+
+func validAfter(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,16 @@
+def valid_before(a, b):
+    total = a + b
+    print(total)
+    return total
+
+
+def broken(values):
+    result = values +* 2
+    return result
+
+
+def valid_after(values):
+    total = 0
+    for v in values:
+        total += v
+    return total
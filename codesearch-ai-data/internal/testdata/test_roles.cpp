@@ -0,0 +1,17 @@
+int parse(const std::string& text) {
+  return text.size();
+}
+
+TEST(ParserTest, Parses) {
+  EXPECT_EQ(parse("a"), 1);
+}
+
+TEST_F(ParserFixture, ParsesAll) {
+  EXPECT_EQ(parse(text), 1);
+}
+
+static void BM_Parse(benchmark::State& state) {
+  for (auto _ : state) {
+    parse("a");
+  }
+}
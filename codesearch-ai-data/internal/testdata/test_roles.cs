@@ -0,0 +1,37 @@
+class Parser
+{
+    public int Parse(string text)
+    {
+        return text.Length;
+    }
+}
+
+[TestFixture]
+class ParserTests
+{
+    private int Helper()
+    {
+        return 1;
+    }
+}
+
+class XunitParserTests
+{
+    [Fact]
+    public void Parses()
+    {
+        Assert.Equal(1, new Parser().Parse("a"));
+    }
+
+    [Xunit.TheoryAttribute, InlineData("a")]
+    public void ParsesAll(string text)
+    {
+        Assert.Equal(1, new Parser().Parse(text));
+    }
+
+    [Benchmark]
+    public int ParseSpeed()
+    {
+        return new Parser().Parse("a");
+    }
+}
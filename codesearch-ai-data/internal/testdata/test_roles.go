@@ -0,0 +1,32 @@
+package parser
+
+import "testing"
+
+func parse(input string) int {
+	return len(input)
+}
+
+func TestParse(t *testing.T) {
+	if parse("a") != 1 {
+		t.Fatal("expected 1")
+	}
+}
+
+func Testify() bool {
+	return true
+}
+
+func FuzzParse(f *testing.F) {
+	f.Fuzz(func(t *testing.T, input string) { parse(input) })
+}
+
+func BenchmarkParse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		parse("a")
+	}
+}
+
+func ExampleParse() {
+	parse("a")
+	// Output:
+}
@@ -0,0 +1,34 @@
+class Parser {
+    int parse(String text) {
+        return text.length();
+    }
+}
+
+class ParserTests {
+    @BeforeEach
+    void setUp() {
+        parser = new Parser();
+    }
+
+    @Test
+    void parses() {
+        assertEquals(1, parser.parse("a"));
+    }
+
+    @org.junit.jupiter.params.ParameterizedTest
+    @ValueSource(strings = {"a"})
+    void parsesAll(String text) {
+        assertEquals(1, parser.parse(text));
+    }
+
+    @Benchmark
+    public void parseSpeed(Blackhole blackhole) {
+        blackhole.consume(parser.parse("a"));
+    }
+}
+
+class LegacyParserTest extends junit.framework.TestCase {
+    public void checkParse() {
+        assertEquals(1, new Parser().parse("a"));
+    }
+}
@@ -0,0 +1,20 @@
+function parse(text) {
+  return text.length;
+}
+
+describe('parse', () => {
+  function helper() {
+    return parse('a');
+  }
+
+  it.each(['a'])('parses', (text) => {
+    expect(parse(text)).toBe(1);
+  });
+
+  bench('speed', () => {
+    parse('a');
+  });
+});
+
+const pattern = /a/;
+pattern.test('a');
@@ -0,0 +1,16 @@
+<?php
+class Parser {
+  public function parse($text) {
+    return strlen($text);
+  }
+
+  public function testable() {
+    return true;
+  }
+}
+
+class ParserTest extends \PHPUnit\Framework\TestCase {
+  public function setUp(): void {
+    $this->parser = new Parser();
+  }
+}
@@ -0,0 +1,21 @@
+import unittest
+
+
+def parse(text):
+    return len(text)
+
+
+def test_parse():
+    assert parse("a") == 1
+
+
+def test_parse_speed(benchmark):
+    benchmark(parse, "a")
+
+
+class ParserTest(unittest.TestCase):
+    def setUp(self):
+        self.text = "a"
+
+    def check(self):
+        self.assertEqual(parse(self.text), 1)
@@ -0,0 +1,19 @@
+def parse(text)
+  text.length
+end
+
+RSpec.describe Parser do
+  def helper
+    parse('a')
+  end
+end
+
+class ParserTest < Minitest::Test
+  def setup
+    @text = 'a'
+  end
+end
+
+def test_parse
+  parse('a')
+end
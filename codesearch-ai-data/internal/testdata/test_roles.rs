@@ -0,0 +1,26 @@
+fn parse(text: &str) -> usize {
+    text.len()
+}
+
+#[test]
+fn parses() {
+    assert_eq!(parse("a"), 1);
+}
+
+#[tokio::test]
+#[should_panic]
+async fn parses_async() {
+    assert_eq!(parse("a"), 2);
+}
+
+#[bench]
+fn parse_speed(b: &mut Bencher) {
+    b.iter(|| parse("a"));
+}
+
+#[cfg(test)]
+mod tests {
+    fn helper() -> usize {
+        parse("a")
+    }
+}
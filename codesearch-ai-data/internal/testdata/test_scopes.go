@@ -0,0 +1,13 @@
+package server
+
+func (s *Server) Handle(path string) {
+	s.routes[path] = true
+}
+
+func (c Config) Get(key string) string {
+	return c.values[key]
+}
+
+func Run() {
+	NewServer().Start()
+}
@@ -0,0 +1,13 @@
+package com.example.server;
+
+public class Outer {
+    public void run() {
+        start();
+    }
+
+    static class Inner {
+        String get(String key) {
+            return key;
+        }
+    }
+}
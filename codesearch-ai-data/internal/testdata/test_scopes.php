@@ -0,0 +1,21 @@
+<?php
+
+namespace App\Http;
+
+function helper() {
+    return 1;
+}
+
+class Controller {
+    public function handle($request) {
+        return $request;
+    }
+}
+
+namespace App\Console;
+
+class Kernel {
+    public function run() {
+        return 0;
+    }
+}
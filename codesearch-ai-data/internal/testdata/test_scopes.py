@@ -0,0 +1,12 @@
+def run():
+    return main()
+
+
+class Server:
+    class Handler:
+        def handle(self, request):
+            return request
+
+    @property
+    def get(self):
+        return self.value
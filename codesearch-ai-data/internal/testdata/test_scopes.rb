@@ -0,0 +1,15 @@
+def run
+  main
+end
+
+module Outer
+  class Inner
+    def handle(request)
+      request
+    end
+  end
+
+  def self.get
+    1
+  end
+end
@@ -0,0 +1,11 @@
+static int add(int a, int b) {
+    return a + b;
+}
+
+char **split(const char *text, char separator, size_t *count) {
+    return NULL;
+}
+
+void log_message(const char *format, ...) {
+    vprintf(format);
+}
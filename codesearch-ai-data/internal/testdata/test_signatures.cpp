@@ -0,0 +1,20 @@
+template <typename T>
+std::vector<T> filter(const std::vector<T> &items, bool (*predicate)(const T &)) {
+    return items;
+}
+
+int Parser::parse(const std::string &input, int flags = 0) {
+    return 0;
+}
+
+class Buffer {
+public:
+    static Buffer *create(size_t size) {
+        return new Buffer();
+    }
+
+private:
+    void grow(size_t extra) {
+        data.resize(extra);
+    }
+};
@@ -0,0 +1,22 @@
+public class Repository<T>
+{
+    public static async Task<List<T>> LoadAsync(string path, int limit = 10, params string[] tags)
+    {
+        return new List<T>();
+    }
+
+    private void Save(ref T item, out bool saved)
+    {
+        saved = true;
+    }
+
+    internal int Count()
+    {
+        return items.Count;
+    }
+
+    void Clear()
+    {
+        items.Clear();
+    }
+}
@@ -0,0 +1,14 @@
+package signatures
+
+// Sum adds the numbers.
+func Sum(a, b int, rest ...int) (total int, err error) {
+	return a + b, nil
+}
+
+func (s *Stack[T]) push(value T) {
+	s.items = append(s.items, value)
+}
+
+func (c Counter) Value() int {
+	return c.n
+}
@@ -0,0 +1,17 @@
+public class Repository<T> {
+    public static <K> Map<K, List<T>> groupBy(List<T> items, Function<T, K> key) {
+        return null;
+    }
+
+    private void save(final T item, String... tags) {
+        items.add(item);
+    }
+
+    protected int size() {
+        return items.size();
+    }
+
+    void clear() {
+        items.clear();
+    }
+}
@@ -0,0 +1,21 @@
+export async function load(url, { retries = 3 } = {}, ...handlers) {
+  return fetch(url)
+}
+
+class Queue {
+  static create(size = 10) {
+    return new Queue(size)
+  }
+
+  async *drain() {
+    yield 1
+  }
+
+  compact(items) {
+    return items.filter(item => item)
+  }
+}
+
+const handler = async (event) => {
+  return event
+}
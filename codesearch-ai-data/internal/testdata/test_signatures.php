@@ -0,0 +1,19 @@
+<?php
+
+function format_name(string $first, ?string $last = null, ...$rest): string {
+    return $first;
+}
+
+class Mailer {
+    public static function create(array $config): self {
+        return new self();
+    }
+
+    private function send(Message &$message, $retries = 3) {
+        return true;
+    }
+
+    function reset() {
+        $this->queue = [];
+    }
+}
@@ -0,0 +1,11 @@
+def parse(text: str, strict=False, *args, encoding: str = "utf-8", **kwargs) -> dict:
+    return {}
+
+
+class Client:
+    async def fetch(self, url, timeout: float = 1.0):
+        return None
+
+    @staticmethod
+    def _build_headers(token):
+        return {}
@@ -0,0 +1,17 @@
+pub fn parse(input: &str, strict: bool) -> Result<Config, Error> {
+    Ok(Config::default())
+}
+
+impl<T> Stack<T> {
+    pub(crate) fn push(&mut self, value: T) {
+        self.items.push(value);
+    }
+
+    pub async fn len(&self) -> usize {
+        self.items.len()
+    }
+
+    fn new() -> Self {
+        Stack { items: Vec::new() }
+    }
+}
@@ -0,0 +1,21 @@
+export async function load<T>(url: string, options?: RequestInit, ...rest: number[]): Promise<T> {
+  return fetch(url).then((r) => r.json())
+}
+
+class Queue<T> {
+  public static create(size: number = 10): Queue<number> {
+    return new Queue()
+  }
+
+  private push(item: T): void {
+    this.items.push(item)
+  }
+
+  protected async drain(): Promise<T[]> {
+    return []
+  }
+}
+
+const handler = async (event: Event): Promise<void> => {
+  console.log(event)
+}
@@ -0,0 +1,38 @@
+using System;
+
+namespace Example
+{
+    /// <summary>
+    /// A thread-safe <see cref="Counter"/> implementation.
+    /// </summary>
+    public class Counter
+    {
+        private int count;
+
+        public int Count { get { return count; } }
+
+        public void Increment()
+        {
+            count++;
+        }
+    }
+
+    // A point in 2D space.
+    public struct Point
+    {
+        public int X;
+        public int Y;
+    }
+
+    public interface IShape
+    {
+        double Area();
+        string Name { get; }
+    }
+
+    public enum Direction
+    {
+        North,
+        South,
+    }
+}
@@ -0,0 +1,33 @@
+package shapes
+
+import "math"
+
+// Shape is anything with an area.
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+}
+
+// Circle is a round shape.
+type Circle struct {
+	X, Y   float64
+	Radius float64
+}
+
+func (c *Circle) Area() float64 {
+	return math.Pi * c.Radius * c.Radius
+}
+
+func (c Circle) Perimeter() float64 {
+	return 2 * math.Pi * c.Radius
+}
+
+type (
+	// Celsius is a temperature.
+	Celsius float64
+	Names   []string
+)
+
+func (c Celsius) Fahrenheit() float64 {
+	return float64(c)*9/5 + 32
+}
@@ -0,0 +1,45 @@
+package com.example.cache;
+
+import java.util.LinkedHashMap;
+import java.util.Map;
+
+/**
+ * A thread-safe LRU cache.
+ */
+public class LruCache<K, V> {
+    private final int capacity;
+    private final Map<K, V> entries = new LinkedHashMap<>();
+
+    public LruCache(int capacity) {
+        this.capacity = capacity;
+    }
+
+    public synchronized V get(K key) {
+        return entries.get(key);
+    }
+
+    public synchronized void put(K key, V value) {
+        entries.put(key, value);
+    }
+
+    /** Entry of the cache. */
+    static class Entry {
+        String key;
+        String value;
+    }
+}
+
+// Evicts entries from a cache.
+interface EvictionPolicy {
+    void evict();
+    boolean shouldEvict(int size);
+}
+
+enum Color {
+    RED,
+    GREEN;
+
+    String lower() {
+        return name().toLowerCase();
+    }
+}
@@ -0,0 +1,26 @@
+/**
+ * An event emitter.
+ */
+class Emitter {
+  listeners = {};
+
+  on(event, listener) {
+    (this.listeners[event] ||= []).push(listener);
+  }
+
+  emit(event, ...args) {
+    for (const listener of this.listeners[event] || []) {
+      listener(...args);
+    }
+  }
+}
+
+export class Store extends Emitter {
+  static create() {
+    return new Store();
+  }
+
+  get state() {
+    return this._state;
+  }
+}
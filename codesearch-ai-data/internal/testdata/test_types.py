@@ -0,0 +1,25 @@
+class LRUCache:
+    """A least recently used cache."""
+
+    capacity = 128
+
+    def __init__(self, capacity):
+        self.capacity = capacity
+        self.entries = {}
+
+    @property
+    def size(self):
+        return len(self.entries)
+
+    def get(self, key):
+        # Move to the end
+        return self.entries.get(key)
+
+    class Entry:
+        def __init__(self, key):
+            self.key = key
+
+
+# Not a docstring
+class Empty(Exception):
+    pass
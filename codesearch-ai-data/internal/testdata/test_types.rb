@@ -0,0 +1,23 @@
+# Helpers for formatting values.
+module Formatting
+  def self.money(amount)
+    format('%.2f', amount)
+  end
+
+  def percent(value)
+    "#{value * 100}%"
+  end
+
+  # A formatted table.
+  class Table
+    attr_reader :rows
+
+    def initialize(rows)
+      @rows = rows
+    end
+
+    def render
+      rows.map(&:to_s).join("\n")
+    end
+  end
+end
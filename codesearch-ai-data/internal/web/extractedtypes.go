@@ -0,0 +1,8 @@
+package web
+
+type HighlightedExtractedType struct {
+	HighlightedExtractedFunction
+	Kind       string   `json:"kind"`
+	Identifier string   `json:"identifier"`
+	Members    []string `json:"members"`
+}
@@ -11,6 +11,7 @@ columns_to_remove = [
     "query",
     "soQuestionId",
     "extractedFunctionId",
+    "extractedTypeId",
 ]
 
 